@@ -0,0 +1,56 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// CanonicalProduct is the catalogue item that listings of different stores are matched to,
+// so that a buyer can compare the offers of every store for the same item.
+// Listings are matched by GTIN (barcode), by normalized title or by the canonical product declared by the seller.
+type CanonicalProduct struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	Title           string         `json:"title" gorm:"size:100;not null"`
+	NormalizedTitle string         `json:"-" gorm:"size:255;not null;index"`
+	GTIN            string         `json:"gtin,omitempty" gorm:"size:14;index"`
+	CategoryID      uint           `json:"category_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (CanonicalProduct) TableName() string {
+	return "productapp_canonicalproduct"
+}
+
+// ProductOffer is a listing of a canonical product in one store.
+type ProductOffer struct {
+	ProductID    uint    `json:"product_id"`
+	Title        string  `json:"title"`
+	StoreID      uint    `json:"store_id"`
+	StoreName    string  `json:"store_name"`
+	Price        float64 `json:"price"`
+	Amount       uint    `json:"amount"`
+	InStock      bool    `json:"in_stock"`
+	StoreRating  float64 `json:"store_rating"`
+	ReviewsCount int64   `json:"reviews_count"`
+}
+
+// CanonicalProductOffers is a canonical product with the offers of all stores.
+type CanonicalProductOffers struct {
+	Product      CanonicalProduct `json:"product"`
+	Offers       []ProductOffer   `json:"offers"`
+	MinPrice     float64          `json:"min_price"`
+	MaxPrice     float64          `json:"max_price"`
+	InStockCount int              `json:"in_stock_count"`
+}
+
+// CanonicalProductSummary is a canonical product found by name with its best in-stock price.
+type CanonicalProductSummary struct {
+	ID          uint    `json:"id"`
+	Title       string  `json:"title"`
+	GTIN        string  `json:"gtin,omitempty"`
+	CategoryID  uint    `json:"category_id"`
+	MinPrice    float64 `json:"min_price"`
+	OffersCount int64   `json:"offers_count"`
+}
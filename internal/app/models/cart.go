@@ -0,0 +1,48 @@
+package models
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+// CartItem represents a product line in a user's shopping cart.
+// The same variant with different modifiers makes different lines, ModifierKey is the sorted list of modifier IDs.
+type CartItem struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	UserID      uint           `json:"user_id" gorm:"not null;uniqueIndex:idx_cart_user_variant_modifiers"`
+	User        User           `json:"-" gorm:"foreignKey:UserID"`
+	ProductID   uint           `json:"product_id" gorm:"not null"`
+	Product     Product        `json:"-" gorm:"foreignKey:ProductID"`
+	VariantID   uint           `json:"variant_id" gorm:"uniqueIndex:idx_cart_user_variant_modifiers"`
+	Variant     ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+	ModifierIDs pq.Int64Array  `json:"modifier_ids" gorm:"type:bigint[]"`
+	ModifierKey string         `json:"-" gorm:"size:255;not null;default:'';uniqueIndex:idx_cart_user_variant_modifiers"`
+	Quantity    uint           `json:"quantity" gorm:"not null;default:1"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// CartLine is a cart item enriched with the current product title, variant price and chosen modifiers.
+// Price is the unit price including the modifier price deltas.
+type CartLine struct {
+	ProductID    uint                `json:"product_id"`
+	VariantID    uint                `json:"variant_id"`
+	SKU          string              `json:"sku"`
+	Title        string              `json:"title"`
+	VariantTitle string              `json:"variant_title"`
+	Modifiers    []OrderItemModifier `json:"modifiers,omitempty"`
+	Price        float64             `json:"price"`
+	Quantity     uint                `json:"quantity"`
+	Subtotal     float64             `json:"subtotal"`
+}
+
+// Cart represents the cart of a user with its computed total.
+type Cart struct {
+	UserID uint       `json:"user_id"`
+	Items  []CartLine `json:"items"`
+	Total  float64    `json:"total"`
+}
+
+func (CartItem) TableName() string {
+	return "cartapp_cartitem"
+}
@@ -0,0 +1,7 @@
+package models
+
+// CategoryNode is a category with its subcategories in the category tree.
+type CategoryNode struct {
+	Category
+	Children []*CategoryNode `json:"children"`
+}
@@ -3,10 +3,15 @@ package models
 import "time"
 
 type Configs struct {
-	LogParams      LogParams      `json:"log_params"`
-	AppParams      AppParams      `json:"app_params"`
-	PostgresParams PostgresParams `json:"postgres_params"`
-	Auth           Auth           `json:"auth"`
+	LogParams          LogParams          `json:"log_params"`
+	AppParams          AppParams          `json:"app_params"`
+	PostgresParams     PostgresParams     `json:"postgres_params"`
+	Auth               Auth               `json:"auth"`
+	OrderParams        OrderParams        `json:"order_params"`
+	CacheParams        CacheParams        `json:"cache_params"`
+	StorageParams      StorageParams      `json:"storage_params"`
+	PasswordHashParams PasswordHashParams `json:"password_hash_params"`
+	MailParams         MailParams         `json:"mail_params"`
 }
 
 type LogParams struct {
@@ -42,3 +47,52 @@ type Auth struct {
 	JwtSecretKey  string        `json:"jwt_secret_key"`
 	JwtTtlMinutes time.Duration `json:"jwt_ttl_minutes"`
 }
+
+// CacheParams selects the cache backend: "redis" (default) or "memory".
+// The in-memory cache is local to the process and is meant for local and test runs.
+type CacheParams struct {
+	Backend    string `json:"backend"`
+	Host       string `json:"host"`
+	Port       string `json:"port"`
+	DB         int    `json:"db"`
+	MaxEntries int    `json:"max_entries"`
+}
+
+// StorageParams selects where uploaded images are stored: "local" (default) or "s3".
+// PublicURL is the address files are served from, for the local backend files are served by the app under /media.
+type StorageParams struct {
+	Backend            string `json:"backend"`
+	PublicURL          string `json:"public_url"`
+	LocalDirectory     string `json:"local_directory"`
+	S3Endpoint         string `json:"s3_endpoint"`
+	S3Region           string `json:"s3_region"`
+	S3Bucket           string `json:"s3_bucket"`
+	S3AccessKey        string `json:"s3_access_key"`
+	MaxUploadMegabytes int    `json:"max_upload_megabytes"`
+	MaxProductImages   int    `json:"max_product_images"`
+}
+
+// PasswordHashParams sets the argon2id cost of password hashes, zero values use the defaults (64 MiB, 3 iterations, 2 threads).
+// Hashes created with other parameters are recomputed on the next successful sign in.
+type PasswordHashParams struct {
+	MemoryKiB   uint32 `json:"memory_kib"`
+	Iterations  uint32 `json:"iterations"`
+	Parallelism uint8  `json:"parallelism"`
+}
+
+// MailParams selects how mail is sent: "file" (default) writes messages to Directory for local and test runs, "smtp" sends them.
+// AppURL is the address of the client app, links in verification and password reset emails point to it.
+type MailParams struct {
+	Backend   string `json:"backend"`
+	Host      string `json:"host"`
+	Port      string `json:"port"`
+	Username  string `json:"username"`
+	From      string `json:"from"`
+	Directory string `json:"directory"`
+	AppURL    string `json:"app_url"`
+}
+
+type OrderParams struct {
+	ReservationTTLMinutes           int `json:"reservation_ttl_minutes"`
+	ReservationCheckIntervalSeconds int `json:"reservation_check_interval_seconds"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Idempotency key states
+const (
+	IdempotencyStatusProcessing = "processing"
+	IdempotencyStatusCompleted  = "completed"
+)
+
+// IdempotencyKey stores the fingerprint of a request sent with an Idempotency-Key header and the response it produced.
+// Keys being processed are locked in Redis, the table is used when Redis is unavailable.
+// Responses are always stored in the table and cached in Redis.
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_idempotency_user_key"`
+	Key          string    `json:"key" gorm:"size:255;not null;uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint  string    `json:"fingerprint" gorm:"size:64;not null"`
+	Status       string    `json:"status" gorm:"size:20;not null"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  string    `json:"content_type" gorm:"size:100"`
+	ResponseBody string    `json:"response_body" gorm:"type:text"`
+	ExpiresAt    time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotencyapp_idempotencykey"
+}
@@ -0,0 +1,12 @@
+package models
+
+// UploadedImage describes a stored image and its generated thumbnails.
+type UploadedImage struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	// Thumbnails maps a thumbnail size (small, medium, large) to its URL.
+	Thumbnails map[string]string `json:"thumbnails"`
+}
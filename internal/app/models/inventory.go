@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Inventory movement kinds
+const (
+	MovementReceipt            = "receipt"
+	MovementSale               = "sale"
+	MovementReturn             = "return"
+	MovementAdjustment         = "adjustment"
+	MovementReservationRelease = "reservation_release"
+)
+
+// InventoryMovement records a single change of the stock of a product variant.
+// Quantity is signed: positive values add stock, negative values take it away.
+type InventoryMovement struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ProductID    uint           `json:"product_id" gorm:"not null;index"`
+	Product      Product        `json:"-" gorm:"foreignKey:ProductID"`
+	VariantID    uint           `json:"variant_id" gorm:"index"`
+	Variant      ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+	Kind         string         `json:"kind" gorm:"size:30;not null"`
+	Quantity     int            `json:"quantity" gorm:"not null"`
+	BalanceAfter uint           `json:"balance_after" gorm:"not null"`
+	OrderID      *uint          `json:"order_id,omitempty" gorm:"index"`
+	UserID       *uint          `json:"user_id,omitempty"`
+	User         *User          `json:"-" gorm:"foreignKey:UserID"`
+	Reason       string         `json:"reason"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
+
+func (InventoryMovement) TableName() string {
+	return "inventoryapp_inventorymovement"
+}
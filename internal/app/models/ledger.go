@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Journal entry kinds
+const (
+	JournalKindTopUp    = "top_up"
+	JournalKindPurchase = "purchase"
+	JournalKindPayout   = "payout"
+	JournalKindRefund   = "refund"
+	// JournalKindOpeningBalance переносит в журнал баланс счёта, созданного до появления журнала
+	JournalKindOpeningBalance = "opening_balance"
+)
+
+// Posting directions. Credit increases an account balance, debit decreases it.
+const (
+	PostingDebit  = "debit"
+	PostingCredit = "credit"
+)
+
+// System accounts are ledger sides that don't belong to any user Account.
+const (
+	SystemAccountExternal   = "external"   // деньги, пришедшие извне платформы (пополнения)
+	SystemAccountSettlement = "settlement" // транзитный счёт между покупателем и продавцом
+)
+
+// JournalEntry represents a single balanced money movement made of several postings.
+type JournalEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Kind        string    `json:"kind" gorm:"size:30;not null;index"`
+	PaymentID   *uint     `json:"payment_id,omitempty" gorm:"index"`
+	Description string    `json:"description"`
+	Postings    []Posting `json:"postings,omitempty" gorm:"foreignKey:JournalEntryID"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Posting represents one side of a journal entry. Amount is kept in minor units (cents).
+type Posting struct {
+	ID             uint          `json:"id" gorm:"primaryKey"`
+	JournalEntryID uint          `json:"journal_entry_id" gorm:"not null;index"`
+	JournalEntry   *JournalEntry `json:"journal_entry,omitempty" gorm:"foreignKey:JournalEntryID"`
+	AccountID      *uint         `json:"account_id,omitempty" gorm:"index"`
+	Account        *Account      `json:"-" gorm:"foreignKey:AccountID"`
+	SystemAccount  string        `json:"system_account,omitempty" gorm:"size:30"`
+	Direction      string        `json:"direction" gorm:"size:6;not null"`
+	Amount         int64         `json:"amount" gorm:"not null;check:amount > 0"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// AccountReconciliation describes an account whose stored balance diverges from its postings.
+type AccountReconciliation struct {
+	AccountID     uint    `json:"account_id"`
+	AccountNumber string  `json:"account_number"`
+	StoredBalance float64 `json:"stored_balance"`
+	LedgerBalance float64 `json:"ledger_balance"`
+	Difference    float64 `json:"difference"`
+}
+
+func (JournalEntry) TableName() string {
+	return "ledgerapp_journalentry"
+}
+
+func (Posting) TableName() string {
+	return "ledgerapp_posting"
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Order status codes
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusPaid      = "paid"
+	OrderStatusPreparing = "preparing"
+	OrderStatusShipped   = "shipped"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRefunded  = "refunded"
+	OrderStatusExpired   = "expired"
+)
+
+// DefaultOrderStatuses are the statuses known to the order state machine, they are seeded on migration.
+var DefaultOrderStatuses = []OrderStatus{
+	{Code: OrderStatusPending, StatusName: OrderStatusPending, Description: "Order is created and waits for payment"},
+	{Code: OrderStatusPaid, StatusName: OrderStatusPaid, Description: "Order is paid"},
+	{Code: OrderStatusPreparing, StatusName: OrderStatusPreparing, Description: "Seller is preparing the order"},
+	{Code: OrderStatusShipped, StatusName: OrderStatusShipped, Description: "Order is on its way to the buyer"},
+	{Code: OrderStatusDelivered, StatusName: OrderStatusDelivered, Description: "Order is delivered to the buyer"},
+	{Code: OrderStatusCancelled, StatusName: OrderStatusCancelled, Description: "Order is cancelled before payment"},
+	{Code: OrderStatusRefunded, StatusName: OrderStatusRefunded, Description: "Money for the order is fully returned to the buyer"},
+	{Code: OrderStatusExpired, StatusName: OrderStatusExpired, Description: "Order was not paid in time and its stock reservation is released"},
+}
+
+// OrderStatusHistory records a single status transition of an order or of the items of one of its stores.
+type OrderStatusHistory struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	OrderID      uint         `json:"order_id" gorm:"not null;index"`
+	Order        Order        `json:"-" gorm:"foreignKey:OrderID"`
+	FromStatusID *uint        `json:"from_status_id,omitempty"`
+	FromStatus   *OrderStatus `json:"from_status,omitempty" gorm:"foreignKey:FromStatusID"`
+	ToStatusID   uint         `json:"to_status_id" gorm:"not null"`
+	ToStatus     OrderStatus  `json:"to_status" gorm:"foreignKey:ToStatusID"`
+	ChangedByID  *uint        `json:"changed_by_id,omitempty"`
+	ChangedBy    *User        `json:"-" gorm:"foreignKey:ChangedByID"`
+	StoreID      *uint        `json:"store_id,omitempty"` // set when a store moved only its own items of the order
+	Actor        string       `json:"actor" gorm:"size:20;not null"`
+	Reason       string       `json:"reason"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "orderapp_orderstatushistory"
+}
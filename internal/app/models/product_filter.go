@@ -0,0 +1,12 @@
+package models
+
+// ProductFilter holds the filters of the product list.
+// Options (e.g. size=42, color=black) match products that have an in-stock variant with all of the given values.
+type ProductFilter struct {
+	MinPrice    float64
+	MaxPrice    float64
+	CategoryID  uint
+	StoreID     uint
+	ProductName string
+	Options     map[string]string
+}
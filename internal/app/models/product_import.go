@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Product import statuses
+const (
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ProductImportColumns is the header of import and export files, the columns may come in any order.
+// Images are URLs separated by "|". Every row is a product variant identified by its SKU.
+var ProductImportColumns = []string{
+	"sku", "title", "description", "category_id", "price", "amount", "low_stock_threshold", "gtin", "images",
+}
+
+// ProductImport is a bulk import of products into a store from a CSV or XLSX file, processed in the background.
+// FileData keeps the uploaded file until the import finishes, so that an import interrupted by a restart
+// is picked up by another worker and resumed after ProcessedRows. Attempts counts how many times it was started.
+type ProductImport struct {
+	ID            uint                 `json:"id" gorm:"primaryKey"`
+	StoreID       uint                 `json:"store_id" gorm:"not null;index"`
+	Store         Store                `json:"-" gorm:"foreignKey:StoreID"`
+	UserID        uint                 `json:"user_id" gorm:"not null"`
+	User          User                 `json:"-" gorm:"foreignKey:UserID"`
+	FileName      string               `json:"file_name" gorm:"size:255"`
+	Format        string               `json:"format" gorm:"size:10;not null"`
+	Status        string               `json:"status" gorm:"size:20;not null;index"`
+	TotalRows     int                  `json:"total_rows" gorm:"not null;default:0"`
+	ProcessedRows int                  `json:"processed_rows" gorm:"not null;default:0"`
+	CreatedCount  int                  `json:"created_count" gorm:"not null;default:0"`
+	UpdatedCount  int                  `json:"updated_count" gorm:"not null;default:0"`
+	FailedCount   int                  `json:"failed_count" gorm:"not null;default:0"`
+	Error         string               `json:"error,omitempty"`
+	FileData      []byte               `json:"-" gorm:"type:bytea"`
+	Attempts      int                  `json:"-" gorm:"not null;default:0"`
+	Errors        []ProductImportError `json:"errors,omitempty" gorm:"foreignKey:ImportID"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+	FinishedAt    *time.Time           `json:"finished_at,omitempty"`
+}
+
+// ProductImportError describes why a row of an import was skipped, Row is the line number in the file
+// and Column is the column with the invalid value, if the error is about a single value.
+type ProductImportError struct {
+	ID       uint   `json:"-" gorm:"primaryKey"`
+	ImportID uint   `json:"-" gorm:"not null;index"`
+	Row      int    `json:"row" gorm:"not null"`
+	SKU      string `json:"sku" gorm:"size:64"`
+	Column   string `json:"column,omitempty" gorm:"size:50"`
+	Message  string `json:"message" gorm:"not null"`
+}
+
+func (ProductImport) TableName() string {
+	return "productapp_productimport"
+}
+
+func (ProductImportError) TableName() string {
+	return "productapp_productimporterror"
+}
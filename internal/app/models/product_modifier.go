@@ -0,0 +1,60 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ModifierGroup is a set of choices a buyer makes for a product, e.g. "Toppings" or "Remove ingredients".
+// A required group needs at least one selection, MinSelections and MaxSelections (0 — no limit) bound the count.
+type ModifierGroup struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	ProductID     uint           `json:"product_id" gorm:"not null;index"`
+	Product       Product        `json:"-" gorm:"foreignKey:ProductID"`
+	Name          string         `json:"name" gorm:"size:100;not null"`
+	IsRequired    bool           `json:"is_required" gorm:"not null;default:false"`
+	MinSelections uint           `json:"min_selections" gorm:"not null;default:0"`
+	MaxSelections uint           `json:"max_selections" gorm:"not null;default:0"`
+	Position      uint           `json:"position" gorm:"not null;default:0"`
+	Modifiers     []Modifier     `json:"modifiers" gorm:"foreignKey:GroupID"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (ModifierGroup) TableName() string {
+	return "productapp_modifiergroup"
+}
+
+// Modifier is a single choice of a group, e.g. "Extra cheese" for +1.50 or "No onions" for free.
+// PriceDelta is added to the unit price of the order line and may be negative.
+type Modifier struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	GroupID    uint           `json:"group_id" gorm:"not null;index"`
+	Name       string         `json:"name" gorm:"size:100;not null"`
+	PriceDelta float64        `json:"price_delta" gorm:"not null;default:0"`
+	SoldOut    bool           `json:"sold_out" gorm:"not null;default:false"`
+	Position   uint           `json:"position" gorm:"not null;default:0"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (Modifier) TableName() string {
+	return "productapp_modifier"
+}
+
+// OrderItemModifier is a modifier chosen for an order line.
+// Names and price are snapshotted at order time so later menu edits don't rewrite history.
+type OrderItemModifier struct {
+	ID          uint    `json:"-" gorm:"primaryKey"`
+	OrderItemID uint    `json:"-" gorm:"not null;index"`
+	ModifierID  uint    `json:"modifier_id" gorm:"not null"`
+	GroupName   string  `json:"group_name" gorm:"size:100;not null"`
+	Name        string  `json:"name" gorm:"size:100;not null"`
+	PriceDelta  float64 `json:"price_delta" gorm:"not null"`
+}
+
+func (OrderItemModifier) TableName() string {
+	return "orderapp_orderitemmodifier"
+}
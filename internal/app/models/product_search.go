@@ -0,0 +1,59 @@
+package models
+
+import "github.com/lib/pq"
+
+// ProductSearchParams describes a full-text product search request.
+type ProductSearchParams struct {
+	Query      string
+	CategoryID uint
+	StoreID    uint
+	MinPrice   float64
+	MaxPrice   float64
+	Limit      int
+	Offset     int
+}
+
+// ProductSearchHit is a product matched by a search with its relevance and a highlighted snippet.
+type ProductSearchHit struct {
+	ID               uint           `json:"id"`
+	StoreID          uint           `json:"store_id"`
+	StoreName        string         `json:"store_name"`
+	CategoryID       uint           `json:"category_id"`
+	CategoryName     string         `json:"category_name"`
+	Title            string         `json:"title"`
+	Price            float64        `json:"price"`
+	Amount           uint           `json:"amount"`
+	ProductImageList pq.StringArray `json:"product_image" gorm:"type:text[]"`
+	Rank             float64        `json:"rank"`
+	Snippet          string         `json:"snippet"`
+}
+
+// FacetCount is the number of matched products with the given category or store.
+type FacetCount struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// PriceBucketCount is the number of matched products in a price range, To is 0 for the last open range.
+type PriceBucketCount struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int64   `json:"count"`
+}
+
+// ProductSearchFacets holds counts of the matched products by category, store and price range.
+// Each facet ignores its own filter, so the buyer sees how many products the other choices would give.
+type ProductSearchFacets struct {
+	Categories   []FacetCount       `json:"categories"`
+	Stores       []FacetCount       `json:"stores"`
+	PriceBuckets []PriceBucketCount `json:"price_buckets"`
+}
+
+// ProductSearchResult is a page of search hits with the total number of matches and facets.
+type ProductSearchResult struct {
+	Query  string              `json:"query"`
+	Total  int64               `json:"total"`
+	Items  []ProductSearchHit  `json:"items"`
+	Facets ProductSearchFacets `json:"facets"`
+}
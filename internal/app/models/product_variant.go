@@ -0,0 +1,45 @@
+package models
+
+import (
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ProductVariant is a sellable version of a product (e.g. size 42 in black) with its own SKU, price, stock and images.
+// Every product has at least one variant, products created without variants get a single default one.
+// Product.Price and Product.Amount mirror the lowest variant price and the total variant stock.
+// StoreID mirrors Product.StoreID: a SKU is unique among the live variants of one store, so different sellers
+// may use the same SKU and a deleted variant frees its SKU.
+type ProductVariant struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	ProductID uint            `json:"product_id" gorm:"not null;index"`
+	Product   Product         `json:"-" gorm:"foreignKey:ProductID"`
+	StoreID   uint            `json:"-" gorm:"not null;default:0;uniqueIndex:idx_variant_store_sku,where:deleted_at IS NULL"`
+	SKU       string          `json:"sku" gorm:"size:64;not null;uniqueIndex:idx_variant_store_sku,where:deleted_at IS NULL"`
+	Title     string          `json:"title" gorm:"size:100"`
+	Price     float64         `json:"price" gorm:"not null"`
+	Amount    uint            `json:"amount" gorm:"not null"`
+	IsDefault bool            `json:"is_default" gorm:"not null;default:false"`
+	Images    pq.StringArray  `json:"images" gorm:"type:text[]"`
+	Options   []VariantOption `json:"options" gorm:"foreignKey:VariantID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	DeletedAt gorm.DeletedAt  `json:"-" gorm:"index"`
+}
+
+func (ProductVariant) TableName() string {
+	return "productapp_productvariant"
+}
+
+// VariantOption is the value of a variant on one option axis, e.g. size = 42.
+type VariantOption struct {
+	ID        uint   `json:"-" gorm:"primaryKey"`
+	VariantID uint   `json:"-" gorm:"not null;uniqueIndex:idx_variant_option_name"`
+	Name      string `json:"name" gorm:"size:50;not null;uniqueIndex:idx_variant_option_name;index:idx_variant_option_value"`
+	Value     string `json:"value" gorm:"size:100;not null;index:idx_variant_option_value"`
+}
+
+func (VariantOption) TableName() string {
+	return "productapp_variantoption"
+}
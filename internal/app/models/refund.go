@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Refund represents money returned to the buyer against a Payment.
+type Refund struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	PaymentID   uint         `json:"payment_id" gorm:"not null;index"`
+	Payment     Payment      `json:"-" gorm:"foreignKey:PaymentID"`
+	OrderID     uint         `json:"order_id" gorm:"not null;index"`
+	Order       Order        `json:"-" gorm:"foreignKey:OrderID"`
+	InitiatorID uint         `json:"initiator_id" gorm:"not null"`
+	Initiator   User         `json:"-" gorm:"foreignKey:InitiatorID"`
+	Amount      float64      `json:"amount" gorm:"not null"`
+	Reason      string       `json:"reason"`
+	Items       []RefundItem `json:"items" gorm:"foreignKey:RefundID"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// RefundItem represents the refunded quantity of a single order line.
+type RefundItem struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RefundID    uint      `json:"refund_id" gorm:"not null;index"`
+	OrderItemID uint      `json:"order_item_id" gorm:"not null"`
+	OrderItem   OrderItem `json:"-" gorm:"foreignKey:OrderItemID"`
+	Quantity    uint      `json:"quantity" gorm:"not null"`
+	Amount      float64   `json:"amount" gorm:"not null"`
+}
+
+func (Refund) TableName() string {
+	return "refundapp_refund"
+}
+
+func (RefundItem) TableName() string {
+	return "refundapp_refunditem"
+}
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// Role codes
+const (
+	RolePlatformAdmin = "platform_admin"
+	RoleModerator     = "moderator"
+	RoleSupport       = "support"
+	RoleSeller        = "seller"
+	RoleBuyer         = "buyer"
+)
+
+// Permission codes checked by routes
+const (
+	PermissionManageCategories    = "categories.manage"
+	PermissionManageOrderStatuses = "order_statuses.manage"
+	PermissionReconcileLedger     = "ledger.reconcile"
+	PermissionViewRoles           = "roles.view"
+	PermissionManageRoles         = "roles.manage"
+)
+
+// Role grant audit actions
+const (
+	RoleAuditGrant  = "grant"
+	RoleAuditRevoke = "revoke"
+)
+
+// DefaultPermissions are the permissions known to the routes, they are seeded on migration.
+var DefaultPermissions = []Permission{
+	{Code: PermissionManageCategories, Description: "Create, update, move and delete categories"},
+	{Code: PermissionManageOrderStatuses, Description: "Update order statuses"},
+	{Code: PermissionReconcileLedger, Description: "Reconcile account balances with the ledger"},
+	{Code: PermissionViewRoles, Description: "View roles of users and the role audit trail"},
+	{Code: PermissionManageRoles, Description: "Grant and revoke roles"},
+}
+
+// DefaultRoles are the roles with their permissions, they are seeded on migration.
+// Sign up grants the buyer role and creating a store grants the seller role.
+var DefaultRoles = []Role{
+	{Code: RolePlatformAdmin, Name: "Platform admin", Description: "Manages the whole marketplace", Permissions: permissions(
+		PermissionManageCategories, PermissionManageOrderStatuses, PermissionReconcileLedger, PermissionViewRoles, PermissionManageRoles)},
+	{Code: RoleModerator, Name: "Moderator", Description: "Maintains the catalog", Permissions: permissions(PermissionManageCategories)},
+	{Code: RoleSupport, Name: "Support", Description: "Helps users with their accounts and orders", Permissions: permissions(PermissionViewRoles)},
+	{Code: RoleSeller, Name: "Seller", Description: "Owns a store"},
+	{Code: RoleBuyer, Name: "Buyer", Description: "Buys products"},
+}
+
+func permissions(codes ...string) []Permission {
+	result := make([]Permission, 0, len(codes))
+	for _, code := range codes {
+		result = append(result, Permission{Code: code})
+	}
+	return result
+}
+
+// Permission is a named action a route can require.
+type Permission struct {
+	ID          uint   `json:"-" gorm:"primaryKey"`
+	Code        string `json:"code" gorm:"size:50;unique;not null"`
+	Description string `json:"description"`
+}
+
+// Role is a set of permissions granted to users.
+type Role struct {
+	ID          uint         `json:"-" gorm:"primaryKey"`
+	Code        string       `json:"code" gorm:"size:50;unique;not null"`
+	Name        string       `json:"name" gorm:"size:100;not null"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:userapp_rolepermission"`
+}
+
+// UserRole is a role granted to a user.
+type UserRole struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_role"`
+	User        User      `json:"-" gorm:"foreignKey:UserID"`
+	RoleID      uint      `json:"-" gorm:"not null;uniqueIndex:idx_user_role"`
+	Role        Role      `json:"role" gorm:"foreignKey:RoleID"`
+	GrantedByID *uint     `json:"granted_by_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RoleAudit records a grant or revocation of a role. ActorID is empty for roles granted by the system, e.g. on sign up.
+type RoleAudit struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	RoleCode  string    `json:"role" gorm:"size:50;not null"`
+	Action    string    `json:"action" gorm:"size:10;not null"`
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// RoleGrantRequest is the body of a role grant.
+type RoleGrantRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+func (Permission) TableName() string {
+	return "userapp_permission"
+}
+
+func (Role) TableName() string {
+	return "userapp_role"
+}
+
+func (UserRole) TableName() string {
+	return "userapp_userrole"
+}
+
+func (RoleAudit) TableName() string {
+	return "userapp_roleaudit"
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ProductSalesStats holds denormalized sales counters of a product.
+// The counters are updated in the same transactions that place, delete, pay and refund orders
+// and are periodically rebuilt from the orders with aggregate queries.
+type ProductSalesStats struct {
+	ProductID       uint      `json:"product_id" gorm:"primaryKey;autoIncrement:false"`
+	Product         Product   `json:"-" gorm:"foreignKey:ProductID"`
+	OrdersCount     int64     `json:"orders_count" gorm:"not null;default:0;index"`
+	PaidOrdersCount int64     `json:"paid_orders_count" gorm:"not null;default:0"`
+	UnitsSold       int64     `json:"units_sold" gorm:"not null;default:0"`
+	Revenue         float64   `json:"revenue" gorm:"not null;default:0"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// StoreSalesStats holds denormalized sales counters of a store, an order is counted once per store.
+type StoreSalesStats struct {
+	StoreID         uint      `json:"store_id" gorm:"primaryKey;autoIncrement:false"`
+	Store           Store     `json:"-" gorm:"foreignKey:StoreID"`
+	OrdersCount     int64     `json:"orders_count" gorm:"not null;default:0"`
+	PaidOrdersCount int64     `json:"paid_orders_count" gorm:"not null;default:0"`
+	UnitsSold       int64     `json:"units_sold" gorm:"not null;default:0"`
+	Revenue         float64   `json:"revenue" gorm:"not null;default:0"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (ProductSalesStats) TableName() string {
+	return "productapp_productsalesstats"
+}
+
+func (StoreSalesStats) TableName() string {
+	return "store_sales_stats"
+}
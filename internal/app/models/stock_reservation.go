@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Stock reservation states
+const (
+	ReservationActive    = "active"
+	ReservationConverted = "converted"
+	ReservationReleased  = "released"
+)
+
+// StockReservation holds products of an unpaid order line until ExpiresAt.
+// The reserved quantity is taken from Product.Amount when the order is created, payment converts the reservation
+// into a sale and an expired or cancelled order releases it back to stock.
+type StockReservation struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	OrderID     uint           `json:"order_id" gorm:"not null;index"`
+	Order       Order          `json:"-" gorm:"foreignKey:OrderID"`
+	OrderItemID uint           `json:"order_item_id" gorm:"not null;uniqueIndex"`
+	OrderItem   OrderItem      `json:"-" gorm:"foreignKey:OrderItemID"`
+	ProductID   uint           `json:"product_id" gorm:"not null"`
+	Product     Product        `json:"-" gorm:"foreignKey:ProductID"`
+	VariantID   uint           `json:"variant_id"`
+	Variant     ProductVariant `json:"-" gorm:"foreignKey:VariantID"`
+	Quantity    uint           `json:"quantity" gorm:"not null"`
+	Status      string         `json:"status" gorm:"size:20;not null;index:idx_reservation_status_expires"`
+	ExpiresAt   time.Time      `json:"expires_at" gorm:"not null;index:idx_reservation_status_expires"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (StockReservation) TableName() string {
+	return "orderapp_stockreservation"
+}
@@ -9,6 +9,7 @@ type Store struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
 	Name        string         `json:"name" gorm:"unique;not null"`
 	Description string         `json:"description"`
+	ImageURL    string         `json:"image_url"`
 	OwnerID     uint           `json:"owner_id" gorm:"not null"`
 	Owner       User           `json:"-" gorm:"foreignKey:OwnerID"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -53,17 +53,25 @@ type Category struct {
 
 // Product represents a product in the system.
 type Product struct {
-	ID               uint           `json:"id" gorm:"primaryKey"`
-	StoreID          uint           `gorm:"not null" json:"store_id"`
-	Store            Store          `json:"-" gorm:"foreignKey:StoreID"`
-	CategoryID       uint           `gorm:"not null" json:"category_id"`
-	Category         Category       `json:"-" gorm:"foreignKey:CategoryID;constraint:OnDelete:CASCADE;"`
-	Title            string         `gorm:"size:100;not null" json:"title"`
-	Description      string         `gorm:"not null" json:"description"`
-	Price            float64        `gorm:"not null" json:"price"`
-	Amount           uint           `gorm:"not null" json:"amount"`
-	ProductImageList pq.StringArray `gorm:"type:text[]" json:"product_image"`
-	Views            int            `gorm:"default:0" json:"views"`
+	ID                 uint              `json:"id" gorm:"primaryKey"`
+	StoreID            uint              `gorm:"not null" json:"store_id"`
+	Store              Store             `json:"-" gorm:"foreignKey:StoreID"`
+	CategoryID         uint              `gorm:"not null" json:"category_id"`
+	Category           Category          `json:"-" gorm:"foreignKey:CategoryID;constraint:OnDelete:RESTRICT;"`
+	Title              string            `gorm:"size:100;not null" json:"title"`
+	Description        string            `gorm:"not null" json:"description"`
+	Price              float64           `gorm:"not null" json:"price"`
+	Amount             uint              `gorm:"not null" json:"amount"`
+	LowStockThreshold  uint              `gorm:"not null;default:0" json:"low_stock_threshold"`
+	ProductImageList   pq.StringArray    `gorm:"type:text[]" json:"product_image"`
+	Views              int               `gorm:"default:0" json:"views"`
+	GTIN               string            `gorm:"size:14;index" json:"gtin"`
+	CanonicalProductID *uint             `gorm:"index" json:"canonical_product_id"`
+	CanonicalProduct   *CanonicalProduct `json:"-" gorm:"foreignKey:CanonicalProductID"`
+	Variants           []ProductVariant  `gorm:"foreignKey:ProductID" json:"variants,omitempty"`
+	ModifierGroups     []ModifierGroup   `gorm:"foreignKey:ProductID" json:"modifier_groups,omitempty"`
+	OrdersCount        int64             `gorm:"->;-:migration" json:"orders_count,omitempty"`
+	SearchRank         float64           `gorm:"->;-:migration" json:"-"`
 }
 
 // FeaturedProduct represents a featured product.
@@ -122,8 +130,10 @@ type CommentTree struct {
 }
 
 // OrderStatus represents the status of an order.
+// Code is a stable identifier used by the order state machine, see order_status.go.
 type OrderStatus struct {
 	ID          uint           `json:"id" gorm:"primaryKey"`
+	Code        string         `gorm:"size:30;uniqueIndex" json:"code"`
 	StatusName  string         `gorm:"unique;size:100;not null" json:"status_name"`
 	Description string         `json:"description"`
 	CreatedAt   time.Time      `json:"created_at"`
@@ -132,9 +142,10 @@ type OrderStatus struct {
 }
 
 // OrderDetails represents the details of an order.
+// ProductID is only set for single-product orders, checkout orders keep their lines in OrderItem.
 type OrderDetails struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
-	ProductID uint           `gorm:"not null" json:"product_id"`
+	ProductID *uint          `json:"product_id,omitempty"`
 	Product   Product        `json:"-" gorm:"foreignKey:ProductID"`
 	Price     float64        `json:"price,omitempty"`
 	Quantity  uint           `gorm:"default:1" json:"quantity"`
@@ -145,6 +156,31 @@ type OrderDetails struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// OrderItem represents a single line of an order.
+// Title and Price are snapshotted at order time so later product edits don't rewrite history.
+// Price is the unit price of the variant including the price deltas of the chosen modifiers.
+// RefundedQuantity is the part of Quantity that has already been refunded and restocked.
+type OrderItem struct {
+	ID               uint                `json:"id" gorm:"primaryKey"`
+	OrderID          uint                `gorm:"not null;index" json:"order_id"`
+	ProductID        uint                `gorm:"not null" json:"product_id"`
+	Product          Product             `json:"-" gorm:"foreignKey:ProductID"`
+	VariantID        uint                `json:"variant_id"`
+	Variant          ProductVariant      `json:"-" gorm:"foreignKey:VariantID"`
+	SKU              string              `gorm:"size:64" json:"sku"`
+	Title            string              `gorm:"size:100;not null" json:"title"`
+	VariantTitle     string              `gorm:"size:100" json:"variant_title"`
+	Price            float64             `gorm:"not null" json:"price"`
+	Quantity         uint                `gorm:"not null" json:"quantity"`
+	RefundedQuantity uint                `gorm:"not null;default:0" json:"refunded_quantity"`
+	PayoutAccountID  *uint               `json:"-"`                   // store owner's account credited for the item when the order was paid
+	StatusID         *uint               `json:"status_id,omitempty"` // fulfilment status set by the item's store, empty while it follows the order
+	Modifiers        []OrderItemModifier `json:"modifiers,omitempty" gorm:"foreignKey:OrderItemID"`
+	CreatedAt        time.Time           `json:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt      `json:"-" gorm:"index"`
+}
+
 // Order represents a user's order.
 type Order struct {
 	ID             uint           `json:"id" gorm:"primaryKey"`
@@ -154,17 +190,19 @@ type Order struct {
 	Status         OrderStatus    `json:"-" gorm:"foreignKey:StatusID"`
 	OrderDetailsID uint           `gorm:"not null" json:"order_details_id"`
 	OrderDetails   OrderDetails   `json:"order_details" gorm:"foreignKey:OrderDetailsID"`
+	Items          []OrderItem    `json:"items" gorm:"foreignKey:OrderID"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 type OrderRequestJsonBind struct {
-	UserID    uint `json:"user_id"`
-	StatusID  uint `json:"status_id"`
-	AddressID uint `json:"address_id"`
-	ProductID uint `json:"product_id"`
-	Quantity  uint `json:"quantity"`
+	UserID      uint   `json:"user_id"`
+	AddressID   uint   `json:"address_id"`
+	ProductID   uint   `json:"product_id"`
+	VariantID   uint   `json:"variant_id"`
+	ModifierIDs []uint `json:"modifier_ids"`
+	Quantity    uint   `json:"quantity"`
 }
 
 // Payment represents a payment made by a user.
@@ -196,6 +234,10 @@ func (OrderDetails) TableName() string {
 	return "orderapp_orderdetails"
 }
 
+func (OrderItem) TableName() string {
+	return "orderapp_orderitem"
+}
+
 func (OrderStatus) TableName() string {
 	return "orderapp_orderstatus"
 }
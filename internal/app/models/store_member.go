@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// Store member roles
+const (
+	StoreRoleOwner         = "owner"
+	StoreRoleManager       = "manager"
+	StoreRoleCatalogEditor = "catalog_editor"
+	StoreRoleOrderOperator = "order_operator"
+)
+
+// Store permissions checked by store, product and order operations
+const (
+	StorePermissionManageStore   = "store.manage"
+	StorePermissionDeleteStore   = "store.delete"
+	StorePermissionViewStaff     = "store.staff.view"
+	StorePermissionManageStaff   = "store.staff.manage"
+	StorePermissionManageCatalog = "store.catalog.manage"
+	StorePermissionProcessOrders = "store.orders.process"
+)
+
+// StoreRolePermissions are the permissions of every store role.
+var StoreRolePermissions = map[string][]string{
+	StoreRoleOwner: {StorePermissionManageStore, StorePermissionDeleteStore, StorePermissionViewStaff, StorePermissionManageStaff,
+		StorePermissionManageCatalog, StorePermissionProcessOrders},
+	StoreRoleManager: {StorePermissionManageStore, StorePermissionViewStaff, StorePermissionManageStaff,
+		StorePermissionManageCatalog, StorePermissionProcessOrders},
+	StoreRoleCatalogEditor: {StorePermissionViewStaff, StorePermissionManageCatalog},
+	StoreRoleOrderOperator: {StorePermissionViewStaff, StorePermissionProcessOrders},
+}
+
+// Store invitation statuses
+const (
+	StoreInvitationPending   = "pending"
+	StoreInvitationAccepted  = "accepted"
+	StoreInvitationDeclined  = "declined"
+	StoreInvitationCancelled = "cancelled"
+)
+
+// StoreMember is a user working in a store. The store owner is a member with the owner role.
+type StoreMember struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	StoreID     uint      `json:"store_id" gorm:"not null;uniqueIndex:idx_store_member"`
+	Store       Store     `json:"-" gorm:"foreignKey:StoreID"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_store_member;index"`
+	User        User      `json:"-" gorm:"foreignKey:UserID"`
+	Username    string    `json:"username" gorm:"->;-:migration"`
+	Role        string    `json:"role" gorm:"size:20;not null"`
+	InvitedByID *uint     `json:"invited_by_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// StoreInvitation invites a user to join a store with a role, the user accepts or declines it.
+type StoreInvitation struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	StoreID     uint       `json:"store_id" gorm:"not null;index"`
+	Store       Store      `json:"-" gorm:"foreignKey:StoreID"`
+	StoreName   string     `json:"store_name,omitempty" gorm:"->;-:migration"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	User        User       `json:"-" gorm:"foreignKey:UserID"`
+	Username    string     `json:"username,omitempty" gorm:"->;-:migration"`
+	Role        string     `json:"role" gorm:"size:20;not null"`
+	InvitedByID uint       `json:"invited_by_id" gorm:"not null"`
+	Status      string     `json:"status" gorm:"size:20;not null;index"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// StoreInvitationRequest is the body of a store invitation. Login is the username or email of the invited user.
+type StoreInvitationRequest struct {
+	Login string `json:"login" binding:"required"`
+	Role  string `json:"role" binding:"required"`
+}
+
+// StoreMemberRoleRequest is the body of a store member role change.
+type StoreMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (StoreMember) TableName() string {
+	return "storeapp_storemember"
+}
+
+func (StoreInvitation) TableName() string {
+	return "storeapp_storeinvitation"
+}
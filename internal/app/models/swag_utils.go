@@ -23,16 +23,28 @@ type DefaultResponse struct {
 }
 
 type UserRequest struct {
-	Username     string `json:"username"`
-	Email        string `json:"email"`
-	FirstName    string `json:"first_name"`
-	LastName     string `json:"last_name"`
-	HashPassword string `json:"password"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Password  string `json:"password"`
+}
+
+// ToUser возвращает пользователя для регистрации, пароль кладётся в HashPassword и хэшируется в сервисе
+func (r UserRequest) ToUser() User {
+	return User{
+		Username:     r.Username,
+		Email:        r.Email,
+		FirstName:    r.FirstName,
+		LastName:     r.LastName,
+		HashPassword: r.Password,
+	}
 }
 
 type UserLogin struct {
-	Username     string `json:"username"`
-	HashPassword string `json:"password"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
 }
 
 type CategoryRequest struct {
@@ -41,6 +53,10 @@ type CategoryRequest struct {
 	Description  string `json:"description,omitempty"`            // Описание категории, необязательное поле
 }
 
+type MoveCategoryRequest struct {
+	ParentID uint `json:"parent_id"` // Идентификатор нового родителя, 0 — сделать категорию корневой
+}
+
 type AddressRequest struct {
 	AddressName string `json:"address_name"`
 }
@@ -69,10 +85,11 @@ type ReviewRequest struct {
 }
 
 type OrderRequest struct {
-	StatusID  uint `json:"status_id"`
-	AddressID uint `json:"address_id"`
-	ProductID uint `json:"product_id"`
-	Quantity  uint `json:"quantity"`
+	AddressID   uint   `json:"address_id"`
+	ProductID   uint   `json:"product_id"`
+	VariantID   uint   `json:"variant_id"`
+	ModifierIDs []uint `json:"modifier_ids"`
+	Quantity    uint   `json:"quantity"`
 }
 
 type OrderStatusRequest struct {
@@ -80,23 +97,39 @@ type OrderStatusRequest struct {
 	Description string `json:"description"`
 }
 
-type PaymentRequest struct {
-	AccountID uint `json:"account_id"`
-	OrderID   uint `json:"order_id"`
-}
-
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
 type ProductRequest struct {
-	StoreID       uint     `json:"store_id"`
-	CategoryID    uint     `json:"category_id"`
-	Title         string   `json:"title"`
-	Description   string   `json:"description"`
-	Price         uint     `json:"price"`
-	Amount        uint     `json:"amount"`
-	ProductImages []string `json:"product_images"`
+	StoreID            uint     `json:"store_id"`
+	CategoryID         uint     `json:"category_id"`
+	Title              string   `json:"title"`
+	Description        string   `json:"description"`
+	Price              uint     `json:"price"`
+	Amount             uint     `json:"amount"`
+	LowStockThreshold  uint     `json:"low_stock_threshold"`
+	ProductImages      []string `json:"product_images"`
+	GTIN               string   `json:"gtin"`
+	CanonicalProductID *uint    `json:"canonical_product_id"`
 }
 
 type ProductResponse struct {
@@ -123,3 +156,71 @@ type CommentRequest struct {
 	ParentID    uint   `json:"parent_id"`
 	CommentText string `json:"text" binding:"required"`
 }
+
+type CartItemRequest struct {
+	ProductID   uint   `json:"product_id"`
+	VariantID   uint   `json:"variant_id"`
+	ModifierIDs []uint `json:"modifier_ids"`
+	Quantity    uint   `json:"quantity"`
+}
+
+type CheckoutRequest struct {
+	AddressID uint `json:"address_id"`
+}
+
+type RefundItemRequest struct {
+	OrderItemID uint `json:"order_item_id"`
+	Quantity    uint `json:"quantity"`
+}
+
+type RefundRequest struct {
+	Items  []RefundItemRequest `json:"items"`
+	Reason string              `json:"reason"`
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason"`
+}
+
+type OrderStatusChangeRequest struct {
+	Status  string `json:"status" binding:"required"`
+	StoreID uint   `json:"store_id"` // limits the change to the items of one store of the order
+	Reason  string `json:"reason"`
+}
+
+type StockAdjustmentRequest struct {
+	VariantID uint   `json:"variant_id"`
+	Kind      string `json:"kind"`
+	Quantity  int    `json:"quantity" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+type VariantOptionRequest struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type VariantRequest struct {
+	SKU     string                 `json:"sku"`
+	Title   string                 `json:"title"`
+	Price   float64                `json:"price"`
+	Amount  uint                   `json:"amount"`
+	Images  []string               `json:"images"`
+	Options []VariantOptionRequest `json:"options"`
+}
+
+type ModifierRequest struct {
+	ID         uint    `json:"id"`
+	Name       string  `json:"name"`
+	PriceDelta float64 `json:"price_delta"`
+	SoldOut    bool    `json:"sold_out"`
+}
+
+type ModifierGroupRequest struct {
+	Name          string            `json:"name"`
+	IsRequired    bool              `json:"is_required"`
+	MinSelections uint              `json:"min_selections"`
+	MaxSelections uint              `json:"max_selections"`
+	Position      uint              `json:"position"`
+	Modifiers     []ModifierRequest `json:"modifiers"`
+}
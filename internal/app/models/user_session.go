@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Reasons a user session was revoked
+const (
+	SessionRevokedLogout          = "logout"
+	SessionRevokedByUser          = "revoked"
+	SessionRevokedTokenReuse      = "token_reuse"
+	SessionRevokedPasswordChanged = "password_changed"
+)
+
+// UserSession is a sign in of a user on a device. It keeps the hash of the only valid refresh token of the session:
+// every refresh replaces it, and presenting a token that was already replaced revokes the whole session.
+type UserSession struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"-" gorm:"not null;index"`
+	User         User       `json:"-" gorm:"foreignKey:UserID"`
+	TokenHash    string     `json:"-" gorm:"size:64;not null"`
+	DeviceName   string     `json:"device_name" gorm:"size:100"`
+	IP           string     `json:"ip" gorm:"size:45"`
+	UserAgent    string     `json:"user_agent" gorm:"size:255"`
+	Current      bool       `json:"current" gorm:"-"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"-"`
+	RevokeReason string     `json:"-" gorm:"size:30"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (UserSession) TableName() string {
+	return "userapp_session"
+}
+
+// SessionClient describes the device a session is started or refreshed from.
+type SessionClient struct {
+	DeviceName string
+	IP         string
+	UserAgent  string
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// User token purposes
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+// UserToken is a single-use token sent to the user by email. Only the hash of the token is stored.
+// Email is the address the token was sent to, the token stops working if the user's email changes.
+type UserToken struct {
+	ID        uint       `json:"-" gorm:"primaryKey"`
+	UserID    uint       `json:"-" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"foreignKey:UserID"`
+	Purpose   string     `json:"-" gorm:"size:30;not null"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Email     string     `json:"-" gorm:"not null"`
+	ExpiresAt time.Time  `json:"-" gorm:"not null"`
+	UsedAt    *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"-"`
+}
+
+func (UserToken) TableName() string {
+	return "userapp_usertoken"
+}
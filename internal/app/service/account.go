@@ -2,6 +2,7 @@ package service
 
 import (
 	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
 	"github.com/gin-gonic/gin"
 )
@@ -24,3 +25,28 @@ func ValidateAccount(HandleError func(ctx *gin.Context, err error), accountData
 
 	return nil
 }
+
+// DeleteAccount удаляет счёт, только если по проводкам на нём не осталось средств
+func DeleteAccount(accountID uint) error {
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		accounts, err := uow.LockAccounts([]uint{accountID})
+		if err != nil {
+			return err
+		}
+
+		if _, ok := accounts[accountID]; !ok {
+			return errs.ErrAccountNotFound
+		}
+
+		balance, err := uow.GetLedgerBalance(accountID)
+		if err != nil {
+			return err
+		}
+
+		if balance != 0 {
+			return errs.ErrAccountHasBalance
+		}
+
+		return uow.DeleteAccount(accountID)
+	})
+}
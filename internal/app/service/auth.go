@@ -4,37 +4,47 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
 	"BizMart/pkg/utils"
+	"errors"
 )
 
-func SignIn(username, useremail, password string) (user models.User, accessToken string, refreshToken string, err error) {
+func SignIn(username, useremail, password string, client models.SessionClient) (user models.User, accessToken string, refreshToken string, err error) {
 	if useremail == "" && username == "" {
 		return user, "", "", errs.ErrInvalidData
 	}
 
-	if useremail != "" && username != "" {
-		user, err = repository.GetUserByEmailPasswordAndUsername(username, useremail, password)
-		if err != nil {
-			return user, "", "", repository.TranslateGormError(err)
-		}
-	} else if username != "" {
-		user, err = repository.GetUserByUsernameAndPassword(username, password)
-		if err != nil {
-			return user, "", "", repository.TranslateGormError(err)
-		}
-	} else if useremail != "" {
-		user, err = repository.GetUserByEmailAndPassword(useremail, password)
-		if err != nil {
-			return user, "", "", repository.TranslateGormError(err)
-		}
-	} else {
-		return user, "", "", errs.ErrInvalidData
+	user, err = repository.GetUserForSignIn(username, useremail)
+	if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+		return models.User{}, "", "", err
+	}
+
+	// Для несуществующего пользователя пароль тоже проверяется, чтобы время ответа было одинаковым
+	match, needsRehash := utils.VerifyPassword(password, user.HashPassword)
+	if err != nil || !match {
+		return models.User{}, "", "", errs.ErrIncorrectUsernameOrPassword
+	}
+
+	// Старый хеш SHA-256 или хеш с прежними параметрами заменяется, пока известен пароль
+	if needsRehash {
+		upgradePasswordHash(user, password)
 	}
 
-	accessToken, refreshToken, err = utils.GenerateToken(user.ID, user.Username)
+	accessToken, refreshToken, err = StartSession(user, client)
 	if err != nil {
 		return user, "", "", err
 	}
 
 	return user, accessToken, refreshToken, nil
 }
+
+// upgradePasswordHash пересчитывает хеш пароля. Ошибка не мешает входу: хеш обновится при следующем входе
+func upgradePasswordHash(user models.User, password string) {
+	newHash, err := utils.HashPassword(password)
+	if err != nil {
+		logger.Error.Printf("[service.upgradePasswordHash] error hashing password of user %d: %v", user.ID, err)
+		return
+	}
+
+	_ = repository.UpdateUserPasswordHash(user.ID, user.HashPassword, newHash)
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	cartCacheTTL     = 30 * time.Minute
+	maxCartItemCount = 1000
+)
+
+func cartCacheKey(userID uint) string {
+	return fmt.Sprintf("cart:%d", userID)
+}
+
+func invalidateCart(userID uint) {
+	if err := db.DeleteCache(cartCacheKey(userID)); err != nil {
+		logger.Warn.Printf("[service.invalidateCart] error deleting cart cache of user %d: %v", userID, err)
+	}
+}
+
+// GetCart возвращает корзину пользователя, сначала пытаясь взять её из Redis
+func GetCart(userID uint) (models.Cart, error) {
+	if cached, err := db.GetCache(cartCacheKey(userID)); err == nil && cached != "" {
+		var cart models.Cart
+		if err = json.Unmarshal([]byte(cached), &cart); err == nil {
+			return cart, nil
+		}
+	}
+
+	items, err := repository.GetCartItemsByUserID(userID)
+	if err != nil {
+		return models.Cart{}, err
+	}
+
+	var modifierIDs []uint
+	for _, item := range items {
+		modifierIDs = append(modifierIDs, cartItemModifierIDs(item)...)
+	}
+
+	modifiers := map[uint]models.OrderItemModifier{}
+	if len(modifierIDs) > 0 {
+		if modifiers, err = repository.GetModifiersByIDs(modifierIDs); err != nil {
+			return models.Cart{}, err
+		}
+	}
+
+	cart := models.Cart{UserID: userID, Items: []models.CartLine{}}
+	for _, item := range items {
+		line := models.CartLine{
+			ProductID:    item.ProductID,
+			VariantID:    item.VariantID,
+			SKU:          item.Variant.SKU,
+			Title:        item.Product.Title,
+			VariantTitle: item.Variant.Title,
+			Price:        item.Variant.Price,
+			Quantity:     item.Quantity,
+		}
+
+		// Удалённые продавцом модификаторы не показываются, такую строку не удастся оформить при checkout
+		for _, modifierID := range cartItemModifierIDs(item) {
+			if modifier, ok := modifiers[modifierID]; ok {
+				line.Modifiers = append(line.Modifiers, modifier)
+				line.Price += modifier.PriceDelta
+			}
+		}
+
+		line.Subtotal = line.Price * float64(line.Quantity)
+		cart.Items = append(cart.Items, line)
+		cart.Total += line.Subtotal
+	}
+
+	if cartData, err := json.Marshal(cart); err == nil {
+		if err = db.SetCache(cartCacheKey(userID), cartData, cartCacheTTL); err != nil {
+			logger.Warn.Printf("[service.GetCart] error caching cart of user %d: %v", userID, err)
+		}
+	}
+
+	return cart, nil
+}
+
+// AddCartItem добавляет вариант товара в корзину или увеличивает его количество
+func AddCartItem(userID uint, request models.CartItemRequest) error {
+	if request.Quantity == 0 {
+		request.Quantity = 1
+	}
+
+	variant, err := resolveVariant(request.ProductID, request.VariantID)
+	if err != nil {
+		return err
+	}
+
+	// Выбор модификаторов проверяется сразу, чтобы не принимать в корзину то, что нельзя оформить
+	groups, err := repository.GetModifierGroupsByProductID(request.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err = selectModifiers(groups, request.ModifierIDs); err != nil {
+		return err
+	}
+
+	key := modifierKey(request.ModifierIDs)
+	item, err := repository.GetCartItem(userID, variant.ID, key)
+	if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+		return err
+	}
+
+	item.UserID = userID
+	item.ProductID = request.ProductID
+	item.VariantID = variant.ID
+	item.ModifierKey = key
+	item.ModifierIDs = make(pq.Int64Array, 0, len(request.ModifierIDs))
+	for _, modifierID := range request.ModifierIDs {
+		item.ModifierIDs = append(item.ModifierIDs, int64(modifierID))
+	}
+	item.Quantity += request.Quantity
+
+	if err = validateCartQuantity(variant, item.Quantity); err != nil {
+		return err
+	}
+
+	if err = repository.SaveCartItem(item); err != nil {
+		return err
+	}
+
+	invalidateCart(userID)
+	return nil
+}
+
+// UpdateCartItem задаёт новое количество варианта товара с выбранными модификаторами в корзине
+func UpdateCartItem(userID, productID, variantID uint, modifierIDs []uint, quantity uint) error {
+	variant, err := resolveVariant(productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	item, err := repository.GetCartItem(userID, variant.ID, modifierKey(modifierIDs))
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errs.ErrCartItemNotFound
+		}
+
+		return err
+	}
+
+	if quantity == 0 {
+		return RemoveCartItem(userID, productID, variant.ID, modifierIDs)
+	}
+
+	if err = validateCartQuantity(variant, quantity); err != nil {
+		return err
+	}
+
+	item.Quantity = quantity
+	if err = repository.SaveCartItem(item); err != nil {
+		return err
+	}
+
+	invalidateCart(userID)
+	return nil
+}
+
+// RemoveCartItem удаляет вариант товара с выбранными модификаторами из корзины
+func RemoveCartItem(userID, productID, variantID uint, modifierIDs []uint) error {
+	variant, err := resolveVariant(productID, variantID)
+	if err != nil {
+		return err
+	}
+
+	key := modifierKey(modifierIDs)
+	if _, err = repository.GetCartItem(userID, variant.ID, key); err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errs.ErrCartItemNotFound
+		}
+
+		return err
+	}
+
+	if err = repository.DeleteCartItem(userID, variant.ID, key); err != nil {
+		return err
+	}
+
+	invalidateCart(userID)
+	return nil
+}
+
+// ClearCart очищает корзину пользователя
+func ClearCart(userID uint) error {
+	if err := repository.ClearCart(userID); err != nil {
+		return err
+	}
+
+	invalidateCart(userID)
+	return nil
+}
+
+// Checkout превращает корзину пользователя в один заказ с несколькими позициями
+func Checkout(userID, addressID uint) (orderID uint, err error) {
+	address, err := repository.GetAddressByID(addressID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return 0, errs.ErrAddressNotFound
+		}
+
+		return 0, err
+	}
+
+	if address.UserID != userID {
+		return 0, errs.ErrAddressNotFound
+	}
+
+	cartItems, err := repository.GetCartItemsByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(cartItems) == 0 {
+		return 0, errs.ErrCartIsEmpty
+	}
+
+	lines := make([]orderLine, 0, len(cartItems))
+	for _, cartItem := range cartItems {
+		lines = append(lines, orderLine{
+			productID:   cartItem.ProductID,
+			variantID:   cartItem.VariantID,
+			modifierIDs: cartItemModifierIDs(cartItem),
+			quantity:    cartItem.Quantity,
+		})
+	}
+
+	err = repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := placeOrder(uow, userID, addressID, lines)
+		if err != nil {
+			return err
+		}
+
+		orderID = order.ID
+		return uow.ClearCart(userID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	invalidateCart(userID)
+	return orderID, nil
+}
+
+func validateCartQuantity(variant models.ProductVariant, quantity uint) error {
+	if quantity > maxCartItemCount {
+		return errs.ErrInvalidQuantity
+	}
+
+	if quantity > variant.Amount {
+		return errs.ErrNotEnoughProductInStock
+	}
+
+	return nil
+}
+
+func cartItemModifierIDs(item models.CartItem) []uint {
+	modifierIDs := make([]uint, 0, len(item.ModifierIDs))
+	for _, modifierID := range item.ModifierIDs {
+		modifierIDs = append(modifierIDs, uint(modifierID))
+	}
+
+	return modifierIDs
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/testutil"
+	"BizMart/pkg/errs"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCheckoutRollsBackOnError(t *testing.T) {
+	testutil.RequireDB(t)
+
+	tests := []struct {
+		name  string
+		table string
+	}{
+		{name: "after stock movement", table: "orderapp_stockreservation"},
+		{name: "on clearing cart", table: "cartapp_cartitem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testutil.NewFixture(t, 10, 25, 100)
+			if err := AddCartItem(f.Buyer.ID, models.CartItemRequest{
+				ProductID: f.Product.ID,
+				VariantID: f.Variant.ID,
+				Quantity:  2,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			before := f.TakeSnapshot(t)
+			testutil.InjectError(t, func(tx *gorm.DB) bool {
+				return tx.Statement.Table == tt.table
+			})
+
+			if _, err := Checkout(f.Buyer.ID, f.Address.ID); !errors.Is(err, testutil.ErrInjected) {
+				t.Fatalf("expected injected error, got %v", err)
+			}
+
+			f.RequireUnchanged(t, before)
+		})
+	}
+}
+
+func TestUpdateOrderRejectsCheckedOutCart(t *testing.T) {
+	testutil.RequireDB(t)
+
+	a := testutil.NewFixture(t, 10, 25, 100)
+	b := testutil.NewFixture(t, 10, 25, 0)
+
+	for _, variant := range []models.ProductVariant{a.Variant, b.Variant} {
+		if err := AddCartItem(a.Buyer.ID, models.CartItemRequest{
+			ProductID: variant.ProductID,
+			VariantID: variant.ID,
+			Quantity:  1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orderID, err := Checkout(a.Buyer.ID, a.Address.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := a.TakeSnapshot(t)
+	if err = UpdateOrder(orderID, models.OrderRequestJsonBind{
+		UserID:    a.Buyer.ID,
+		AddressID: a.Address.ID,
+		ProductID: a.Product.ID,
+		Quantity:  3,
+	}); !errors.Is(err, errs.ErrOrderNotEditable) {
+		t.Fatalf("expected %v, got %v", errs.ErrOrderNotEditable, err)
+	}
+
+	a.RequireUnchanged(t, before)
+}
@@ -4,8 +4,26 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"errors"
+	"sort"
 )
 
+// checkParentCategory проверяет, что родительская категория существует
+func checkParentCategory(parentID uint) error {
+	if parentID == 0 {
+		return nil
+	}
+
+	if _, err := repository.GetCategoryByID(parentID); err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return errs.ErrInvalidTargetCategory
+		}
+		return err
+	}
+
+	return nil
+}
+
 func CreateCategory(categ models.Category) (categID uint, err error) {
 	var category models.Category
 
@@ -14,6 +32,10 @@ func CreateCategory(categ models.Category) (categID uint, err error) {
 		return category.ID, errs.ErrCategoryNameUniquenessFailed
 	}
 
+	if err = checkParentCategory(categ.ParentID); err != nil {
+		return 0, err
+	}
+
 	if categID, err = repository.CreateCategory(categ); err != nil {
 		return 0, err
 	}
@@ -21,6 +43,7 @@ func CreateCategory(categ models.Category) (categID uint, err error) {
 	return categID, nil
 }
 
+// UpdateCategory обновляет категорию. Если указан другой родитель, категория перемещается вместе с поддеревом
 func UpdateCategory(categoryID uint, categ models.Category) (categID uint, err error) {
 	if categID, err = repository.UpdateCategory(categoryID, categ); err != nil {
 		return 0, err
@@ -28,3 +51,68 @@ func UpdateCategory(categoryID uint, categ models.Category) (categID uint, err e
 
 	return categID, nil
 }
+
+// MoveCategory перемещает категорию с поддеревом под другого родителя, parentID 0 делает её корневой
+func MoveCategory(categoryID, parentID uint) error {
+	return repository.MoveCategory(categoryID, parentID)
+}
+
+// DeleteCategory удаляет категорию. Непустую категорию можно удалить, только перенеся её товары и подкатегории в reassignTo
+func DeleteCategory(categoryID, reassignTo uint) error {
+	return repository.DeleteCategory(categoryID, reassignTo)
+}
+
+// GetCategoryPath возвращает цепочку категорий от корня до указанной для хлебных крошек
+func GetCategoryPath(categoryID uint) ([]models.Category, error) {
+	return repository.GetCategoryPath(categoryID)
+}
+
+// GetCategoryTree возвращает дерево категорий, дети отсортированы по названию.
+// При rootID > 0 возвращается только поддерево этой категории
+func GetCategoryTree(rootID uint) ([]*models.CategoryNode, error) {
+	categories, err := repository.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*models.CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &models.CategoryNode{Category: category, Children: []*models.CategoryNode{}}
+	}
+
+	var roots []*models.CategoryNode
+	for _, category := range categories {
+		node := nodes[category.ID]
+		// Категория, родитель которой удалён, показывается среди корневых
+		if parent, ok := nodes[category.ParentID]; ok && category.ParentID != category.ID {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	for _, node := range nodes {
+		sortCategoryNodes(node.Children)
+	}
+	sortCategoryNodes(roots)
+
+	if rootID == 0 {
+		if roots == nil {
+			roots = []*models.CategoryNode{}
+		}
+		return roots, nil
+	}
+
+	root, ok := nodes[rootID]
+	if !ok {
+		return nil, errs.ErrCategoryNotFound
+	}
+
+	return []*models.CategoryNode{root}, nil
+}
+
+func sortCategoryNodes(nodes []*models.CategoryNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].CategoryName < nodes[j].CategoryName
+	})
+}
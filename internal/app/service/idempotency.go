@@ -0,0 +1,248 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// idempotencyKeyTTL срок, в течение которого повтор запроса с тем же ключом возвращает сохранённый ответ
+	idempotencyKeyTTL = 24 * time.Hour
+	// idempotencyLockTTL срок, после которого зависший незавершённый запрос перестаёт блокировать ключ
+	idempotencyLockTTL = time.Minute
+	// idempotencyLockRefreshInterval период продления блокировки ключа, пока запрос выполняется
+	idempotencyLockRefreshInterval = idempotencyLockTTL / 3
+)
+
+func idempotencyCacheKey(userID uint, key string) string {
+	return fmt.Sprintf("idempotency:%d:%s", userID, key)
+}
+
+// BeginIdempotentRequest резервирует ключ идемпотентности за запросом.
+// Если ключ уже использовался, возвращается сохранённая запись: завершённая — для повтора ответа,
+// незавершённая — ErrIdempotentRequestInProgress, с другим телом запроса — ErrIdempotencyKeyReused.
+// Ключ резервируется в Redis, при его недоступности в Postgres. Ответы всегда сохраняются в Postgres,
+// поэтому промах в Redis перед резервированием сверяется с базой.
+func BeginIdempotentRequest(userID uint, key, fingerprint string) (*models.IdempotencyKey, error) {
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      models.IdempotencyStatusProcessing,
+		ExpiresAt:   time.Now().Add(idempotencyLockTTL),
+	}
+
+	existing, err := reserveIdempotencyKeyInCache(record)
+	if err != nil {
+		logger.Warn.Printf("[service.BeginIdempotentRequest] redis is unavailable, falling back to postgres: %v", err)
+
+		existing, err = reserveIdempotencyKeyInDB(record)
+		if err != nil {
+			return nil, err
+		}
+	} else if existing == nil {
+		existing, err = getStoredIdempotencyKey(userID, key)
+		if err != nil || existing != nil {
+			// Ключ уже занят в Postgres, резерв в Redis снимается, чтобы повторы читали запись из базы
+			if deleteErr := db.DeleteCache(idempotencyCacheKey(userID, key)); deleteErr != nil {
+				logger.Warn.Printf("[service.BeginIdempotentRequest] error deleting idempotency key %s from redis: %v", key, deleteErr)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if existing == nil {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, errs.ErrIdempotencyKeyReused
+	}
+
+	if existing.Status != models.IdempotencyStatusCompleted {
+		return nil, errs.ErrIdempotentRequestInProgress
+	}
+
+	return existing, nil
+}
+
+// HoldIdempotentRequest продлевает блокировку ключа, пока выполняется обработчик запроса,
+// чтобы долгий запрос не потерял ключ и повтор не выполнился параллельно с ним.
+// Возвращённая функция останавливает продление и должна быть вызвана до сохранения ответа.
+func HoldIdempotentRequest(userID uint, key, fingerprint string) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(idempotencyLockRefreshInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				extendIdempotencyLock(userID, key, fingerprint)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// CompleteIdempotentRequest сохраняет ответ на запрос, чтобы повторы с тем же ключом получили его же.
+// Ответ записывается в Postgres и кэшируется в Redis
+func CompleteIdempotentRequest(userID uint, key, fingerprint string, statusCode int, contentType string, body []byte) {
+	record := models.IdempotencyKey{
+		UserID:       userID,
+		Key:          key,
+		Fingerprint:  fingerprint,
+		Status:       models.IdempotencyStatusCompleted,
+		StatusCode:   statusCode,
+		ContentType:  contentType,
+		ResponseBody: string(body),
+		ExpiresAt:    time.Now().Add(idempotencyKeyTTL),
+	}
+
+	if err := repository.SaveIdempotencyKey(&record); err != nil {
+		logger.Error.Printf("[service.CompleteIdempotentRequest] error saving response for idempotency key %s: %v", key, err)
+	}
+
+	if err := cacheIdempotencyKey(record, idempotencyKeyTTL); err != nil {
+		logger.Warn.Printf("[service.CompleteIdempotentRequest] error caching response for idempotency key %s: %v", key, err)
+
+		// Без ответа в Redis повторы должны прочитать его из базы, а не ждать истечения блокировки
+		if err = db.DeleteCache(idempotencyCacheKey(userID, key)); err != nil {
+			logger.Warn.Printf("[service.CompleteIdempotentRequest] error deleting idempotency key %s from redis: %v", key, err)
+		}
+	}
+}
+
+// ReleaseIdempotentRequest освобождает ключ, например после ошибки сервера, чтобы клиент мог повторить запрос
+func ReleaseIdempotentRequest(userID uint, key string) {
+	if err := db.DeleteCache(idempotencyCacheKey(userID, key)); err != nil {
+		logger.Warn.Printf("[service.ReleaseIdempotentRequest] error deleting idempotency key %s from redis: %v", key, err)
+	}
+
+	if err := repository.DeleteIdempotencyKey(userID, key); err != nil {
+		logger.Error.Printf("[service.ReleaseIdempotentRequest] error deleting idempotency key %s: %v", key, err)
+	}
+}
+
+func reserveIdempotencyKeyInCache(record models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := idempotencyCacheKey(record.UserID, record.Key)
+	reserved, err := db.SetCacheNX(cacheKey, data, idempotencyLockTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	cached, err := db.GetCache(cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ключ истёк между SETNX и GET
+	if cached == "" {
+		return reserveIdempotencyKeyInCache(record)
+	}
+
+	var existing models.IdempotencyKey
+	if err = json.Unmarshal([]byte(cached), &existing); err != nil {
+		return nil, err
+	}
+
+	return &existing, nil
+}
+
+// getStoredIdempotencyKey возвращает действующую запись ключа из Postgres или nil, если её нет
+func getStoredIdempotencyKey(userID uint, key string) (*models.IdempotencyKey, error) {
+	stored, err := repository.GetIdempotencyKey(userID, key)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if stored.ExpiresAt.Before(time.Now()) {
+		return nil, nil
+	}
+
+	return &stored, nil
+}
+
+func extendIdempotencyLock(userID uint, key, fingerprint string) {
+	record := models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Status:      models.IdempotencyStatusProcessing,
+		ExpiresAt:   time.Now().Add(idempotencyLockTTL),
+	}
+
+	// Ключ зарезервирован либо в Redis, либо в Postgres, продлеваются оба
+	if err := cacheIdempotencyKey(record, idempotencyLockTTL); err != nil {
+		logger.Warn.Printf("[service.extendIdempotencyLock] error extending idempotency key %s in redis: %v", key, err)
+	}
+
+	if err := repository.ExtendIdempotencyKey(userID, key, record.ExpiresAt); err != nil {
+		logger.Error.Printf("[service.extendIdempotencyLock] error extending idempotency key %s: %v", key, err)
+	}
+}
+
+func cacheIdempotencyKey(record models.IdempotencyKey, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return db.SetCache(idempotencyCacheKey(record.UserID, record.Key), data, ttl)
+}
+
+func reserveIdempotencyKeyInDB(record models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	created, err := repository.CreateIdempotencyKey(&record)
+	if err != nil {
+		return nil, err
+	}
+
+	if created {
+		return nil, nil
+	}
+
+	existing, err := repository.GetIdempotencyKey(record.UserID, record.Key)
+	if err != nil {
+		// Ключ удалили между вставкой и чтением, пробуем ещё раз
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return reserveIdempotencyKeyInDB(record)
+		}
+
+		return nil, err
+	}
+
+	return &existing, nil
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/internal/security"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/images"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/storage"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+)
+
+const (
+	defaultMaxUploadMegabytes = 5
+	defaultMaxProductImages   = 10
+)
+
+// MaxImageUploadSize максимальный размер одного загружаемого изображения в байтах
+func MaxImageUploadSize() int64 {
+	megabytes := security.AppSettings.StorageParams.MaxUploadMegabytes
+	if megabytes <= 0 {
+		megabytes = defaultMaxUploadMegabytes
+	}
+	return int64(megabytes) << 20
+}
+
+// MaxProductImages максимальное количество изображений у товара
+func MaxProductImages() int {
+	if limit := security.AppSettings.StorageParams.MaxProductImages; limit > 0 {
+		return limit
+	}
+	return defaultMaxProductImages
+}
+
+// thumbnailKey ключ миниатюры: имя оригинала с суффиксом размера, например products/1/ab12_small.jpg
+func thumbnailKey(key, sizeName, extension string) string {
+	base := key
+	if dot := strings.LastIndex(key, "."); dot > strings.LastIndex(key, "/") {
+		base = key[:dot]
+	}
+	return base + "_" + sizeName + extension
+}
+
+// imageFileKeys ключи оригинала и всех его миниатюр. Миниатюры JPEG хранятся в JPEG, остальные в PNG
+func imageFileKeys(key string) []string {
+	extension := ".png"
+	if strings.HasSuffix(key, ".jpg") {
+		extension = ".jpg"
+	}
+
+	keys := []string{key}
+	for _, size := range images.ThumbnailSizes {
+		keys = append(keys, thumbnailKey(key, size.Name, extension))
+	}
+	return keys
+}
+
+func deleteStoredFiles(keys []string) {
+	for _, key := range keys {
+		if err := storage.Get().Delete(key); err != nil {
+			logger.Error.Printf("[service.deleteStoredFiles] error deleting file %s: %v", key, err)
+		}
+	}
+}
+
+// readImageFile читает загруженный файл целиком, не более лимита размера
+func readImageFile(file *multipart.FileHeader) ([]byte, error) {
+	maxSize := MaxImageUploadSize()
+	if file.Size > maxSize {
+		return nil, errs.ErrImageTooLarge
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxSize {
+		return nil, errs.ErrImageTooLarge
+	}
+
+	return data, nil
+}
+
+// saveImage проверяет изображение по содержимому, сохраняет его и миниатюры под префиксом prefix.
+// Если сохранить не удалось, уже записанные файлы удаляются
+func saveImage(prefix string, file *multipart.FileHeader) (models.UploadedImage, error) {
+	data, err := readImageFile(file)
+	if err != nil {
+		return models.UploadedImage{}, err
+	}
+
+	img, err := images.Decode(data)
+	if err != nil {
+		if errors.Is(err, images.ErrTooManyPixels) {
+			return models.UploadedImage{}, errs.ErrImageTooLarge
+		}
+		return models.UploadedImage{}, errs.ErrUnsupportedImageType
+	}
+
+	thumbnails, err := img.Thumbnails()
+	if err != nil {
+		return models.UploadedImage{}, err
+	}
+
+	name := make([]byte, 16)
+	if _, err = rand.Read(name); err != nil {
+		return models.UploadedImage{}, err
+	}
+
+	key := prefix + "/" + hex.EncodeToString(name) + img.Extension
+	store := storage.Get()
+
+	if err = store.Put(key, bytes.NewReader(data), int64(len(data)), img.ContentType); err != nil {
+		logger.Error.Printf("[service.saveImage] error storing image %s: %v", key, err)
+		return models.UploadedImage{}, err
+	}
+
+	stored := []string{key}
+	uploaded := models.UploadedImage{
+		URL:         store.URL(key),
+		ContentType: img.ContentType,
+		Size:        int64(len(data)),
+		Width:       img.Width,
+		Height:      img.Height,
+		Thumbnails:  make(map[string]string, len(thumbnails)),
+	}
+
+	for _, thumbnail := range thumbnails {
+		thumbKey := thumbnailKey(key, thumbnail.Size.Name, thumbnail.Extension)
+		if err = store.Put(thumbKey, bytes.NewReader(thumbnail.Data), int64(len(thumbnail.Data)), thumbnail.ContentType); err != nil {
+			logger.Error.Printf("[service.saveImage] error storing thumbnail %s: %v", thumbKey, err)
+			deleteStoredFiles(stored)
+			return models.UploadedImage{}, err
+		}
+
+		stored = append(stored, thumbKey)
+		uploaded.Thumbnails[thumbnail.Size.Name] = store.URL(thumbKey)
+	}
+
+	return uploaded, nil
+}
+
+// DeleteOrphanedImages удаляет файлы изображений, которые больше нигде не используются.
+// Внешние ссылки, не принадлежащие хранилищу, пропускаются
+func DeleteOrphanedImages(imageURLs []string) {
+	var stored []string
+	for _, imageURL := range imageURLs {
+		if _, ok := storage.Get().Key(imageURL); ok {
+			stored = append(stored, imageURL)
+		}
+	}
+
+	if len(stored) == 0 {
+		return
+	}
+
+	referenced, err := repository.GetReferencedImageURLs(stored)
+	if err != nil {
+		return
+	}
+
+	for _, imageURL := range stored {
+		if referenced[imageURL] {
+			continue
+		}
+
+		key, _ := storage.Get().Key(imageURL)
+		deleteStoredFiles(imageFileKeys(key))
+	}
+}
+
+// UploadProductImages сохраняет изображения товара продавца и добавляет их к товару
+func UploadProductImages(userID, productID uint, files []*multipart.FileHeader) ([]models.UploadedImage, error) {
+	if len(files) == 0 {
+		return nil, errs.ErrNoImagesUploaded
+	}
+
+	product, err := getOwnedProduct(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(product.ProductImageList)+len(files) > MaxProductImages() {
+		return nil, errs.ErrTooManyImages
+	}
+
+	uploaded := make([]models.UploadedImage, 0, len(files))
+	imageURLs := make([]string, 0, len(files))
+	for _, file := range files {
+		image, err := saveImage(fmt.Sprintf("products/%d", productID), file)
+		if err != nil {
+			DeleteOrphanedImages(imageURLs)
+			return nil, err
+		}
+
+		uploaded = append(uploaded, image)
+		imageURLs = append(imageURLs, image.URL)
+	}
+
+	if err = repository.AddProductImages(productID, imageURLs, MaxProductImages()); err != nil {
+		DeleteOrphanedImages(imageURLs)
+		return nil, err
+	}
+
+	return uploaded, nil
+}
+
+// UploadStoreImage заменяет изображение магазина, файл прежнего изображения удаляется
+func UploadStoreImage(userID, storeID uint, file *multipart.FileHeader) (models.UploadedImage, error) {
+	if file == nil {
+		return models.UploadedImage{}, errs.ErrNoImagesUploaded
+	}
+
+	if _, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageStore); err != nil {
+		return models.UploadedImage{}, err
+	}
+
+	image, err := saveImage(fmt.Sprintf("stores/%d", storeID), file)
+	if err != nil {
+		return models.UploadedImage{}, err
+	}
+
+	previous, err := repository.SetStoreImage(storeID, image.URL)
+	if err != nil {
+		DeleteOrphanedImages([]string{image.URL})
+		return models.UploadedImage{}, err
+	}
+
+	if previous != "" {
+		DeleteOrphanedImages([]string{previous})
+	}
+
+	return image, nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"errors"
+)
+
+// getOwnedProduct возвращает товар, если роль пользователя в магазине товара позволяет менять каталог
+func getOwnedProduct(userID, productID uint) (models.Product, error) {
+	product, err := repository.GetProductByID(productID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.Product{}, errs.ErrProductNotFound
+		}
+
+		return models.Product{}, err
+	}
+
+	store, err := repository.GetStoreByID(product.StoreID)
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	if err = CheckStorePermission(userID, store, models.StorePermissionManageCatalog); err != nil {
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+// AdjustProductStock проводит ручное изменение остатка товара продавцом.
+// Поступление (receipt) может только увеличивать остаток, корректировка (adjustment) — менять его в обе стороны.
+func AdjustProductStock(userID, productID uint, request models.StockAdjustmentRequest) (models.InventoryMovement, error) {
+	if request.Kind == "" {
+		request.Kind = models.MovementAdjustment
+	}
+
+	switch request.Kind {
+	case models.MovementAdjustment:
+	case models.MovementReceipt:
+		if request.Quantity <= 0 {
+			return models.InventoryMovement{}, errs.ErrInvalidQuantity
+		}
+	default:
+		return models.InventoryMovement{}, errs.ErrInvalidMovementKind
+	}
+
+	if request.Quantity == 0 {
+		return models.InventoryMovement{}, errs.ErrInvalidQuantity
+	}
+
+	if _, err := getOwnedProduct(userID, productID); err != nil {
+		return models.InventoryMovement{}, err
+	}
+
+	variant, err := resolveVariant(productID, request.VariantID)
+	if err != nil {
+		return models.InventoryMovement{}, err
+	}
+
+	movement := models.InventoryMovement{
+		ProductID: productID,
+		VariantID: variant.ID,
+		Kind:      request.Kind,
+		Quantity:  request.Quantity,
+		UserID:    &userID,
+		Reason:    request.Reason,
+	}
+
+	if err := repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		return uow.ApplyStockMovement(&movement)
+	}); err != nil {
+		return models.InventoryMovement{}, err
+	}
+
+	return movement, nil
+}
+
+// SetProductPriceAndStock задаёт цену и остаток товара с единственным вариантом, разница остатка записывается как корректировка.
+// У товара с несколькими вариантами цена и остаток меняются через сами варианты.
+func SetProductPriceAndStock(userID, productID uint, price float64, amount uint, reason string) error {
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		if _, err := uow.LockProducts([]uint{productID}); err != nil {
+			return err
+		}
+
+		variants, err := uow.LockProductVariants(productID)
+		if err != nil {
+			return err
+		}
+
+		if len(variants) != 1 {
+			return uow.RefreshProductAggregates(productID)
+		}
+
+		variant := variants[0]
+		if price > 0 && price != variant.Price {
+			if err = uow.UpdateVariantPrice(variant, price); err != nil {
+				return err
+			}
+		}
+
+		if variant.Amount == amount {
+			return nil
+		}
+
+		return uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: productID,
+			VariantID: variant.ID,
+			Kind:      models.MovementAdjustment,
+			Quantity:  int(amount) - int(variant.Amount),
+			UserID:    &userID,
+			Reason:    reason,
+		})
+	})
+}
+
+// GetProductStockHistory возвращает историю движений товара его продавцу
+func GetProductStockHistory(userID, productID uint) ([]models.InventoryMovement, error) {
+	if _, err := getOwnedProduct(userID, productID); err != nil {
+		return nil, err
+	}
+
+	return repository.GetInventoryMovements(productID)
+}
+
+// GetLowStockProducts возвращает товары магазина, остаток которых опустился до порога пополнения
+func GetLowStockProducts(userID, storeID uint) ([]models.Product, error) {
+	if _, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageCatalog); err != nil {
+		return nil, err
+	}
+
+	return repository.GetLowStockProducts(storeID)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/utils"
+	"fmt"
+)
+
+// TopUpAccount пополняет счёт пользователя, записывая пополнение в журнал
+func TopUpAccount(accountID uint, amount float64) error {
+	minorAmount := utils.ToMinorUnits(amount)
+	if minorAmount <= 0 {
+		return errs.ErrInvalidBalance
+	}
+
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		accounts, err := uow.LockAccounts([]uint{accountID})
+		if err != nil {
+			return err
+		}
+
+		if _, ok := accounts[accountID]; !ok {
+			return errs.ErrAccountNotFound
+		}
+
+		return uow.PostJournalEntry(&models.JournalEntry{
+			Kind:        models.JournalKindTopUp,
+			Description: fmt.Sprintf("top up of account %d", accountID),
+			Postings: []models.Posting{
+				{SystemAccount: models.SystemAccountExternal, Direction: models.PostingDebit, Amount: minorAmount},
+				{AccountID: &accountID, Direction: models.PostingCredit, Amount: minorAmount},
+			},
+		})
+	})
+}
+
+// GetAccountTransactions возвращает историю движения средств по счёту
+func GetAccountTransactions(accountID uint) ([]models.Posting, error) {
+	return repository.GetAccountPostings(accountID)
+}
+
+// ReconcileAccounts возвращает счета, баланс которых не совпадает с суммой проводок
+func ReconcileAccounts() ([]models.AccountReconciliation, error) {
+	return repository.GetDivergentAccounts()
+}
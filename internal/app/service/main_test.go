@@ -0,0 +1,16 @@
+package service
+
+import (
+	"BizMart/internal/testutil"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if err := testutil.Setup(); err != nil {
+		log.Fatalf("setting up test database: %v", err)
+	}
+
+	os.Exit(m.Run())
+}
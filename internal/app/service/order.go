@@ -8,25 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func CreateOrderStatus(orderStatus models.OrderStatus) (orderStatusID uint, err error) {
-	var orderStat models.OrderStatus
-
-	orderStat, err = repository.GetOrderStatusByName(orderStatus.StatusName)
-	if err == nil {
-		return 0, errs.ErrOrderStatusNameUniquenessFailed
-	}
-	if orderStat.ID != 0 {
-		return 0, errs.ErrOrderStatusNameUniquenessFailed
-	}
-
-	orderStatID, err := repository.CreateOrderStatus(orderStatus)
-	if err != nil {
-		return 0, errs.ErrValidationFailed
-	}
-
-	return orderStatID, nil
-}
-
+// UpdateOrderStatus меняет название и описание статуса, код статуса, используемый машиной состояний, не меняется
 func UpdateOrderStatus(ordStatID uint, orderStatus models.OrderStatus) (orderStatusID uint, err error) {
 	_, err = repository.GetOrderStatusByID(ordStatID)
 	if err != nil {
@@ -45,120 +27,276 @@ func UpdateOrderStatus(ordStatID uint, orderStatus models.OrderStatus) (orderSta
 	return orderStatusID, nil
 }
 
-func CreateOrder(orderRequest models.OrderRequestJsonBind) (err error) {
-	var order models.Order
-	var orderDetails models.OrderDetails
+type orderLine struct {
+	productID   uint
+	variantID   uint
+	modifierIDs []uint
+	quantity    uint
+}
 
-	order.UserID = orderRequest.UserID
-	order.StatusID = 1
-	orderDetails.ProductID = orderRequest.ProductID
-	orderDetails.AddressID = orderRequest.AddressID
-	orderDetails.Quantity = orderRequest.Quantity
+// placeOrder создаёт заказ и резервирует его товары на складе в рамках переданной транзакции
+func placeOrder(uow *repository.UnitOfWork, userID, addressID uint, lines []orderLine) (models.Order, error) {
+	productIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		productIDs = append(productIDs, line.productID)
+	}
 
-	product, err := repository.GetProductByID(orderDetails.ProductID)
-	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrProductNotFound
-		}
+	variantIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		variantIDs = append(variantIDs, line.variantID)
+	}
 
-		return err
+	products, err := uow.LockProducts(productIDs)
+	if err != nil {
+		return models.Order{}, err
 	}
 
-	orderDetails.Price = product.Price * float64(orderRequest.Quantity)
+	variants, err := uow.LockVariants(variantIDs)
+	if err != nil {
+		return models.Order{}, err
+	}
 
-	_, err = repository.GetAddressByID(orderDetails.AddressID)
+	groups, err := repository.GetModifierGroupsByProductIDs(productIDs)
 	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrProductNotFound
+		return models.Order{}, err
+	}
+
+	productGroups := make(map[uint][]models.ModifierGroup)
+	for _, group := range groups {
+		productGroups[group.ProductID] = append(productGroups[group.ProductID], group)
+	}
+
+	var orderDetails models.OrderDetails
+	var items []models.OrderItem
+
+	for _, line := range lines {
+		product, ok := products[line.productID]
+		if !ok {
+			return models.Order{}, errs.ErrProductNotFound
 		}
 
-		return err
+		variant, ok := variants[line.variantID]
+		if !ok || variant.ProductID != product.ID {
+			return models.Order{}, errs.ErrVariantNotFound
+		}
+
+		if line.quantity == 0 {
+			return models.Order{}, errs.ErrInvalidQuantity
+		}
+
+		if line.quantity > variant.Amount {
+			return models.Order{}, errs.ErrNotEnoughProductInStock
+		}
+
+		modifiers, priceDelta, err := selectModifiers(productGroups[product.ID], line.modifierIDs)
+		if err != nil {
+			return models.Order{}, err
+		}
+
+		// Цена позиции включает надбавки выбранных модификаторов
+		price := variant.Price + priceDelta
+		if price < 0 {
+			return models.Order{}, errs.ErrInvalidModifierSelection
+		}
+
+		items = append(items, models.OrderItem{
+			ProductID:    product.ID,
+			VariantID:    variant.ID,
+			SKU:          variant.SKU,
+			Title:        product.Title,
+			VariantTitle: variant.Title,
+			Price:        price,
+			Quantity:     line.quantity,
+			Modifiers:    modifiers,
+		})
+
+		orderDetails.Price += price * float64(line.quantity)
+		orderDetails.Quantity += line.quantity
+	}
+
+	if len(items) == 1 {
+		orderDetails.ProductID = &items[0].ProductID
 	}
 
-	order.StatusID = 1
+	orderDetails.AddressID = addressID
 
-	if err = repository.CreateOrder(order, orderDetails); err != nil {
-		return err
+	pendingStatus, err := getOrderStatusByCode(models.OrderStatusPending)
+	if err != nil {
+		return models.Order{}, err
 	}
 
-	return nil
-}
+	order := models.Order{
+		UserID:   userID,
+		StatusID: pendingStatus.ID,
+	}
 
-func UpdateOrder(orderID uint, orderRequest models.OrderRequestJsonBind) (err error) {
-	var order models.Order
-	var orderDetails models.OrderDetails
+	if err = uow.CreateOrder(&order, orderDetails, items); err != nil {
+		return models.Order{}, err
+	}
 
-	order.ID = orderID
+	if err = uow.RecordOrderPlaced(order.ID); err != nil {
+		return models.Order{}, err
+	}
 
-	product, err := repository.GetProductByID(orderRequest.ProductID)
-	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrProductNotFound
+	// Товары заблокированы выше, поэтому проверка остатков остаётся актуальной до конца транзакции
+	for _, item := range order.Items {
+		if err = uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Kind:      models.MovementSale,
+			Quantity:  -int(item.Quantity),
+			OrderID:   &order.ID,
+			UserID:    &userID,
+		}); err != nil {
+			return models.Order{}, err
 		}
+	}
 
-		return err
+	if err = recordOrderStatusHistory(uow, order.ID, nil, pendingStatus.ID, 0, orderActorBuyer, userID, ""); err != nil {
+		return models.Order{}, err
 	}
 
-	order, err = repository.GetOrderByID(orderID)
+	if err = reserveOrderStock(uow, order); err != nil {
+		return models.Order{}, err
+	}
+
+	return order, nil
+}
+
+func CreateOrder(orderRequest models.OrderRequestJsonBind) (err error) {
+	_, err = repository.GetAddressByID(orderRequest.AddressID)
 	if err != nil {
 		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrOrderNotFound
+			return errs.ErrAddressNotFound
 		}
 
 		return err
 	}
 
-	orderDetails, err = repository.GetOrderDetailsByID(order.OrderDetailsID)
+	variant, err := resolveVariant(orderRequest.ProductID, orderRequest.VariantID)
 	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrOrderNotFound
-		}
+		return err
+	}
 
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		_, err := placeOrder(uow, orderRequest.UserID, orderRequest.AddressID, []orderLine{
+			{
+				productID:   orderRequest.ProductID,
+				variantID:   variant.ID,
+				modifierIDs: orderRequest.ModifierIDs,
+				quantity:    orderRequest.Quantity,
+			},
+		})
 		return err
+	})
+}
+
+// UpdateOrder меняет количество и адрес неоплаченного заказа. Заказ блокируется до проверки статуса,
+// поэтому оплата или отмена не могут вклиниться между проверкой и изменением остатков, позиций и резервов
+func UpdateOrder(orderID uint, orderRequest models.OrderRequestJsonBind) (err error) {
+	// Заказ без товаров не обновляется, для отказа от заказа есть отмена
+	if orderRequest.Quantity == 0 {
+		return errs.ErrInvalidQuantity
 	}
 
-	if orderRequest.Quantity != 0 {
-		// Проверка на отрицательное значение
-		if product.Amount < 0 || orderRequest.Quantity > product.Amount {
-			return errs.ErrNotEnoughProductInStock
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := uow.LockOrder(orderID)
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return errs.ErrOrderNotFound
+			}
+
+			return err
+		}
+
+		statusCode, err := getOrderStatusCode(order.StatusID)
+		if err != nil {
+			return err
 		}
 
-		newAmount := product.Amount + orderDetails.Quantity - orderRequest.Quantity
+		// Менять состав можно только у заказа, ожидающего оплаты, статус меняется только через машину состояний
+		if statusCode != models.OrderStatusPending {
+			return errs.ErrOrderNotEditable
+		}
 
-		product.Amount = newAmount
+		// Изменять можно только заказ из одной позиции, заказ из корзины пересобирается заново
+		if len(order.Items) != 1 {
+			return errs.ErrOrderNotEditable
+		}
+		item := order.Items[0]
 
-		if err = repository.UpdateProduct(&product); err != nil {
+		// Вариант заказанного товара не меняется, меняются только количество и адрес
+		resolved, err := resolveVariant(item.ProductID, item.VariantID)
+		if err != nil {
 			return err
 		}
-	}
 
-	orderDetails.Price = product.Price * float64(orderRequest.Quantity)
-	orderDetails.AddressID = orderRequest.AddressID
-	order.StatusID = orderRequest.StatusID
+		if _, err = uow.LockProducts([]uint{resolved.ProductID}); err != nil {
+			return err
+		}
 
-	if err = repository.UpdateOrder(order, orderDetails); err != nil {
-		return err
-	}
+		variants, err := uow.LockVariants([]uint{resolved.ID})
+		if err != nil {
+			return err
+		}
 
-	return nil
-}
+		variant, ok := variants[resolved.ID]
+		if !ok {
+			return errs.ErrVariantNotFound
+		}
 
-func DeleteOrder(userID, orderID uint) (err error) {
-	order, err := repository.GetOrderByID(orderID)
-	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrOrderNotFound
+		// Товары этого заказа уже списаны с остатка, поэтому на складе проверяется только прибавка
+		if increase := int(orderRequest.Quantity) - int(item.Quantity); increase > int(variant.Amount) {
+			return errs.ErrNotEnoughProductInStock
 		}
 
-		return err
-	}
+		if orderRequest.Quantity != item.Quantity {
+			movement := models.InventoryMovement{
+				ProductID: variant.ProductID,
+				VariantID: variant.ID,
+				Kind:      models.MovementSale,
+				Quantity:  int(item.Quantity) - int(orderRequest.Quantity),
+				OrderID:   &order.ID,
+				UserID:    &order.UserID,
+				Reason:    "order quantity changed",
+			}
+			if movement.Quantity > 0 {
+				movement.Kind = models.MovementReservationRelease
+			}
 
-	if order.UserID != userID {
-		return errs.ErrPermissionDenied
-	}
+			if err = uow.ApplyStockMovement(&movement); err != nil {
+				return err
+			}
+		}
 
-	if order.StatusID == 3 || order.StatusID == 4 {
-		orderDetails, err := repository.GetOrderDetailsByID(order.OrderDetailsID)
+		// Цена позиции — снимок на момент заказа вместе с модификаторами, поздние изменения цены продавцом её не трогают
+		orderDetails := order.OrderDetails
+		orderDetails.Price = item.Price * float64(orderRequest.Quantity)
+		orderDetails.Quantity = orderRequest.Quantity
+		orderDetails.AddressID = orderRequest.AddressID
+
+		if err = uow.UpdateOrderDetails(orderDetails); err != nil {
+			return err
+		}
+
+		item.Quantity = orderRequest.Quantity
+		if err = uow.UpdateOrderItem(item); err != nil {
+			return err
+		}
+
+		if err = uow.UpdateReservationQuantity(item.ID, item.Quantity); err != nil {
+			return err
+		}
+
+		return nil
+	})
+}
+
+// DeleteOrder удаляет неоплаченный заказ и возвращает его товары на склад.
+// Оплаченные заказы не удаляются, для них используется отмена с возвратом средств.
+func DeleteOrder(userID, orderID uint) (err error) {
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := uow.LockOrder(orderID)
 		if err != nil {
 			if errors.Is(err, errs.ErrRecordNotFound) {
 				return errs.ErrOrderNotFound
@@ -167,35 +305,60 @@ func DeleteOrder(userID, orderID uint) (err error) {
 			return err
 		}
 
-		product, err := repository.GetProductByID(orderDetails.ProductID)
+		if order.UserID != userID {
+			return errs.ErrPermissionDenied
+		}
+
+		if _, err = uow.LockPaymentByOrderID(order.ID); err == nil {
+			return errs.ErrOrderHasBeenPaidFor
+		} else if !errors.Is(err, errs.ErrRecordNotFound) {
+			return err
+		}
+
+		statusCode, err := getOrderStatusCode(order.StatusID)
 		if err != nil {
-			if errors.Is(err, errs.ErrRecordNotFound) {
-				return errs.ErrProductNotFound
+			return err
+		}
+
+		// Отменённый или просроченный заказ уже вернул товары на склад
+		if statusCode != models.OrderStatusCancelled && statusCode != models.OrderStatusExpired {
+			if err = releaseOrderStock(uow, order, userID, "order deleted"); err != nil {
+				return err
 			}
+		}
 
+		if err = uow.RecordOrderDeleted(order.ID); err != nil {
 			return err
 		}
 
-		product.Amount += orderDetails.Quantity
-	}
+		return uow.DeleteOrder(order)
+	})
+}
 
-	if err = repository.DeleteOrder(orderID); err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			return errs.ErrOrderNotFound
-		}
+func ValidateOrder(HandleError func(ctx *gin.Context, err error), orderData models.OrderRequestJsonBind, c *gin.Context) error {
+	if err := ValidateOrderUpdate(HandleError, orderData, c); err != nil {
+		return err
+	}
 
+	variant, err := resolveVariant(orderData.ProductID, orderData.VariantID)
+	if err != nil {
+		HandleError(c, err)
 		return err
 	}
 
+	if orderData.Quantity > variant.Amount {
+		HandleError(c, errs.ErrInvalidQuantity)
+		return errs.ErrInvalidQuantity
+	}
+
 	return nil
 }
 
-func ValidateOrder(HandleError func(ctx *gin.Context, err error), orderData models.OrderRequestJsonBind, c *gin.Context) error {
-	var product models.Product
-	var address *models.Address
-	var err error
-
-	if address, err = repository.GetAddressByID(orderData.AddressID); err != nil {
+// ValidateOrderUpdate проверяет адрес и количество заказа без остатков на складе:
+// при изменении заказа остаток проверяется в UpdateOrder под блокировкой и только на прибавку
+func ValidateOrderUpdate(HandleError func(ctx *gin.Context, err error), orderData models.OrderRequestJsonBind, c *gin.Context) error {
+	address, err := repository.GetAddressByID(orderData.AddressID)
+	if err != nil {
 		HandleError(c, errs.ErrAddressNotFound)
 		return errs.ErrAddressNotFound
 	}
@@ -205,17 +368,7 @@ func ValidateOrder(HandleError func(ctx *gin.Context, err error), orderData mode
 		return errs.ErrAddressNotFound
 	}
 
-	if product, err = repository.GetProductByID(orderData.ProductID); err != nil {
-		HandleError(c, errs.ErrProductNotFound)
-		return errs.ErrProductNotFound
-	}
-
-	if _, err := repository.GetOrderStatusByID(orderData.StatusID); err != nil {
-		HandleError(c, errs.ErrOrderStatusNotFound)
-		return errs.ErrOrderStatusNotFound
-	}
-
-	if orderData.Quantity > product.Amount || orderData.Quantity > 1000 {
+	if orderData.Quantity == 0 || orderData.Quantity > 1000 {
 		HandleError(c, errs.ErrInvalidQuantity)
 		return errs.ErrInvalidQuantity
 	}
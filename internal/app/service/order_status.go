@@ -0,0 +1,429 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"errors"
+	"sync"
+)
+
+// Участники, которые могут менять статус заказа
+const (
+	orderActorBuyer  = "buyer"
+	orderActorSeller = "seller"
+	orderActorSystem = "system"
+)
+
+// orderStatusTransitions описывает разрешённые переходы между статусами и кто может их выполнять.
+// Переходы, связанные с деньгами (оплата, возврат), выполняются только сервисами оплаты и возвратов.
+var orderStatusTransitions = map[string]map[string][]string{
+	models.OrderStatusPending: {
+		models.OrderStatusPaid:      {orderActorSystem},
+		models.OrderStatusCancelled: {orderActorBuyer, orderActorSeller, orderActorSystem},
+		models.OrderStatusExpired:   {orderActorSystem},
+	},
+	models.OrderStatusPaid: {
+		models.OrderStatusPreparing: {orderActorSeller},
+		models.OrderStatusRefunded:  {orderActorBuyer, orderActorSeller, orderActorSystem},
+	},
+	models.OrderStatusPreparing: {
+		models.OrderStatusShipped:  {orderActorSeller},
+		models.OrderStatusRefunded: {orderActorSeller, orderActorSystem},
+	},
+	models.OrderStatusShipped: {
+		models.OrderStatusDelivered: {orderActorBuyer, orderActorSeller},
+		models.OrderStatusRefunded:  {orderActorSeller, orderActorSystem},
+	},
+	models.OrderStatusDelivered: {
+		models.OrderStatusRefunded: {orderActorSeller, orderActorSystem},
+	},
+}
+
+// Статусы, при которых заказ уже оплачен
+var paidOrderStatuses = map[string]bool{
+	models.OrderStatusPaid:      true,
+	models.OrderStatusPreparing: true,
+	models.OrderStatusShipped:   true,
+	models.OrderStatusDelivered: true,
+}
+
+// Порядок статусов выполнения оплаченного заказа. Магазины двигают свои позиции независимо,
+// а заказ находится в наименьшем из статусов своих позиций
+var fulfilmentStatusRank = map[string]int{
+	models.OrderStatusPaid:      0,
+	models.OrderStatusPreparing: 1,
+	models.OrderStatusShipped:   2,
+	models.OrderStatusDelivered: 3,
+}
+
+// orderStatusCache хранит статусы по коду и по ID, статусы создаются при миграции и не меняют свой код
+var orderStatusCache sync.Map
+
+func getOrderStatusByCode(code string) (models.OrderStatus, error) {
+	if cached, ok := orderStatusCache.Load(code); ok {
+		return cached.(models.OrderStatus), nil
+	}
+
+	orderStatus, err := repository.GetOrderStatusByCode(code)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.OrderStatus{}, errs.ErrOrderStatusNotFound
+		}
+
+		return models.OrderStatus{}, err
+	}
+
+	orderStatusCache.Store(code, orderStatus)
+	orderStatusCache.Store(orderStatus.ID, orderStatus)
+	return orderStatus, nil
+}
+
+func getOrderStatusCode(statusID uint) (string, error) {
+	if cached, ok := orderStatusCache.Load(statusID); ok {
+		return cached.(models.OrderStatus).Code, nil
+	}
+
+	orderStatus, err := repository.GetOrderStatusByID(statusID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return "", errs.ErrOrderStatusNotFound
+		}
+
+		return "", err
+	}
+
+	if orderStatus.Code != "" {
+		orderStatusCache.Store(orderStatus.Code, orderStatus)
+		orderStatusCache.Store(orderStatus.ID, orderStatus)
+	}
+
+	return orderStatus.Code, nil
+}
+
+func canTransitOrder(fromCode, toCode, actor string) bool {
+	for _, allowed := range orderStatusTransitions[fromCode][toCode] {
+		if allowed == actor {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transitOrder переводит заблокированный заказ в новый статус и записывает переход в историю
+func transitOrder(uow *repository.UnitOfWork, order *models.Order, toCode, actor string, changedByID uint, reason string) error {
+	fromCode, err := getOrderStatusCode(order.StatusID)
+	if err != nil {
+		return err
+	}
+
+	if !canTransitOrder(fromCode, toCode, actor) {
+		return errs.ErrInvalidOrderStatusTransition
+	}
+
+	toStatus, err := getOrderStatusByCode(toCode)
+	if err != nil {
+		return err
+	}
+
+	if err = uow.UpdateOrderStatus(order.ID, toStatus.ID); err != nil {
+		return err
+	}
+
+	fromStatusID := order.StatusID
+	order.StatusID = toStatus.ID
+
+	return recordOrderStatusHistory(uow, order.ID, &fromStatusID, toStatus.ID, 0, actor, changedByID, reason)
+}
+
+// transitOrderItems переводит позиции scope (позиция -> магазин) в статус toCode и пересчитывает статус заказа.
+// В истории переход записывается отдельно по каждому магазину, чьи позиции сдвинулись
+func transitOrderItems(uow *repository.UnitOfWork, order *models.Order, scope map[uint]uint, toCode, actor string, changedByID uint, reason string) error {
+	orderCode, err := getOrderStatusCode(order.StatusID)
+	if err != nil {
+		return err
+	}
+
+	itemCodes, err := getOrderItemStatusCodes(*order, orderCode)
+	if err != nil {
+		return err
+	}
+
+	moved, err := orderItemsToTransit(order.Items, itemCodes, scope, toCode, actor)
+	if err != nil {
+		return err
+	}
+
+	toStatus, err := getOrderStatusByCode(toCode)
+	if err != nil {
+		return err
+	}
+
+	if err = uow.UpdateOrderItemsStatus(moved, toStatus.ID); err != nil {
+		return err
+	}
+
+	recordedStores := make(map[uint]bool)
+	for _, itemID := range moved {
+		storeID := scope[itemID]
+		if recordedStores[storeID] {
+			continue
+		}
+		recordedStores[storeID] = true
+
+		fromStatus, err := getOrderStatusByCode(itemCodes[itemID])
+		if err != nil {
+			return err
+		}
+
+		if err = recordOrderStatusHistory(uow, order.ID, &fromStatus.ID, toStatus.ID, storeID, actor, changedByID, reason); err != nil {
+			return err
+		}
+	}
+
+	movedItems := make(map[uint]bool, len(moved))
+	for _, itemID := range moved {
+		movedItems[itemID] = true
+	}
+
+	for i := range order.Items {
+		if movedItems[order.Items[i].ID] {
+			statusID := toStatus.ID
+			order.Items[i].StatusID = &statusID
+		}
+	}
+
+	return syncOrderStatus(uow, order, actor, changedByID, reason)
+}
+
+// syncOrderStatus переводит оплаченный заказ в статус, выведенный из статусов его позиций
+func syncOrderStatus(uow *repository.UnitOfWork, order *models.Order, actor string, changedByID uint, reason string) error {
+	orderCode, err := getOrderStatusCode(order.StatusID)
+	if err != nil {
+		return err
+	}
+
+	itemCodes, err := getOrderItemStatusCodes(*order, orderCode)
+	if err != nil {
+		return err
+	}
+
+	derivedCode := deriveOrderStatus(orderCode, order.Items, itemCodes)
+	if derivedCode == orderCode {
+		return nil
+	}
+
+	derivedStatus, err := getOrderStatusByCode(derivedCode)
+	if err != nil {
+		return err
+	}
+
+	if err = uow.UpdateOrderStatus(order.ID, derivedStatus.ID); err != nil {
+		return err
+	}
+
+	fromStatusID := order.StatusID
+	order.StatusID = derivedStatus.ID
+
+	return recordOrderStatusHistory(uow, order.ID, &fromStatusID, derivedStatus.ID, 0, actor, changedByID, reason)
+}
+
+// getOrderItemStatusCodes возвращает статусы позиций заказа. Позиции, которые магазин ещё не двигал,
+// и позиции неоплаченного, отменённого или возвращённого заказа находятся в статусе самого заказа
+func getOrderItemStatusCodes(order models.Order, orderCode string) (map[uint]string, error) {
+	itemCodes := make(map[uint]string, len(order.Items))
+	for _, item := range order.Items {
+		if item.StatusID == nil || !paidOrderStatuses[orderCode] {
+			itemCodes[item.ID] = orderCode
+			continue
+		}
+
+		code, err := getOrderStatusCode(*item.StatusID)
+		if err != nil {
+			return nil, err
+		}
+		itemCodes[item.ID] = code
+	}
+
+	return itemCodes, nil
+}
+
+// orderItemsToTransit возвращает позиции из scope, которые переходят в статус toCode.
+// Полностью возвращённые позиции пропускаются, переход должен быть разрешён для каждой из остальных
+func orderItemsToTransit(items []models.OrderItem, itemCodes map[uint]string, scope map[uint]uint, toCode, actor string) ([]uint, error) {
+	var moved []uint
+	for _, item := range items {
+		if _, ok := scope[item.ID]; !ok || item.RefundedQuantity >= item.Quantity {
+			continue
+		}
+
+		if !canTransitOrder(itemCodes[item.ID], toCode, actor) {
+			return nil, errs.ErrInvalidOrderStatusTransition
+		}
+
+		moved = append(moved, item.ID)
+	}
+
+	if len(moved) == 0 {
+		return nil, errs.ErrInvalidOrderStatusTransition
+	}
+
+	return moved, nil
+}
+
+// deriveOrderStatus возвращает статус оплаченного заказа по статусам его невозвращённых позиций:
+// заказ переходит дальше, только когда все магазины перевели свои позиции
+func deriveOrderStatus(orderCode string, items []models.OrderItem, itemCodes map[uint]string) string {
+	if !paidOrderStatuses[orderCode] {
+		return orderCode
+	}
+
+	derivedCode := ""
+	for _, item := range items {
+		if item.RefundedQuantity >= item.Quantity {
+			continue
+		}
+
+		code := itemCodes[item.ID]
+		if _, ok := fulfilmentStatusRank[code]; !ok {
+			return orderCode
+		}
+
+		if derivedCode == "" || fulfilmentStatusRank[code] < fulfilmentStatusRank[derivedCode] {
+			derivedCode = code
+		}
+	}
+
+	if derivedCode == "" {
+		return orderCode
+	}
+
+	return derivedCode
+}
+
+func recordOrderStatusHistory(uow *repository.UnitOfWork, orderID uint, fromStatusID *uint, toStatusID, storeID uint, actor string, changedByID uint, reason string) error {
+	history := models.OrderStatusHistory{
+		OrderID:      orderID,
+		FromStatusID: fromStatusID,
+		ToStatusID:   toStatusID,
+		Actor:        actor,
+		Reason:       reason,
+	}
+	if changedByID != 0 {
+		history.ChangedByID = &changedByID
+	}
+	if storeID != 0 {
+		history.StoreID = &storeID
+	}
+
+	return uow.CreateOrderStatusHistory(&history)
+}
+
+// ChangeOrderStatus выполняет переход статуса позиций заказа по запросу покупателя или продавца.
+// Продавец двигает только позиции своих магазинов, покупатель — все позиции или позиции одного магазина.
+// Оплата, отмена и возврат выполняются через свои эндпоинты, так как они двигают деньги и товары.
+func ChangeOrderStatus(userID, orderID uint, request models.OrderStatusChangeRequest) error {
+	switch request.Status {
+	case models.OrderStatusPaid, models.OrderStatusCancelled, models.OrderStatusRefunded, models.OrderStatusExpired:
+		return errs.ErrInvalidOrderStatusTransition
+	}
+
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := uow.LockOrder(orderID)
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return errs.ErrOrderNotFound
+			}
+
+			return err
+		}
+
+		actor, scope, err := getOrderActor(uow, userID, order, request.Status)
+		if err != nil {
+			return err
+		}
+
+		if request.StoreID != 0 {
+			for itemID, storeID := range scope {
+				if storeID != request.StoreID {
+					delete(scope, itemID)
+				}
+			}
+		}
+
+		return transitOrderItems(uow, &order, scope, request.Status, actor, userID, request.Reason)
+	})
+}
+
+// getOrderActor определяет роль пользователя в заказе для перехода в статус toCode и позиции,
+// которыми он распоряжается (позиция -> магазин)
+func getOrderActor(uow *repository.UnitOfWork, userID uint, order models.Order, toCode string) (string, map[uint]uint, error) {
+	itemStores, err := getOrderItemStores(uow, order.Items)
+	if err != nil {
+		return "", nil, err
+	}
+
+	owned, err := ownedOrderItems(userID, itemStores)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return resolveOrderActor(userID, order, itemStores, owned, toCode)
+}
+
+// resolveOrderActor выбирает роль пользователя: покупатель распоряжается всеми позициями заказа,
+// продавец — позициями своих магазинов. Покупатель, который продаёт часть позиций своего заказа, действует
+// как покупатель, если покупателю вообще разрешён переход в toCode, и как продавец своих позиций иначе
+func resolveOrderActor(userID uint, order models.Order, itemStores, owned map[uint]uint, toCode string) (string, map[uint]uint, error) {
+	if order.UserID == userID && (len(owned) == 0 || actorCanReachStatus(orderActorBuyer, toCode)) {
+		scope := make(map[uint]uint, len(order.Items))
+		for _, item := range order.Items {
+			scope[item.ID] = itemStores[item.ID]
+		}
+
+		return orderActorBuyer, scope, nil
+	}
+
+	if len(owned) > 0 {
+		return orderActorSeller, owned, nil
+	}
+
+	return "", nil, errs.ErrPermissionDenied
+}
+
+// actorCanReachStatus сообщает, может ли участник перевести заказ в статус toCode хоть из какого-то статуса
+func actorCanReachStatus(actor, toCode string) bool {
+	for fromCode := range orderStatusTransitions {
+		if canTransitOrder(fromCode, toCode, actor) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetOrderStatusHistory возвращает историю статусов заказа покупателю или продавцу
+func GetOrderStatusHistory(userID, orderID uint) ([]models.OrderStatusHistory, error) {
+	order, err := repository.GetOrderByID(orderID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, errs.ErrOrderNotFound
+		}
+
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		isSeller, err := hasOrderItemsOfSeller(userID, order)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isSeller {
+			return nil, errs.ErrPermissionDenied
+		}
+	}
+
+	return repository.GetOrderStatusHistory(orderID)
+}
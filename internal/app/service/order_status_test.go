@@ -0,0 +1,316 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/internal/testutil"
+	"BizMart/pkg/errs"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTransitTwoStoreOrderItems(t *testing.T) {
+	const storeA, storeB = 10, 20
+
+	// Позиции 1 и 2 из магазина A, позиция 3 из магазина B
+	items := []models.OrderItem{
+		{ID: 1, Quantity: 2},
+		{ID: 2, Quantity: 1},
+		{ID: 3, Quantity: 1},
+	}
+	storeItems := func(storeID uint) map[uint]uint {
+		scope := make(map[uint]uint)
+		for itemID, itemStore := range map[uint]uint{1: storeA, 2: storeA, 3: storeB} {
+			if storeID == 0 || itemStore == storeID {
+				scope[itemID] = itemStore
+			}
+		}
+		return scope
+	}
+
+	itemCodes := map[uint]string{
+		1: models.OrderStatusPaid,
+		2: models.OrderStatusPaid,
+		3: models.OrderStatusPaid,
+	}
+	orderCode := models.OrderStatusPaid
+
+	steps := []struct {
+		name      string
+		scope     map[uint]uint
+		to        string
+		actor     string
+		wantErr   error
+		wantMoved []uint
+		wantOrder string
+	}{
+		{
+			name:      "store A prepares its items",
+			scope:     storeItems(storeA),
+			to:        models.OrderStatusPreparing,
+			actor:     orderActorSeller,
+			wantMoved: []uint{1, 2},
+			wantOrder: models.OrderStatusPaid,
+		},
+		{
+			name:      "store B prepares its items",
+			scope:     storeItems(storeB),
+			to:        models.OrderStatusPreparing,
+			actor:     orderActorSeller,
+			wantMoved: []uint{3},
+			wantOrder: models.OrderStatusPreparing,
+		},
+		{
+			name:      "store A ships its items",
+			scope:     storeItems(storeA),
+			to:        models.OrderStatusShipped,
+			actor:     orderActorSeller,
+			wantMoved: []uint{1, 2},
+			wantOrder: models.OrderStatusPreparing,
+		},
+		{
+			name:    "store A can't ship its items twice",
+			scope:   storeItems(storeA),
+			to:      models.OrderStatusShipped,
+			actor:   orderActorSeller,
+			wantErr: errs.ErrInvalidOrderStatusTransition,
+		},
+		{
+			name:    "buyer can't confirm delivery of the whole order before store B ships",
+			scope:   storeItems(0),
+			to:      models.OrderStatusDelivered,
+			actor:   orderActorBuyer,
+			wantErr: errs.ErrInvalidOrderStatusTransition,
+		},
+		{
+			name:      "buyer confirms delivery of store A items",
+			scope:     storeItems(storeA),
+			to:        models.OrderStatusDelivered,
+			actor:     orderActorBuyer,
+			wantMoved: []uint{1, 2},
+			wantOrder: models.OrderStatusPreparing,
+		},
+		{
+			name:      "store B ships its items",
+			scope:     storeItems(storeB),
+			to:        models.OrderStatusShipped,
+			actor:     orderActorSeller,
+			wantMoved: []uint{3},
+			wantOrder: models.OrderStatusShipped,
+		},
+	}
+
+	for _, step := range steps {
+		moved, err := orderItemsToTransit(items, itemCodes, step.scope, step.to, step.actor)
+		if step.wantErr != nil {
+			if !errors.Is(err, step.wantErr) {
+				t.Fatalf("%s: expected %v, got %v", step.name, step.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if !reflect.DeepEqual(moved, step.wantMoved) {
+			t.Fatalf("%s: expected items %v to move, got %v", step.name, step.wantMoved, moved)
+		}
+
+		for _, itemID := range moved {
+			itemCodes[itemID] = step.to
+		}
+
+		orderCode = deriveOrderStatus(orderCode, items, itemCodes)
+		if orderCode != step.wantOrder {
+			t.Fatalf("%s: expected order status %s, got %s", step.name, step.wantOrder, orderCode)
+		}
+	}
+}
+
+func TestDeriveOrderStatusSkipsRefundedItems(t *testing.T) {
+	items := []models.OrderItem{
+		{ID: 1, Quantity: 2},
+		{ID: 2, Quantity: 1, RefundedQuantity: 1},
+	}
+
+	tests := []struct {
+		name      string
+		orderCode string
+		itemCodes map[uint]string
+		want      string
+	}{
+		{
+			name:      "refunded items of the slowest store don't hold the order back",
+			orderCode: models.OrderStatusPaid,
+			itemCodes: map[uint]string{1: models.OrderStatusShipped, 2: models.OrderStatusPaid},
+			want:      models.OrderStatusShipped,
+		},
+		{
+			name:      "unpaid order keeps its status",
+			orderCode: models.OrderStatusPending,
+			itemCodes: map[uint]string{1: models.OrderStatusPending, 2: models.OrderStatusPending},
+			want:      models.OrderStatusPending,
+		},
+		{
+			name:      "unknown item status keeps the order status",
+			orderCode: models.OrderStatusPreparing,
+			itemCodes: map[uint]string{1: models.OrderStatusCancelled, 2: models.OrderStatusPaid},
+			want:      models.OrderStatusPreparing,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deriveOrderStatus(tt.orderCode, items, tt.itemCodes); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResolveOrderActorOfBuyerWhoAlsoSells(t *testing.T) {
+	const buyerID, otherID, ownStore, otherStore = 1, 2, 10, 20
+
+	// Позиция 1 из магазина самого покупателя, позиция 2 из чужого магазина
+	order := models.Order{UserID: buyerID, Items: []models.OrderItem{{ID: 1}, {ID: 2}}}
+	itemStores := map[uint]uint{1: ownStore, 2: otherStore}
+	owned := map[uint]uint{1: ownStore}
+
+	tests := []struct {
+		name      string
+		userID    uint
+		owned     map[uint]uint
+		to        string
+		wantActor string
+		wantScope map[uint]uint
+		wantErr   error
+	}{
+		{
+			name:      "cancels the whole order as the buyer",
+			userID:    buyerID,
+			owned:     owned,
+			to:        models.OrderStatusCancelled,
+			wantActor: orderActorBuyer,
+			wantScope: itemStores,
+		},
+		{
+			name:      "confirms delivery of every store as the buyer",
+			userID:    buyerID,
+			owned:     owned,
+			to:        models.OrderStatusDelivered,
+			wantActor: orderActorBuyer,
+			wantScope: itemStores,
+		},
+		{
+			name:      "ships own items as the seller",
+			userID:    buyerID,
+			owned:     owned,
+			to:        models.OrderStatusShipped,
+			wantActor: orderActorSeller,
+			wantScope: owned,
+		},
+		{
+			name:      "buyer without own items stays the buyer",
+			userID:    buyerID,
+			to:        models.OrderStatusShipped,
+			wantActor: orderActorBuyer,
+			wantScope: itemStores,
+		},
+		{
+			name:    "stranger is denied",
+			userID:  otherID,
+			to:      models.OrderStatusCancelled,
+			wantErr: errs.ErrPermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor, scope, err := resolveOrderActor(tt.userID, order, itemStores, tt.owned, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+
+			if actor != tt.wantActor || !reflect.DeepEqual(scope, tt.wantScope) {
+				t.Fatalf("expected %s with %v, got %s with %v", tt.wantActor, tt.wantScope, actor, scope)
+			}
+		})
+	}
+}
+
+func TestChangeOrderStatusOfTwoStoreOrder(t *testing.T) {
+	testutil.RequireDB(t)
+
+	a := testutil.NewFixture(t, 10, 25, 100)
+	b := testutil.NewFixture(t, 10, 25, 0)
+
+	for _, variant := range []models.ProductVariant{a.Variant, b.Variant} {
+		if err := AddCartItem(a.Buyer.ID, models.CartItemRequest{
+			ProductID: variant.ProductID,
+			VariantID: variant.ID,
+			Quantity:  1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orderID, err := Checkout(a.Buyer.ID, a.Address.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = CreatePayment(models.Payment{
+		UserID:    a.Buyer.ID,
+		OrderID:   orderID,
+		AccountID: a.BuyerAccount.ID,
+		Amount:    2,
+		Price:     50,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	requireOrderStatus := func(want string) {
+		t.Helper()
+
+		order, err := repository.GetOrderByID(orderID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		code, err := getOrderStatusCode(order.StatusID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if code != want {
+			t.Fatalf("expected order status %s, got %s", want, code)
+		}
+	}
+
+	preparing := models.OrderStatusChangeRequest{Status: models.OrderStatusPreparing}
+	if err = ChangeOrderStatus(a.Seller.ID, orderID, preparing); err != nil {
+		t.Fatal(err)
+	}
+	requireOrderStatus(models.OrderStatusPaid)
+
+	if err = ChangeOrderStatus(b.Seller.ID, orderID, preparing); err != nil {
+		t.Fatal(err)
+	}
+	requireOrderStatus(models.OrderStatusPreparing)
+
+	refund, err := CancelOrder(b.Seller.ID, orderID, "out of stock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(refund.Items) != 1 || refund.Amount != 25 {
+		t.Fatalf("expected store B to refund only its item, got %+v", refund)
+	}
+	requireOrderStatus(models.OrderStatusPreparing)
+
+	if _, err = CancelOrder(a.Seller.ID, orderID, "out of stock"); err != nil {
+		t.Fatal(err)
+	}
+	requireOrderStatus(models.OrderStatusRefunded)
+}
@@ -4,7 +4,9 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/utils"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 )
 
@@ -57,61 +59,162 @@ func ValidatePayment(HandleError func(ctx *gin.Context, err error), paymentData
 	return nil
 }
 
+// CreatePayment оплачивает заказ, платить может только пользователь с подтверждённым email
 func CreatePayment(payment models.Payment) error {
-	order, err := repository.GetOrderByID(payment.OrderID)
-	if err != nil {
+	if err := RequireVerifiedEmail(payment.UserID); err != nil {
 		return err
 	}
 
-	if order.StatusID == 3 || order.StatusID == 4 {
-		return errs.ErrOrderAlreadyPayed
-	}
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := uow.LockOrder(payment.OrderID)
+		if err != nil {
+			return err
+		}
 
-	var accountStore models.Account
+		statusCode, err := getOrderStatusCode(order.StatusID)
+		if err != nil {
+			return err
+		}
 
-	account, err := repository.GetAccountByID(payment.AccountID)
-	if err != nil {
-		return err
-	}
+		if paidOrderStatuses[statusCode] {
+			return errs.ErrOrderAlreadyPayed
+		}
 
-	product, err := repository.GetProductByID(order.OrderDetails.ProductID)
-	if err != nil {
-		return err
-	}
+		if statusCode != models.OrderStatusPending {
+			return errs.ErrInvalidOrderStatusTransition
+		}
+
+		if err = convertOrderReservations(uow, order.ID); err != nil {
+			return err
+		}
 
-	if account.Balance > order.OrderDetails.Price {
-		account.Balance -= order.OrderDetails.Price
-		store, err := repository.GetStoreByID(product.StoreID)
+		storeAccounts, err := resolveStoreAccounts(uow, order.Items)
 		if err != nil {
 			return err
 		}
 
-		accountStores, err := repository.GetAccountsByUserID(store.OwnerID)
+		// Каждый магазин получает сумму только за свои позиции заказа
+		credits := make(map[uint]int64)
+		accountIDs := []uint{payment.AccountID}
+		for _, item := range order.Items {
+			storeAccountID := storeAccounts[item.ID]
+			if _, ok := credits[storeAccountID]; !ok {
+				accountIDs = append(accountIDs, storeAccountID)
+			}
+			credits[storeAccountID] += utils.ToMinorUnits(item.Price * float64(item.Quantity))
+		}
+
+		accounts, err := uow.LockAccounts(accountIDs)
 		if err != nil {
 			return err
 		}
 
-		accountStore = accountStores[0]
-		accountStore.Balance += order.OrderDetails.Price
-	} else {
-		return errs.ErrInsufficientFunds
+		buyerAccount, ok := accounts[payment.AccountID]
+		if !ok {
+			return errs.ErrAccountNotFound
+		}
+
+		var total int64
+		for accountID, amount := range credits {
+			if _, ok = accounts[accountID]; !ok {
+				return errs.ErrAccountNotFound
+			}
+			total += amount
+		}
+
+		if utils.ToMinorUnits(buyerAccount.Balance) < total {
+			return errs.ErrInsufficientFunds
+		}
+
+		if err = transitOrder(uow, &order, models.OrderStatusPaid, orderActorSystem, payment.UserID, ""); err != nil {
+			return err
+		}
+
+		if err = uow.RecordOrderPaid(order.ID); err != nil {
+			return err
+		}
+
+		if err = uow.CreatePayment(&payment); err != nil {
+			return err
+		}
+
+		// Возвраты списывают деньги с тех же счетов, на которые была зачислена оплата
+		for _, item := range order.Items {
+			if err = uow.SetOrderItemPayoutAccount(item.ID, storeAccounts[item.ID]); err != nil {
+				return err
+			}
+		}
+
+		if err = uow.PostJournalEntry(&models.JournalEntry{
+			Kind:        models.JournalKindPurchase,
+			PaymentID:   &payment.ID,
+			Description: fmt.Sprintf("payment for order %d", order.ID),
+			Postings: []models.Posting{
+				{AccountID: &buyerAccount.ID, Direction: models.PostingDebit, Amount: total},
+				{SystemAccount: models.SystemAccountSettlement, Direction: models.PostingCredit, Amount: total},
+			},
+		}); err != nil {
+			return err
+		}
+
+		payout := models.JournalEntry{
+			Kind:        models.JournalKindPayout,
+			PaymentID:   &payment.ID,
+			Description: fmt.Sprintf("payout for order %d", order.ID),
+			Postings: []models.Posting{
+				{SystemAccount: models.SystemAccountSettlement, Direction: models.PostingDebit, Amount: total},
+			},
+		}
+		for accountID, amount := range credits {
+			storeAccountID := accountID
+			payout.Postings = append(payout.Postings, models.Posting{
+				AccountID: &storeAccountID,
+				Direction: models.PostingCredit,
+				Amount:    amount,
+			})
+		}
+
+		return uow.PostJournalEntry(&payout)
+	})
+}
+
+// resolveStoreAccounts возвращает для каждой позиции заказа счёт владельца магазина, на который зачисляется оплата.
+// Счёт выбирается внутри транзакции: самый старый счёт владельца
+func resolveStoreAccounts(uow *repository.UnitOfWork, items []models.OrderItem) (map[uint]uint, error) {
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
 	}
 
-	if err = repository.UpdateAccount(account); err != nil {
-		return err
+	stores, err := uow.GetStoresByProductIDs(productIDs)
+	if err != nil {
+		return nil, err
 	}
-	if err = repository.UpdateAccount(accountStore); err != nil {
-		return err
+
+	ownerIDs := make([]uint, 0, len(stores))
+	for _, store := range stores {
+		ownerIDs = append(ownerIDs, store.OwnerID)
 	}
 
-	order.StatusID = 3
-	if err = repository.UpdateOrder(order, order.OrderDetails); err != nil {
-		return err
+	payoutAccounts, err := uow.GetPayoutAccountIDs(ownerIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	if err = repository.CreatePayment(payment); err != nil {
-		return err
+	storeAccounts := make(map[uint]uint, len(items))
+	for _, item := range items {
+		store, ok := stores[item.ProductID]
+		if !ok {
+			return nil, errs.ErrProductNotFound
+		}
+
+		accountID, ok := payoutAccounts[store.OwnerID]
+		if !ok {
+			return nil, errs.ErrAccountNotFound
+		}
+
+		storeAccounts[item.ID] = accountID
 	}
 
-	return nil
+	return storeAccounts, nil
 }
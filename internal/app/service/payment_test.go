@@ -0,0 +1,67 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/testutil"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestCreatePaymentRollsBackOnError(t *testing.T) {
+	testutil.RequireDB(t)
+
+	tests := []struct {
+		name  string
+		match func(tx *gorm.DB) bool
+	}{
+		{
+			name: "after order status change",
+			match: func(tx *gorm.DB) bool {
+				return tx.Statement.Table == "payapp_payment"
+			},
+		},
+		{
+			name: "on seller payout posting",
+			match: func(tx *gorm.DB) bool {
+				entry, ok := tx.Statement.Dest.(*models.JournalEntry)
+				return ok && entry.Kind == models.JournalKindPayout
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := testutil.NewFixture(t, 10, 25, 100)
+			if err := AddCartItem(f.Buyer.ID, models.CartItemRequest{
+				ProductID: f.Product.ID,
+				VariantID: f.Variant.ID,
+				Quantity:  2,
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			orderID, err := Checkout(f.Buyer.ID, f.Address.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := f.TakeSnapshot(t)
+			testutil.InjectError(t, tt.match)
+
+			err = CreatePayment(models.Payment{
+				UserID:    f.Buyer.ID,
+				OrderID:   orderID,
+				AccountID: f.BuyerAccount.ID,
+				Amount:    2,
+				Price:     50,
+			})
+			if !errors.Is(err, testutil.ErrInjected) {
+				t.Fatalf("expected injected error, got %v", err)
+			}
+
+			f.RequireUnchanged(t, before)
+		})
+	}
+}
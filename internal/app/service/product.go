@@ -7,33 +7,37 @@ import (
 )
 
 func ValidateProduct(HandleError func(ctx *gin.Context, err error), productData models.Product, c *gin.Context, isUpdate bool) error {
+	if err := CheckProduct(productData, isUpdate); err != nil {
+		HandleError(c, err)
+		return err
+	}
+
+	return nil
+}
+
+// CheckProduct проверяет поля товара, те же правила применяются к строкам массового импорта
+func CheckProduct(productData models.Product, isUpdate bool) error {
 	if productData.Amount <= 0 {
-		HandleError(c, errs.ErrInvalidAmount)
 		return errs.ErrInvalidAmount
 	}
 
 	if productData.Price <= 0 {
-		HandleError(c, errs.ErrInvalidPrice)
 		return errs.ErrInvalidPrice
 	}
 
 	if productData.CategoryID <= 0 {
-		HandleError(c, errs.ErrInvalidCategory)
 		return errs.ErrInvalidCategory
 	}
 
 	if len(productData.Title) <= 5 {
-		HandleError(c, errs.ErrInvalidTitle)
 		return errs.ErrInvalidTitle
 	}
 
 	if len(productData.Description) <= 5 {
-		HandleError(c, errs.ErrInvalidDescription)
 		return errs.ErrInvalidDescription
 	}
 
 	if productData.Views > 0 && !isUpdate {
-		HandleError(c, errs.ErrPermissionDenied)
 		return errs.ErrPermissionDenied
 	}
 
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/db"
+	"BizMart/pkg/pagination"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// productListCacheTTL ограничивает устаревание списков изменениями, которые их не инвалидируют: остатки, просмотры, продажи
+	productListCacheTTL = 5 * time.Minute
+	productCacheTTL     = 10 * time.Minute
+)
+
+// productListCacheKey строит ключ списка товаров из нормализованных фильтров и параметров страницы,
+// поэтому запросы, отличающиеся только порядком параметров или пробелами, попадают в один ключ
+func productListCacheKey(filter models.ProductFilter, params pagination.Params) string {
+	values := url.Values{}
+	if filter.MinPrice > 0 {
+		values.Set("min_price", strconv.FormatFloat(filter.MinPrice, 'f', -1, 64))
+	}
+	if filter.MaxPrice > 0 {
+		values.Set("max_price", strconv.FormatFloat(filter.MaxPrice, 'f', -1, 64))
+	}
+	if filter.CategoryID > 0 {
+		values.Set("category", strconv.FormatUint(uint64(filter.CategoryID), 10))
+	}
+	if filter.StoreID > 0 {
+		values.Set("store", strconv.FormatUint(uint64(filter.StoreID), 10))
+	}
+	if filter.ProductName != "" {
+		values.Set("product_name", filter.ProductName)
+	}
+	for name, value := range filter.Options {
+		values.Set("option."+name, value)
+	}
+
+	if params.Limit > 0 {
+		values.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Cursor != "" {
+		values.Set("cursor", params.Cursor)
+	}
+	if params.Sort != "" {
+		values.Set("sort", params.Sort)
+	}
+	if params.Order != "" {
+		values.Set("order", params.Order)
+	}
+	if len(params.Fields) > 0 {
+		fields := append([]string{}, params.Fields...)
+		sort.Strings(fields)
+		values.Set("fields", strings.Join(fields, ","))
+	}
+
+	return db.CacheKey("products", values)
+}
+
+// GetProducts возвращает страницу товаров из кэша или из базы.
+// Список сбрасывается при изменении товаров его магазина или категории, а без этих фильтров — при изменении любого товара
+func GetProducts(filter models.ProductFilter, params pagination.Params) (pagination.Page[models.Product], error) {
+	filter.ProductName = strings.TrimSpace(filter.ProductName)
+
+	page, err := db.GetOrLoadCache(productListCacheKey(filter, params), repository.ProductListCacheTags(filter), productListCacheTTL,
+		func() (pagination.Page[models.Product], error) {
+			page, err := repository.GetAllProducts(filter, params)
+			// В кэше хранятся все поля, выбор полей применяется при ответе
+			return page.WithFields(nil), err
+		})
+	if err != nil {
+		return pagination.Page[models.Product]{}, err
+	}
+
+	return page.WithFields(params.Fields), nil
+}
+
+// GetProduct возвращает товар с вариантами и модификаторами из кэша или из базы
+func GetProduct(productID uint) (models.Product, error) {
+	key := db.CacheKey("product", url.Values{"id": {strconv.FormatUint(uint64(productID), 10)}})
+
+	return db.GetOrLoadCache(key, repository.ProductCacheTags(productID), productCacheTTL, func() (models.Product, error) {
+		return repository.GetProductByID(productID)
+	})
+}
@@ -0,0 +1,502 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/spreadsheet"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxImportFileSize максимальный размер файла импорта в байтах
+	MaxImportFileSize = 10 << 20
+	// maxImportRows максимальное количество строк товаров в одном файле
+	maxImportRows = 10000
+	// importProgressBatch как часто прогресс импорта сохраняется в базе
+	importProgressBatch = 50
+	// importProgressInterval прогресс сохраняется не реже этого интервала, даже если строки обрабатываются медленно
+	importProgressInterval = time.Minute
+	// importStallTimeout через сколько без сохранения прогресса выполняемый импорт считается брошенным
+	importStallTimeout = 10 * time.Minute
+	// maxImportAttempts сколько раз импорт запускается заново, прежде чем считается проваленным
+	maxImportAttempts = 3
+	// importImageSeparator разделитель ссылок на изображения в ячейке images
+	importImageSeparator = "|"
+)
+
+// importQueued будит обработчик импортов, когда появляется новый импорт
+var importQueued = make(chan struct{}, 1)
+
+// importRequiredColumns столбцы, без которых файл не принимается
+var importRequiredColumns = []string{"sku", "title", "description", "category_id", "price", "amount"}
+
+// importRowError ошибка строки импорта и столбец, к которому она относится
+type importRowError struct {
+	column string
+	err    error
+}
+
+func (e importRowError) Error() string {
+	return e.err.Error()
+}
+
+// productImportRow разобранная строка файла импорта
+type productImportRow struct {
+	line      int
+	sku       string
+	product   models.Product
+	images    []string
+	hasImages bool
+}
+
+// importColumns сопоставляет названия столбцов с их номерами, названия не зависят от регистра и порядка
+func importColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range importRequiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, errs.ErrInvalidImportFile
+		}
+	}
+
+	return columns, nil
+}
+
+func parseImportUint(value, column string, invalid error) (uint, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	// Excel может сохранить целое число как 12.0
+	number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil || number < 0 || number != math.Trunc(number) || number > math.MaxUint32 {
+		return 0, importRowError{column: column, err: invalid}
+	}
+
+	return uint(number), nil
+}
+
+func parseImportRow(columns map[string]int, row []string, line int) (productImportRow, error) {
+	cell := func(name string) string {
+		if index, ok := columns[name]; ok && index < len(row) {
+			return row[index]
+		}
+		return ""
+	}
+
+	parsed := productImportRow{line: line, sku: cell("sku")}
+	if parsed.sku == "" || len(parsed.sku) > 64 {
+		return parsed, importRowError{column: "sku", err: errs.ErrInvalidSKU}
+	}
+
+	price, err := strconv.ParseFloat(strings.ReplaceAll(cell("price"), ",", "."), 64)
+	if err != nil || math.IsNaN(price) || math.IsInf(price, 0) {
+		return parsed, importRowError{column: "price", err: errs.ErrInvalidPrice}
+	}
+
+	amount, err := parseImportUint(cell("amount"), "amount", errs.ErrInvalidAmount)
+	if err != nil {
+		return parsed, err
+	}
+
+	categoryID, err := parseImportUint(cell("category_id"), "category_id", errs.ErrInvalidCategory)
+	if err != nil {
+		return parsed, err
+	}
+
+	threshold, err := parseImportUint(cell("low_stock_threshold"), "low_stock_threshold", errs.ErrInvalidAmount)
+	if err != nil {
+		return parsed, err
+	}
+
+	parsed.product = models.Product{
+		CategoryID:        categoryID,
+		Title:             cell("title"),
+		Description:       cell("description"),
+		Price:             price,
+		Amount:            amount,
+		LowStockThreshold: threshold,
+		GTIN:              cell("gtin"),
+	}
+
+	if _, ok := columns["images"]; ok {
+		parsed.hasImages = true
+		for _, image := range strings.Split(cell("images"), importImageSeparator) {
+			if image = strings.TrimSpace(image); image != "" {
+				parsed.images = append(parsed.images, image)
+			}
+		}
+
+		if len(parsed.images) > MaxProductImages() {
+			return parsed, importRowError{column: "images", err: errs.ErrTooManyImages}
+		}
+	}
+
+	if err = CheckProduct(parsed.product, false); err != nil {
+		return parsed, importRowError{column: checkProductColumn(err), err: err}
+	}
+
+	return parsed, nil
+}
+
+// checkProductColumn возвращает столбец, к которому относится ошибка проверки товара
+func checkProductColumn(err error) string {
+	switch {
+	case errors.Is(err, errs.ErrInvalidAmount):
+		return "amount"
+	case errors.Is(err, errs.ErrInvalidPrice):
+		return "price"
+	case errors.Is(err, errs.ErrInvalidCategory):
+		return "category_id"
+	case errors.Is(err, errs.ErrInvalidTitle):
+		return "title"
+	case errors.Is(err, errs.ErrInvalidDescription):
+		return "description"
+	case errors.Is(err, errs.ErrInvalidGTIN):
+		return "gtin"
+	default:
+		return ""
+	}
+}
+
+func productImagesFromURLs(productID uint, imageURLs []string) []models.ProductImage {
+	images := make([]models.ProductImage, 0, len(imageURLs))
+	for _, image := range imageURLs {
+		images = append(images, models.ProductImage{ProductID: productID, Image: image})
+	}
+
+	return images
+}
+
+// setVariantPriceAndStock устанавливает цену и остаток варианта, изменение остатка записывается как корректировка
+func setVariantPriceAndStock(userID, productID, variantID uint, price float64, amount uint, reason string) error {
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		if _, err := uow.LockProducts([]uint{productID}); err != nil {
+			return err
+		}
+
+		variants, err := uow.LockVariants([]uint{variantID})
+		if err != nil {
+			return err
+		}
+
+		variant, ok := variants[variantID]
+		if !ok {
+			return errs.ErrVariantNotFound
+		}
+
+		if price != variant.Price {
+			if err = uow.UpdateVariantPrice(variant, price); err != nil {
+				return err
+			}
+		}
+
+		if amount == variant.Amount {
+			return nil
+		}
+
+		return uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: productID,
+			VariantID: variantID,
+			Kind:      models.MovementAdjustment,
+			Quantity:  int(amount) - int(variant.Amount),
+			UserID:    &userID,
+			Reason:    reason,
+		})
+	})
+}
+
+// importProductRow создаёт товар с новым для магазина SKU или обновляет товар и вариант магазина с этим SKU.
+// SKU уникален только в пределах магазина, поэтому товары других продавцов с тем же SKU не затрагиваются.
+// Возвращает true, если товар создан
+func importProductRow(productImport models.ProductImport, row productImportRow, categories map[uint]bool) (bool, error) {
+	if _, checked := categories[row.product.CategoryID]; !checked {
+		_, err := repository.GetCategoryByID(row.product.CategoryID)
+		if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+			return false, err
+		}
+		categories[row.product.CategoryID] = err == nil
+	}
+
+	if !categories[row.product.CategoryID] {
+		return false, importRowError{column: "category_id", err: errs.ErrCategoryNotFound}
+	}
+
+	variant, err := repository.GetVariantBySKU(productImport.StoreID, row.sku)
+	if errors.Is(err, errs.ErrRecordNotFound) {
+		product := row.product
+		product.StoreID = productImport.StoreID
+		product.ProductImageList = row.images
+
+		if err = MatchProduct(&product); err != nil {
+			return false, importRowError{column: checkProductColumn(err), err: err}
+		}
+
+		return true, repository.CreateProductWithSKU(&product, productImagesFromURLs(0, row.images), row.sku, productImport.UserID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	product, err := repository.GetProductByID(variant.ProductID)
+	if err != nil {
+		return false, err
+	}
+
+	product.Title = row.product.Title
+	product.Description = row.product.Description
+	product.CategoryID = row.product.CategoryID
+	product.LowStockThreshold = row.product.LowStockThreshold
+	product.GTIN = row.product.GTIN
+
+	if err = MatchProduct(&product); err != nil {
+		return false, importRowError{column: checkProductColumn(err), err: err}
+	}
+
+	// Без столбца images изображения товара не меняются
+	imageURLs := []string(product.ProductImageList)
+	if row.hasImages {
+		imageURLs = row.images
+	}
+
+	removedImages, err := repository.UpdateProductWithImages(&product, productImagesFromURLs(product.ID, imageURLs))
+	if err != nil {
+		return false, err
+	}
+	DeleteOrphanedImages(removedImages)
+
+	return false, setVariantPriceAndStock(productImport.UserID, product.ID, variant.ID, row.product.Price, row.product.Amount, "product import")
+}
+
+// StartProductImport проверяет файл и ставит импорт товаров в магазин продавца в очередь, его выполняет
+// обработчик импортов (jobs.ProcessProductImports). Файл должен содержать строку заголовков со столбцами
+// из models.ProductImportColumns
+func StartProductImport(userID, storeID uint, fileName string, data []byte) (models.ProductImport, error) {
+	if _, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageCatalog); err != nil {
+		return models.ProductImport{}, err
+	}
+
+	rows, err := spreadsheet.Read(data)
+	if err != nil || len(rows) < 2 {
+		return models.ProductImport{}, errs.ErrInvalidImportFile
+	}
+
+	if len(rows)-1 > maxImportRows {
+		return models.ProductImport{}, errs.ErrTooManyImportRows
+	}
+
+	if _, err = importColumns(rows[0]); err != nil {
+		return models.ProductImport{}, err
+	}
+
+	productImport := models.ProductImport{
+		StoreID:   storeID,
+		UserID:    userID,
+		FileName:  fileName,
+		Format:    spreadsheet.DetectFormat(data),
+		Status:    models.ImportStatusPending,
+		TotalRows: len(rows) - 1,
+		FileData:  data,
+	}
+
+	if err = repository.CreateProductImport(&productImport); err != nil {
+		return models.ProductImport{}, err
+	}
+
+	select {
+	case importQueued <- struct{}{}:
+	default:
+	}
+
+	return productImport, nil
+}
+
+// ProductImportQueued сообщает обработчику импортов о новых импортах
+func ProductImportQueued() <-chan struct{} {
+	return importQueued
+}
+
+// ProductImportPollInterval как часто обработчик импортов ищет брошенные импорты
+func ProductImportPollInterval() time.Duration {
+	return time.Minute
+}
+
+// ProcessNextProductImport забирает ожидающий или брошенный импорт и выполняет его.
+// Возвращает false, если забирать нечего
+func ProcessNextProductImport() (bool, error) {
+	productImport, err := repository.ClaimProductImport(time.Now().Add(-importStallTimeout))
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	// Импорт, который несколько раз обрывался на середине, скорее всего обрывает сам процесс
+	if productImport.Attempts > maxImportAttempts {
+		logger.Error.Printf("[service.ProcessNextProductImport] import %d was interrupted %d times", productImport.ID, productImport.Attempts-1)
+		return true, failProductImport(&productImport, nil)
+	}
+
+	rows, err := spreadsheet.Read(productImport.FileData)
+	if err != nil || len(rows) < 2 {
+		return true, failProductImport(&productImport, nil)
+	}
+
+	columns, err := importColumns(rows[0])
+	if err != nil {
+		return true, failProductImport(&productImport, nil)
+	}
+
+	runProductImport(productImport, columns, rows[1:])
+	return true, nil
+}
+
+// failProductImport завершает импорт с ошибкой, сохраняя накопленные ошибки строк
+func failProductImport(productImport *models.ProductImport, rowErrors []models.ProductImportError) error {
+	now := time.Now()
+	productImport.Status = models.ImportStatusFailed
+	productImport.Error = errs.ErrSomethingWentWrong.Error()
+	productImport.FinishedAt = &now
+	return repository.SaveProductImportProgress(productImport, rowErrors)
+}
+
+// runProductImport обрабатывает строки по одной: ошибочные строки пропускаются и попадают в отчёт, остальные сохраняются.
+// Прерванный импорт продолжается после последних сохранённых строк: строки после сохранения обрабатываются повторно,
+// что безопасно, так как строка с тем же SKU обновляет тот же вариант
+func runProductImport(productImport models.ProductImport, columns map[string]int, rows [][]string) {
+	var rowErrors []models.ProductImportError
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error.Printf("[service.runProductImport] import %d panicked: %v", productImport.ID, r)
+			_ = failProductImport(&productImport, rowErrors)
+		}
+	}()
+
+	categories := make(map[uint]bool)
+	seenSKUs := make(map[string]int, len(rows))
+	lastSaved := time.Now()
+
+	for i, row := range rows {
+		// Строка 1 — заголовки
+		line := i + 2
+
+		parsed, err := parseImportRow(columns, row, line)
+
+		// Уже сохранённые строки только запоминаются для поиска повторов SKU
+		if i < productImport.ProcessedRows {
+			if _, duplicate := seenSKUs[parsed.sku]; err == nil && !duplicate {
+				seenSKUs[parsed.sku] = line
+			}
+			continue
+		}
+
+		if err == nil {
+			if firstLine, duplicate := seenSKUs[parsed.sku]; duplicate {
+				err = importRowError{column: "sku", err: fmt.Errorf("%w: same as row %d", errs.ErrDuplicateSKU, firstLine)}
+			} else {
+				seenSKUs[parsed.sku] = line
+			}
+		}
+
+		created := false
+		if err == nil {
+			created, err = importProductRow(productImport, parsed, categories)
+		}
+
+		productImport.ProcessedRows++
+		switch {
+		case err != nil:
+			productImport.FailedCount++
+
+			rowError := models.ProductImportError{Row: line, SKU: parsed.sku, Message: err.Error()}
+			var importErr importRowError
+			if errors.As(err, &importErr) {
+				rowError.Column = importErr.column
+			} else {
+				logger.Error.Printf("[service.runProductImport] import %d row %d: %v", productImport.ID, line, err)
+			}
+			rowErrors = append(rowErrors, rowError)
+		case created:
+			productImport.CreatedCount++
+		default:
+			productImport.UpdatedCount++
+		}
+
+		if productImport.ProcessedRows%importProgressBatch == 0 || time.Since(lastSaved) > importProgressInterval {
+			if err = repository.SaveProductImportProgress(&productImport, rowErrors); err == nil {
+				rowErrors = nil
+				lastSaved = time.Now()
+			}
+		}
+	}
+
+	now := time.Now()
+	productImport.Status = models.ImportStatusCompleted
+	productImport.FinishedAt = &now
+	if err := repository.SaveProductImportProgress(&productImport, rowErrors); err == nil {
+		rowErrors = nil
+	}
+}
+
+// GetProductImport возвращает состояние импорта и отчёт об ошибках продавцу магазина
+func GetProductImport(userID, importID uint) (models.ProductImport, error) {
+	productImport, err := repository.GetProductImportByID(importID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.ProductImport{}, errs.ErrProductImportNotFound
+		}
+
+		return models.ProductImport{}, err
+	}
+
+	if _, err = GetAuthorizedStore(userID, productImport.StoreID, models.StorePermissionManageCatalog); err != nil {
+		return models.ProductImport{}, err
+	}
+
+	return productImport, nil
+}
+
+// ExportStoreCatalog возвращает каталог магазина в формате импорта: по строке на каждый вариант,
+// поэтому выгруженный файл можно отредактировать и загрузить обратно
+func ExportStoreCatalog(userID, storeID uint) ([][]string, error) {
+	if _, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageCatalog); err != nil {
+		return nil, err
+	}
+
+	products, err := repository.GetStoreCatalog(storeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := [][]string{models.ProductImportColumns}
+	for _, product := range products {
+		for _, variant := range product.Variants {
+			rows = append(rows, []string{
+				variant.SKU,
+				product.Title,
+				product.Description,
+				strconv.FormatUint(uint64(product.CategoryID), 10),
+				strconv.FormatFloat(variant.Price, 'f', -1, 64),
+				strconv.FormatUint(uint64(variant.Amount), 10),
+				strconv.FormatUint(uint64(product.LowStockThreshold), 10),
+				product.GTIN,
+				strings.Join(product.ProductImageList, importImageSeparator),
+			})
+		}
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const maxCanonicalSearchResults = 20
+
+// Сортировки предложений магазинов
+const (
+	offerSortPrice        = "price"
+	offerSortRating       = "rating"
+	offerSortAvailability = "availability"
+)
+
+// normalizeTitle приводит название к виду, по которому сравниваются товары разных магазинов:
+// нижний регистр, только буквы и цифры, слова без повторов в алфавитном порядке
+func normalizeTitle(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	sort.Strings(words)
+
+	unique := words[:0]
+	for i, word := range words {
+		if i == 0 || word != words[i-1] {
+			unique = append(unique, word)
+		}
+	}
+
+	return strings.Join(unique, " ")
+}
+
+// normalizeGTIN проверяет штрихкод (GTIN-8, 12, 13 или 14) по контрольной цифре и дополняет его нулями до 14 цифр
+func normalizeGTIN(gtin string) (string, error) {
+	gtin = strings.ReplaceAll(strings.TrimSpace(gtin), " ", "")
+	if gtin == "" {
+		return "", nil
+	}
+
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return "", errs.ErrInvalidGTIN
+	}
+
+	for _, r := range gtin {
+		if r < '0' || r > '9' {
+			return "", errs.ErrInvalidGTIN
+		}
+	}
+
+	// Контрольная цифра — последняя, остальные цифры справа налево умножаются на 3 и 1 по очереди
+	last := len(gtin) - 1
+	sum := 0
+	for i := 0; i < last; i++ {
+		digit := int(gtin[i] - '0')
+		if (last-i)%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	if (10-sum%10)%10 != int(gtin[last]-'0') {
+		return "", errs.ErrInvalidGTIN
+	}
+
+	return strings.Repeat("0", 14-len(gtin)) + gtin, nil
+}
+
+// MatchProduct привязывает товар магазина к каноническому товару: указанному продавцом, найденному по GTIN
+// или по нормализованному названию. Если подходящего нет, создаётся новый канонический товар.
+func MatchProduct(product *models.Product) error {
+	gtin, err := normalizeGTIN(product.GTIN)
+	if err != nil {
+		return err
+	}
+	product.GTIN = gtin
+
+	if product.CanonicalProductID != nil && *product.CanonicalProductID != 0 {
+		if _, err = repository.GetCanonicalProductByID(*product.CanonicalProductID); err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return errs.ErrCanonicalProductNotFound
+			}
+
+			return err
+		}
+
+		return nil
+	}
+
+	canonical, err := findCanonicalProduct(product)
+	if err != nil {
+		return err
+	}
+
+	product.CanonicalProductID = &canonical.ID
+	return nil
+}
+
+func findCanonicalProduct(product *models.Product) (models.CanonicalProduct, error) {
+	if product.GTIN != "" {
+		canonical, err := repository.GetCanonicalProductByGTIN(product.GTIN)
+		if err == nil {
+			return canonical, nil
+		}
+
+		if !errors.Is(err, errs.ErrRecordNotFound) {
+			return models.CanonicalProduct{}, err
+		}
+	}
+
+	normalizedTitle := normalizeTitle(product.Title)
+
+	canonical, err := repository.GetCanonicalProductByTitle(normalizedTitle)
+	if err == nil {
+		// Товар с тем же названием, но другим штрихкодом — другой товар
+		if product.GTIN == "" || canonical.GTIN == product.GTIN {
+			return canonical, nil
+		}
+
+		if canonical.GTIN == "" {
+			canonical.GTIN = product.GTIN
+			return canonical, repository.SetCanonicalProductGTIN(canonical.ID, product.GTIN)
+		}
+	} else if !errors.Is(err, errs.ErrRecordNotFound) {
+		return models.CanonicalProduct{}, err
+	}
+
+	canonical = models.CanonicalProduct{
+		Title:           product.Title,
+		NormalizedTitle: normalizedTitle,
+		GTIN:            product.GTIN,
+		CategoryID:      product.CategoryID,
+	}
+
+	return canonical, repository.CreateCanonicalProduct(&canonical)
+}
+
+// MatchUnmatchedProducts привязывает к каноническим товарам товары, созданные до появления сравнения цен.
+// Возвращает количество обработанных товаров.
+func MatchUnmatchedProducts(limit int) (int, error) {
+	products, err := repository.GetUnmatchedProducts(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range products {
+		product := &products[i]
+
+		// Некорректный штрихкод старого товара не мешает сопоставить его по названию
+		if _, err = normalizeGTIN(product.GTIN); err != nil {
+			product.GTIN = ""
+		}
+
+		if err = MatchProduct(product); err != nil {
+			return i, err
+		}
+
+		if err = repository.SetProductCanonical(product.ID, *product.CanonicalProductID); err != nil {
+			return i, err
+		}
+	}
+
+	return len(products), nil
+}
+
+// GetProductOffers возвращает канонический товар с предложениями всех магазинов.
+// По умолчанию сначала идут товары в наличии по возрастанию цены.
+func GetProductOffers(canonicalID uint, sortBy string) (models.CanonicalProductOffers, error) {
+	if sortBy == "" {
+		sortBy = offerSortPrice
+	}
+
+	if sortBy != offerSortPrice && sortBy != offerSortRating && sortBy != offerSortAvailability {
+		return models.CanonicalProductOffers{}, errs.ErrInvalidSort
+	}
+
+	canonical, err := repository.GetCanonicalProductByID(canonicalID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.CanonicalProductOffers{}, errs.ErrCanonicalProductNotFound
+		}
+
+		return models.CanonicalProductOffers{}, err
+	}
+
+	offers, err := repository.GetProductOffers(canonicalID)
+	if err != nil {
+		return models.CanonicalProductOffers{}, err
+	}
+
+	sort.SliceStable(offers, func(i, j int) bool {
+		a, b := offers[i], offers[j]
+
+		switch sortBy {
+		case offerSortRating:
+			if a.InStock != b.InStock {
+				return a.InStock
+			}
+			if a.StoreRating != b.StoreRating {
+				return a.StoreRating > b.StoreRating
+			}
+		case offerSortAvailability:
+			if a.Amount != b.Amount {
+				return a.Amount > b.Amount
+			}
+		default:
+			if a.InStock != b.InStock {
+				return a.InStock
+			}
+		}
+
+		if a.Price != b.Price {
+			return a.Price < b.Price
+		}
+
+		return a.StoreRating > b.StoreRating
+	})
+
+	result := models.CanonicalProductOffers{Product: canonical, Offers: offers}
+	for i, offer := range offers {
+		if i == 0 || offer.Price < result.MinPrice {
+			result.MinPrice = offer.Price
+		}
+		if offer.Price > result.MaxPrice {
+			result.MaxPrice = offer.Price
+		}
+		if offer.InStock {
+			result.InStockCount++
+		}
+	}
+
+	return result, nil
+}
+
+// SearchCanonicalProducts ищет канонические товары по названию или штрихкоду, чтобы сравнить их предложения
+func SearchCanonicalProducts(query string) ([]models.CanonicalProductSummary, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || len([]rune(query)) > maxSearchQueryLen {
+		return nil, errs.ErrInvalidSearchQuery
+	}
+
+	if gtin, err := normalizeGTIN(query); err == nil && gtin != "" {
+		query = gtin
+	}
+
+	return repository.SearchCanonicalProducts(query, maxCanonicalSearchResults)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// selectModifiers проверяет выбор покупателя по группам модификаторов товара и возвращает снимок выбранных модификаторов
+// и суммарную надбавку к цене. Модификаторы чужого товара, распроданные и повторяющиеся отклоняются.
+func selectModifiers(groups []models.ModifierGroup, modifierIDs []uint) ([]models.OrderItemModifier, float64, error) {
+	type choice struct {
+		group    *models.ModifierGroup
+		modifier models.Modifier
+	}
+
+	available := make(map[uint]choice)
+	for i := range groups {
+		for _, modifier := range groups[i].Modifiers {
+			available[modifier.ID] = choice{group: &groups[i], modifier: modifier}
+		}
+	}
+
+	var selected []models.OrderItemModifier
+	var priceDelta float64
+	counts := make(map[uint]uint, len(groups))
+	seen := make(map[uint]bool, len(modifierIDs))
+
+	for _, modifierID := range modifierIDs {
+		c, ok := available[modifierID]
+		if !ok || c.modifier.SoldOut || seen[modifierID] {
+			return nil, 0, errs.ErrInvalidModifier
+		}
+		seen[modifierID] = true
+
+		counts[c.group.ID]++
+		priceDelta += c.modifier.PriceDelta
+		selected = append(selected, models.OrderItemModifier{
+			ModifierID: c.modifier.ID,
+			GroupName:  c.group.Name,
+			Name:       c.modifier.Name,
+			PriceDelta: c.modifier.PriceDelta,
+		})
+	}
+
+	for _, group := range groups {
+		minSelections := group.MinSelections
+		if group.IsRequired && minSelections == 0 {
+			minSelections = 1
+		}
+
+		count := counts[group.ID]
+		if count < minSelections || (group.MaxSelections > 0 && count > group.MaxSelections) {
+			return nil, 0, errs.ErrInvalidModifierSelection
+		}
+	}
+
+	return selected, priceDelta, nil
+}
+
+// modifierKey возвращает отсортированный список модификаторов, по которому различаются строки корзины
+func modifierKey(modifierIDs []uint) string {
+	sorted := append([]uint(nil), modifierIDs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	parts := make([]string, 0, len(sorted))
+	for _, id := range sorted {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func validateModifierGroupRequest(request models.ModifierGroupRequest) error {
+	if strings.TrimSpace(request.Name) == "" || len(request.Modifiers) == 0 {
+		return errs.ErrInvalidModifierGroup
+	}
+
+	count := uint(len(request.Modifiers))
+	if request.MinSelections > count ||
+		(request.MaxSelections > 0 && (request.MaxSelections < request.MinSelections || request.MaxSelections > count)) {
+		return errs.ErrInvalidModifierGroup
+	}
+
+	for _, modifier := range request.Modifiers {
+		if strings.TrimSpace(modifier.Name) == "" {
+			return errs.ErrInvalidModifierGroup
+		}
+	}
+
+	return nil
+}
+
+func modifierGroupFromRequest(group *models.ModifierGroup, request models.ModifierGroupRequest) {
+	group.Name = strings.TrimSpace(request.Name)
+	group.IsRequired = request.IsRequired
+	group.MinSelections = request.MinSelections
+	group.MaxSelections = request.MaxSelections
+	group.Position = request.Position
+
+	group.Modifiers = make([]models.Modifier, 0, len(request.Modifiers))
+	for i, modifier := range request.Modifiers {
+		group.Modifiers = append(group.Modifiers, models.Modifier{
+			ID:         modifier.ID,
+			GroupID:    group.ID,
+			Name:       strings.TrimSpace(modifier.Name),
+			PriceDelta: modifier.PriceDelta,
+			SoldOut:    modifier.SoldOut,
+			Position:   uint(i),
+		})
+	}
+}
+
+// getOwnedModifierGroup возвращает группу модификаторов, если её товар принадлежит магазину пользователя
+func getOwnedModifierGroup(userID, groupID uint) (models.ModifierGroup, error) {
+	group, err := repository.GetModifierGroupByID(groupID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.ModifierGroup{}, errs.ErrModifierGroupNotFound
+		}
+
+		return models.ModifierGroup{}, err
+	}
+
+	if _, err = getOwnedProduct(userID, group.ProductID); err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	return group, nil
+}
+
+// GetProductModifierGroups возвращает группы модификаторов товара
+func GetProductModifierGroups(productID uint) ([]models.ModifierGroup, error) {
+	products, err := repository.GetProductsByIDs([]uint{productID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, errs.ErrProductNotFound
+	}
+
+	return repository.GetModifierGroupsByProductID(productID)
+}
+
+// CreateModifierGroup добавляет группу модификаторов к товару продавца
+func CreateModifierGroup(userID, productID uint, request models.ModifierGroupRequest) (models.ModifierGroup, error) {
+	if _, err := getOwnedProduct(userID, productID); err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	if err := validateModifierGroupRequest(request); err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	for _, modifier := range request.Modifiers {
+		if modifier.ID != 0 {
+			return models.ModifierGroup{}, errs.ErrInvalidModifier
+		}
+	}
+
+	group := models.ModifierGroup{ProductID: productID}
+	modifierGroupFromRequest(&group, request)
+
+	if err := repository.CreateModifierGroup(&group); err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	return group, nil
+}
+
+// UpdateModifierGroup меняет группу модификаторов, модификаторы без ID добавляются, отсутствующие в запросе удаляются
+func UpdateModifierGroup(userID, groupID uint, request models.ModifierGroupRequest) (models.ModifierGroup, error) {
+	group, err := getOwnedModifierGroup(userID, groupID)
+	if err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	if err = validateModifierGroupRequest(request); err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	existing := make(map[uint]bool, len(group.Modifiers))
+	for _, modifier := range group.Modifiers {
+		existing[modifier.ID] = true
+	}
+
+	for _, modifier := range request.Modifiers {
+		if modifier.ID != 0 && !existing[modifier.ID] {
+			return models.ModifierGroup{}, errs.ErrInvalidModifier
+		}
+	}
+
+	modifierGroupFromRequest(&group, request)
+
+	if err = repository.UpdateModifierGroup(&group); err != nil {
+		return models.ModifierGroup{}, err
+	}
+
+	return group, nil
+}
+
+// DeleteModifierGroup удаляет группу модификаторов товара продавца
+func DeleteModifierGroup(userID, groupID uint) error {
+	group, err := getOwnedModifierGroup(userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	return repository.DeleteModifierGroup(group.ID)
+}
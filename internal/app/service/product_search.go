@@ -0,0 +1,58 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchQueryLen  = 200
+)
+
+// searchPriceBounds границы ценовых диапазонов в фасетах поиска
+var searchPriceBounds = []float64{100, 500, 1000, 5000, 10000, 50000}
+
+// SearchProducts ищет товары по названию и описанию с учётом морфологии и опечаток,
+// возвращает страницу результатов по релевантности, подсвеченные фрагменты и фасеты
+func SearchProducts(params models.ProductSearchParams) (models.ProductSearchResult, error) {
+	params.Query = strings.TrimSpace(params.Query)
+	if params.Query == "" || utf8.RuneCountInString(params.Query) > maxSearchQueryLen {
+		return models.ProductSearchResult{}, errs.ErrInvalidSearchQuery
+	}
+
+	if params.MaxPrice > 0 && params.MinPrice > params.MaxPrice {
+		return models.ProductSearchResult{}, errs.ErrInvalidMinPrice
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = defaultSearchLimit
+	}
+	if params.Limit > maxSearchLimit {
+		params.Limit = maxSearchLimit
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+
+	hits, total, err := repository.SearchProducts(params)
+	if err != nil {
+		return models.ProductSearchResult{}, err
+	}
+
+	facets, err := repository.GetProductSearchFacets(params, searchPriceBounds)
+	if err != nil {
+		return models.ProductSearchResult{}, err
+	}
+
+	return models.ProductSearchResult{
+		Query:  params.Query,
+		Total:  total,
+		Items:  hits,
+		Facets: facets,
+	}, nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"errors"
+	"strings"
+)
+
+// resolveVariant возвращает вариант товара, без указания варианта используется вариант по умолчанию
+func resolveVariant(productID, variantID uint) (models.ProductVariant, error) {
+	if variantID == 0 {
+		variant, err := repository.GetDefaultVariant(productID)
+		if err == nil {
+			return variant, nil
+		}
+
+		if !errors.Is(err, errs.ErrRecordNotFound) {
+			return models.ProductVariant{}, err
+		}
+
+		products, err := repository.GetProductsByIDs([]uint{productID})
+		if err != nil {
+			return models.ProductVariant{}, err
+		}
+
+		if len(products) == 0 {
+			return models.ProductVariant{}, errs.ErrProductNotFound
+		}
+
+		// У товара несколько вариантов, покупатель должен выбрать один из них
+		return models.ProductVariant{}, errs.ErrVariantRequired
+	}
+
+	variant, err := repository.GetVariantByID(variantID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.ProductVariant{}, errs.ErrVariantNotFound
+		}
+
+		return models.ProductVariant{}, err
+	}
+
+	if variant.ProductID != productID {
+		return models.ProductVariant{}, errs.ErrVariantNotFound
+	}
+
+	return variant, nil
+}
+
+// getOwnedVariant возвращает вариант, если его товар принадлежит магазину пользователя
+func getOwnedVariant(userID, variantID uint) (models.ProductVariant, error) {
+	variant, err := repository.GetVariantByID(variantID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return models.ProductVariant{}, errs.ErrVariantNotFound
+		}
+
+		return models.ProductVariant{}, err
+	}
+
+	if _, err = getOwnedProduct(userID, variant.ProductID); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	return variant, nil
+}
+
+// validateVariantRequest проверяет запрос варианта, SKU должен быть свободен среди вариантов магазина
+func validateVariantRequest(request *models.VariantRequest, storeID, variantID uint) error {
+	request.SKU = strings.TrimSpace(request.SKU)
+	if request.SKU == "" {
+		return errs.ErrInvalidSKU
+	}
+
+	if request.Price <= 0 {
+		return errs.ErrInvalidPrice
+	}
+
+	seen := make(map[string]bool, len(request.Options))
+	for _, option := range request.Options {
+		if option.Name == "" || option.Value == "" || seen[option.Name] {
+			return errs.ErrValidationFailed
+		}
+		seen[option.Name] = true
+	}
+
+	existing, err := repository.GetVariantBySKU(storeID, request.SKU)
+	if err == nil && existing.ID != variantID {
+		return errs.ErrSKUUniquenessFailed
+	}
+
+	if err != nil && !errors.Is(err, errs.ErrRecordNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+func variantOptionsFromRequest(options []models.VariantOptionRequest) []models.VariantOption {
+	variantOptions := make([]models.VariantOption, 0, len(options))
+	for _, option := range options {
+		variantOptions = append(variantOptions, models.VariantOption{Name: option.Name, Value: option.Value})
+	}
+
+	return variantOptions
+}
+
+// GetProductVariants возвращает варианты товара с их опциями
+func GetProductVariants(productID uint) ([]models.ProductVariant, error) {
+	products, err := repository.GetProductsByIDs([]uint{productID})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products) == 0 {
+		return nil, errs.ErrProductNotFound
+	}
+
+	return repository.GetVariantsByProductID(productID)
+}
+
+// CreateVariant добавляет вариант к товару продавца, начальный остаток записывается как поступление
+func CreateVariant(userID, productID uint, request models.VariantRequest) (models.ProductVariant, error) {
+	product, err := getOwnedProduct(userID, productID)
+	if err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	if err = validateVariantRequest(&request, product.StoreID, 0); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	variant := models.ProductVariant{
+		ProductID: productID,
+		StoreID:   product.StoreID,
+		SKU:       request.SKU,
+		Title:     request.Title,
+		Price:     request.Price,
+		Amount:    request.Amount,
+		Images:    request.Images,
+		Options:   variantOptionsFromRequest(request.Options),
+	}
+
+	if err = repository.CreateVariant(&variant, userID); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	return variant, nil
+}
+
+// UpdateVariant меняет SKU, название, цену, изображения и опции варианта.
+// Остаток меняется только через движения товара (см. AdjustProductStock).
+func UpdateVariant(userID, variantID uint, request models.VariantRequest) (models.ProductVariant, error) {
+	variant, err := getOwnedVariant(userID, variantID)
+	if err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	if err = validateVariantRequest(&request, variant.StoreID, variant.ID); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	variant.SKU = request.SKU
+	variant.Title = request.Title
+	variant.Price = request.Price
+	variant.Images = request.Images
+	variant.Options = variantOptionsFromRequest(request.Options)
+
+	if err = repository.UpdateVariant(&variant); err != nil {
+		return models.ProductVariant{}, err
+	}
+
+	return variant, nil
+}
+
+// DeleteVariant удаляет вариант товара, его остаток списывается корректировкой.
+// Последний вариант удалить нельзя, так как товар продаётся только через варианты.
+func DeleteVariant(userID, variantID uint) error {
+	variant, err := getOwnedVariant(userID, variantID)
+	if err != nil {
+		return err
+	}
+
+	variants, err := repository.GetVariantsByProductID(variant.ProductID)
+	if err != nil {
+		return err
+	}
+
+	if len(variants) <= 1 {
+		return errs.ErrCannotDeleteLastVariant
+	}
+
+	if err = repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		if _, err := uow.LockProducts([]uint{variant.ProductID}); err != nil {
+			return err
+		}
+
+		locked, err := uow.LockVariants([]uint{variant.ID})
+		if err != nil {
+			return err
+		}
+
+		lockedVariant, ok := locked[variant.ID]
+		if !ok {
+			return errs.ErrVariantNotFound
+		}
+
+		if lockedVariant.Amount == 0 {
+			return nil
+		}
+
+		return uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: variant.ProductID,
+			VariantID: variant.ID,
+			Kind:      models.MovementAdjustment,
+			Quantity:  -int(lockedVariant.Amount),
+			UserID:    &userID,
+			Reason:    "variant deleted",
+		})
+	}); err != nil {
+		return err
+	}
+
+	return repository.DeleteVariant(variant)
+}
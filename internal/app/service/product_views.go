@@ -0,0 +1,119 @@
+package service
+
+import (
+	"BizMart/internal/repository"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// productViewDedupWindow окно, в течение которого повторные открытия товара одним посетителем считаются одним просмотром
+	productViewDedupWindow = 30 * time.Minute
+	// productViewsPendingKey хэш с ещё не записанными в Postgres просмотрами: ID товара -> количество
+	productViewsPendingKey = "product_views:pending"
+	// productViewsFlushingKey хэш просмотров, который сейчас записывается в Postgres
+	productViewsFlushingKey = "product_views:flushing"
+	// productViewsFlushBatchSize количество товаров, обновляемых одним запросом
+	productViewsFlushBatchSize = 500
+)
+
+// ProductViewsFlushInterval как часто накопленные просмотры записываются в Postgres
+func ProductViewsFlushInterval() time.Duration {
+	return time.Minute
+}
+
+// ProductVisitorID строит идентификатор посетителя для дедупликации просмотров
+func ProductVisitorID(userID uint, clientIP, userAgent string) string {
+	if userID != 0 {
+		return fmt.Sprintf("user:%d", userID)
+	}
+
+	sum := sha256.Sum256([]byte(clientIP + "|" + userAgent))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// RecordProductView учитывает просмотр страницы товара, если посетитель не открывал её в пределах окна дедупликации.
+// Просмотры копятся в Redis и записываются в Postgres пакетами, ошибки Redis не мешают открыть страницу.
+func RecordProductView(productID uint, visitorID string) {
+	seenKey := fmt.Sprintf("product_views:seen:%d:%s", productID, visitorID)
+
+	isNew, err := db.SetCacheNX(seenKey, 1, productViewDedupWindow)
+	if err != nil {
+		logger.Warn.Printf("[service.RecordProductView] error deduplicating view of product %d: %v", productID, err)
+		return
+	}
+
+	if !isNew {
+		return
+	}
+
+	if err = db.IncrementHashCache(productViewsPendingKey, strconv.FormatUint(uint64(productID), 10), 1); err != nil {
+		logger.Warn.Printf("[service.RecordProductView] error buffering view of product %d: %v", productID, err)
+	}
+}
+
+// FlushProductViews переносит накопленные просмотры из Redis в Postgres и возвращает количество обновлённых товаров.
+// Накопленный хэш сначала переименовывается, чтобы новые просмотры копились отдельно. Записанные пакеты удаляются из хэша,
+// а незаписанные из-за ошибки остаются в Redis и записываются при следующем запуске.
+func FlushProductViews() (int, error) {
+	if _, err := db.RenameCacheNX(productViewsPendingKey, productViewsFlushingKey); err != nil {
+		return 0, err
+	}
+
+	pending, err := db.GetHashCache(productViewsFlushingKey)
+	if err != nil {
+		return 0, err
+	}
+
+	flushed := 0
+	batch := make(map[uint]int64, productViewsFlushBatchSize)
+	fields := make([]string, 0, productViewsFlushBatchSize)
+
+	flush := func() error {
+		if len(fields) == 0 {
+			return nil
+		}
+
+		if err := repository.AddProductViews(batch); err != nil {
+			return err
+		}
+		flushed += len(batch)
+
+		if err := db.DeleteHashCacheFields(productViewsFlushingKey, fields...); err != nil {
+			return err
+		}
+
+		batch = make(map[uint]int64, productViewsFlushBatchSize)
+		fields = fields[:0]
+		return nil
+	}
+
+	for field, value := range pending {
+		fields = append(fields, field)
+
+		// Повреждённые поля просто удаляются вместе с пакетом
+		productID, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+
+		batch[uint(productID)] = count
+		if len(fields) >= productViewsFlushBatchSize {
+			if err = flush(); err != nil {
+				return flushed, err
+			}
+		}
+	}
+
+	return flushed, flush()
+}
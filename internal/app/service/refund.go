@@ -0,0 +1,498 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/utils"
+	"errors"
+	"fmt"
+)
+
+// CancelOrder отменяет заказ.
+// Неоплаченный заказ отменяется целиком покупателем или продавцом, которому принадлежат все позиции, товары возвращаются на склад.
+// В оплаченном заказе покупатель возвращает все позиции, а продавец — только позиции своих магазинов,
+// если машина состояний разрешает возврат этих позиций этому участнику.
+func CancelOrder(userID, orderID uint, reason string) (*models.Refund, error) {
+	var refund *models.Refund
+	err := repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := uow.LockOrder(orderID)
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return errs.ErrOrderNotFound
+			}
+
+			return err
+		}
+
+		actor, scope, err := getOrderActor(uow, userID, order, models.OrderStatusCancelled)
+		if err != nil {
+			return err
+		}
+
+		statusCode, err := getOrderStatusCode(order.StatusID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case canTransitOrder(statusCode, models.OrderStatusCancelled, actor):
+			if len(scope) != len(order.Items) {
+				return errs.ErrPermissionDenied
+			}
+
+			if err = releaseOrderStock(uow, order, userID, reason); err != nil {
+				return err
+			}
+
+			return transitOrder(uow, &order, models.OrderStatusCancelled, actor, userID, reason)
+		case paidOrderStatuses[statusCode]:
+			itemCodes, err := getOrderItemStatusCodes(order, statusCode)
+			if err != nil {
+				return err
+			}
+
+			lines := make(map[uint]uint, len(scope))
+			for _, item := range order.Items {
+				if _, ok := scope[item.ID]; !ok || item.Quantity <= item.RefundedQuantity {
+					continue
+				}
+
+				if !canTransitOrder(itemCodes[item.ID], models.OrderStatusRefunded, actor) {
+					return errs.ErrOrderCannotBeCancelled
+				}
+
+				lines[item.ID] = item.Quantity - item.RefundedQuantity
+			}
+
+			payment, err := uow.LockPaymentByOrderID(order.ID)
+			if err != nil {
+				return err
+			}
+
+			refund, err = refundOrderItems(uow, payment, &order, lines, userID, reason)
+			if err != nil {
+				return err
+			}
+
+			return completeOrderRefund(uow, &order, actor, userID, reason)
+		default:
+			return errs.ErrOrderCannotBeCancelled
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// RefundPayment делает полный или частичный возврат по оплате.
+// Возврат оформляет продавец и только по позициям своих магазинов, пустой список позиций означает все его позиции.
+func RefundPayment(userID, paymentID uint, request models.RefundRequest) (models.Refund, error) {
+	var refund models.Refund
+	err := repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		payment, err := uow.LockPayment(paymentID)
+		if err != nil {
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return errs.ErrPaymentNotFound
+			}
+
+			return err
+		}
+
+		order, err := uow.LockOrder(payment.OrderID)
+		if err != nil {
+			return err
+		}
+
+		statusCode, err := getOrderStatusCode(order.StatusID)
+		if err != nil {
+			return err
+		}
+
+		if !paidOrderStatuses[statusCode] {
+			return errs.ErrOrderCannotBeRefunded
+		}
+
+		ownedItems, err := getOwnedOrderItemIDs(uow, userID, order.Items)
+		if err != nil {
+			return err
+		}
+
+		lines := make(map[uint]uint)
+		if len(request.Items) == 0 {
+			for _, item := range order.Items {
+				if _, ok := ownedItems[item.ID]; ok && item.Quantity > item.RefundedQuantity {
+					lines[item.ID] = item.Quantity - item.RefundedQuantity
+				}
+			}
+		} else {
+			for _, requestItem := range request.Items {
+				if _, ok := ownedItems[requestItem.OrderItemID]; !ok {
+					return errs.ErrPermissionDenied
+				}
+
+				lines[requestItem.OrderItemID] += requestItem.Quantity
+			}
+		}
+
+		// Как и при отмене, возврат каждой позиции должен разрешать её собственный статус
+		itemCodes, err := getOrderItemStatusCodes(order, statusCode)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range order.Items {
+			if lines[item.ID] == 0 {
+				continue
+			}
+
+			if !canTransitOrder(itemCodes[item.ID], models.OrderStatusRefunded, orderActorSeller) {
+				return errs.ErrOrderCannotBeRefunded
+			}
+		}
+
+		createdRefund, err := refundOrderItems(uow, payment, &order, lines, userID, request.Reason)
+		if err != nil {
+			return err
+		}
+		refund = *createdRefund
+
+		return completeOrderRefund(uow, &order, orderActorSeller, userID, request.Reason)
+	})
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	return refund, nil
+}
+
+// GetPaymentRefunds возвращает возвраты по оплате, доступные покупателю и продавцам заказа
+func GetPaymentRefunds(userID, paymentID uint) ([]models.Refund, error) {
+	payment, err := repository.GetPaymentByID(paymentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			return nil, errs.ErrPaymentNotFound
+		}
+
+		return nil, err
+	}
+
+	if payment.UserID != userID {
+		order, err := repository.GetOrderByID(payment.OrderID)
+		if err != nil {
+			return nil, err
+		}
+
+		isSeller, err := hasOrderItemsOfSeller(userID, order)
+		if err != nil {
+			return nil, err
+		}
+
+		if !isSeller {
+			return nil, errs.ErrPermissionDenied
+		}
+	}
+
+	return repository.GetRefundsByPaymentID(paymentID)
+}
+
+// refundOrderItems возвращает деньги покупателю за указанные количества позиций (orderItemID -> количество),
+// списывая их со счетов продавцов, и возвращает товары на склад
+func refundOrderItems(uow *repository.UnitOfWork, payment models.Payment, order *models.Order, lines map[uint]uint, initiatorID uint, reason string) (*models.Refund, error) {
+	storeAccounts, err := resolvePayoutAccounts(uow, payment.ID, order.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	refund := models.Refund{
+		PaymentID:   payment.ID,
+		OrderID:     order.ID,
+		InitiatorID: initiatorID,
+		Reason:      reason,
+	}
+
+	debits := make(map[uint]int64)
+	accountIDs := []uint{payment.AccountID}
+	matchedLines := 0
+	var total int64
+
+	for _, item := range order.Items {
+		quantity, ok := lines[item.ID]
+		if !ok {
+			continue
+		}
+		matchedLines++
+
+		if quantity == 0 {
+			continue
+		}
+
+		if quantity > item.Quantity-item.RefundedQuantity {
+			return nil, errs.ErrInvalidQuantity
+		}
+
+		amount := utils.ToMinorUnits(item.Price * float64(quantity))
+		storeAccountID := storeAccounts[item.ID]
+		if _, ok = debits[storeAccountID]; !ok {
+			accountIDs = append(accountIDs, storeAccountID)
+		}
+		debits[storeAccountID] += amount
+		total += amount
+
+		refund.Items = append(refund.Items, models.RefundItem{
+			OrderItemID: item.ID,
+			Quantity:    quantity,
+			Amount:      utils.FromMinorUnits(amount),
+		})
+	}
+
+	if matchedLines != len(lines) {
+		return nil, errs.ErrInvalidID
+	}
+
+	if total == 0 {
+		return nil, errs.ErrNothingToRefund
+	}
+
+	accounts, err := uow.LockAccounts(accountIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	buyerAccount, ok := accounts[payment.AccountID]
+	if !ok {
+		return nil, errs.ErrAccountNotFound
+	}
+
+	for accountID, amount := range debits {
+		storeAccount, ok := accounts[accountID]
+		if !ok {
+			return nil, errs.ErrAccountNotFound
+		}
+
+		if utils.ToMinorUnits(storeAccount.Balance) < amount {
+			return nil, errs.ErrInsufficientFunds
+		}
+	}
+
+	refund.Amount = utils.FromMinorUnits(total)
+	if err = uow.CreateRefund(&refund); err != nil {
+		return nil, err
+	}
+
+	if err = uow.RecordRefund(refund.ID); err != nil {
+		return nil, err
+	}
+
+	entry := models.JournalEntry{
+		Kind:        models.JournalKindRefund,
+		PaymentID:   &payment.ID,
+		Description: fmt.Sprintf("refund %d for order %d", refund.ID, order.ID),
+		Postings: []models.Posting{
+			{AccountID: &buyerAccount.ID, Direction: models.PostingCredit, Amount: total},
+		},
+	}
+	for accountID, amount := range debits {
+		storeAccountID := accountID
+		entry.Postings = append(entry.Postings, models.Posting{
+			AccountID: &storeAccountID,
+			Direction: models.PostingDebit,
+			Amount:    amount,
+		})
+	}
+
+	if err = uow.PostJournalEntry(&entry); err != nil {
+		return nil, err
+	}
+
+	for i, item := range order.Items {
+		quantity := lines[item.ID]
+		if quantity == 0 {
+			continue
+		}
+
+		if err = uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Kind:      models.MovementReturn,
+			Quantity:  int(quantity),
+			OrderID:   &order.ID,
+			UserID:    &initiatorID,
+			Reason:    reason,
+		}); err != nil {
+			return nil, err
+		}
+
+		if err = uow.AddRefundedQuantity(item.ID, quantity); err != nil {
+			return nil, err
+		}
+
+		order.Items[i].RefundedQuantity += quantity
+	}
+
+	return &refund, nil
+}
+
+// resolvePayoutAccounts возвращает для каждой позиции оплаченного заказа счёт, на который была зачислена её оплата.
+// Для заказов, оплаченных до того, как позиции стали запоминать счёт, счёт берётся из проводок выплаты
+func resolvePayoutAccounts(uow *repository.UnitOfWork, paymentID uint, items []models.OrderItem) (map[uint]uint, error) {
+	storeAccounts := make(map[uint]uint, len(items))
+	var legacyItems []models.OrderItem
+	for _, item := range items {
+		if item.PayoutAccountID == nil {
+			legacyItems = append(legacyItems, item)
+			continue
+		}
+
+		storeAccounts[item.ID] = *item.PayoutAccountID
+	}
+
+	if len(legacyItems) == 0 {
+		return storeAccounts, nil
+	}
+
+	productIDs := make([]uint, 0, len(legacyItems))
+	for _, item := range legacyItems {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	stores, err := uow.GetStoresByProductIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := uow.GetPayoutCredits(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range legacyItems {
+		store, ok := stores[item.ProductID]
+		if !ok {
+			return nil, errs.ErrProductNotFound
+		}
+
+		accountID, ok := credits[store.OwnerID]
+		if !ok {
+			return nil, errs.ErrAccountNotFound
+		}
+
+		storeAccounts[item.ID] = accountID
+	}
+
+	return storeAccounts, nil
+}
+
+// restockOrderItems возвращает на склад ещё не возвращённые товары неоплаченного заказа
+func restockOrderItems(uow *repository.UnitOfWork, order models.Order, userID uint, reason string) error {
+	for _, item := range order.Items {
+		if item.Quantity <= item.RefundedQuantity {
+			continue
+		}
+
+		movement := models.InventoryMovement{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Kind:      models.MovementReservationRelease,
+			Quantity:  int(item.Quantity - item.RefundedQuantity),
+			OrderID:   &order.ID,
+			Reason:    reason,
+		}
+		if userID != 0 {
+			movement.UserID = &userID
+		}
+
+		if err := uow.ApplyStockMovement(&movement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOrderItemStores возвращает магазин каждой позиции заказа (позиция -> магазин)
+func getOrderItemStores(uow *repository.UnitOfWork, items []models.OrderItem) (map[uint]uint, error) {
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	stores, err := uow.GetStoresByProductIDs(productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	itemStores := make(map[uint]uint, len(items))
+	for _, item := range items {
+		if store, ok := stores[item.ProductID]; ok {
+			itemStores[item.ID] = store.ID
+		}
+	}
+
+	return itemStores, nil
+}
+
+// getOwnedOrderItemIDs возвращает позиции заказа из магазинов, где пользователь может обрабатывать заказы (позиция -> магазин)
+func getOwnedOrderItemIDs(uow *repository.UnitOfWork, userID uint, items []models.OrderItem) (map[uint]uint, error) {
+	itemStores, err := getOrderItemStores(uow, items)
+	if err != nil {
+		return nil, err
+	}
+
+	return ownedOrderItems(userID, itemStores)
+}
+
+func ownedOrderItems(userID uint, itemStores map[uint]uint) (map[uint]uint, error) {
+	storeIDs, err := getStoreIDsWithPermission(userID, models.StorePermissionProcessOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[uint]uint, len(itemStores))
+	for itemID, storeID := range itemStores {
+		if storeIDs[storeID] {
+			owned[itemID] = storeID
+		}
+	}
+
+	return owned, nil
+}
+
+// completeOrderRefund переводит заказ в refunded, когда возвращены все его позиции,
+// иначе пересчитывает статус заказа по оставшимся позициям
+func completeOrderRefund(uow *repository.UnitOfWork, order *models.Order, actor string, changedByID uint, reason string) error {
+	for _, item := range order.Items {
+		if item.RefundedQuantity < item.Quantity {
+			return syncOrderStatus(uow, order, actor, changedByID, reason)
+		}
+	}
+
+	return transitOrder(uow, order, models.OrderStatusRefunded, actor, changedByID, reason)
+}
+
+// hasOrderItemsOfSeller проверяет, есть ли в заказе товары из магазинов, где пользователь может обрабатывать заказы
+func hasOrderItemsOfSeller(userID uint, order models.Order) (bool, error) {
+	storeIDs, err := getStoreIDsWithPermission(userID, models.StorePermissionProcessOrders)
+	if err != nil || len(storeIDs) == 0 {
+		return false, err
+	}
+
+	for _, item := range order.Items {
+		products, err := repository.GetProductsByIDs([]uint{item.ProductID})
+		if err != nil {
+			return false, err
+		}
+
+		if len(products) == 0 {
+			continue
+		}
+
+		if storeIDs[products[0].StoreID] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
+	"time"
+)
+
+const (
+	rolePermissionsCacheKey = "auth:role_permissions"
+	rolePermissionsCacheTTL = 5 * time.Minute
+	// rolesCacheTag инвалидируется при изменении прав ролей
+	rolesCacheTag = "roles"
+)
+
+// RolesHavePermission проверяет, даёт ли хотя бы одна из ролей право. При ошибке доступ запрещается
+func RolesHavePermission(roles []string, permission string) bool {
+	if len(roles) == 0 {
+		return false
+	}
+
+	rolePermissions, err := db.GetOrLoadCache(rolePermissionsCacheKey, []string{rolesCacheTag}, rolePermissionsCacheTTL,
+		repository.GetRolePermissions)
+	if err != nil {
+		logger.Error.Printf("[service.RolesHavePermission] error getting role permissions: %v", err)
+		return false
+	}
+
+	for _, role := range roles {
+		for _, code := range rolePermissions[role] {
+			if code == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// GetRoles возвращает все роли с их правами
+func GetRoles() ([]models.Role, error) {
+	return repository.GetRoles()
+}
+
+// GetUserRoles возвращает роли пользователя
+func GetUserRoles(userID uint) ([]models.UserRole, error) {
+	if _, err := repository.GetUserByID(userID); err != nil {
+		return nil, err
+	}
+
+	return repository.GetUserRoles(userID)
+}
+
+// GetRoleAudit возвращает журнал выдачи и отзыва ролей
+func GetRoleAudit(userID uint, params pagination.Params) (pagination.Page[models.RoleAudit], error) {
+	return repository.GetRoleAudit(userID, params)
+}
+
+// GrantRole выдаёт роль пользователю от имени администратора.
+// Новая роль попадает в токены при следующем обновлении сессии
+func GrantRole(actorID, userID uint, roleCode, reason string) error {
+	role, err := repository.GetRoleByCode(roleCode)
+	if err != nil {
+		return err
+	}
+
+	if _, err = repository.GetUserByID(userID); err != nil {
+		return err
+	}
+
+	_, err = repository.GrantUserRole(userID, role, &actorID, reason)
+	return err
+}
+
+// RevokeRole отзывает роль у пользователя. Access-токены, выданные с этой ролью, перестают действовать
+func RevokeRole(actorID, userID uint, roleCode, reason string) error {
+	if actorID == userID && roleCode == models.RolePlatformAdmin {
+		return errs.ErrCannotRevokeOwnAdminRole
+	}
+
+	role, err := repository.GetRoleByCode(roleCode)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := repository.RevokeUserRole(userID, role, &actorID, reason)
+	if err != nil || !revoked {
+		return err
+	}
+
+	changedAt := time.Now()
+	if err = repository.SetUserRolesChangedAt(userID, changedAt); err != nil {
+		return err
+	}
+
+	denyUserAccessTokens(userID, changedAt)
+	return nil
+}
+
+// grantSystemRole выдаёт роль без участия администратора, например buyer при регистрации.
+// Ошибка только пишется в лог: роль можно выдать позже вручную
+func grantSystemRole(userID uint, roleCode, reason string) {
+	role, err := repository.GetRoleByCode(roleCode)
+	if err == nil {
+		_, err = repository.GrantUserRole(userID, role, nil, reason)
+	}
+
+	if err != nil {
+		logger.Error.Printf("[service.grantSystemRole] error granting role %s to user %d: %v", roleCode, userID, err)
+	}
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/internal/security"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"errors"
+	"time"
+)
+
+const (
+	defaultReservationTTL           = 30 * time.Minute
+	defaultReservationCheckInterval = time.Minute
+)
+
+func reservationTTL() time.Duration {
+	if minutes := security.AppSettings.OrderParams.ReservationTTLMinutes; minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+
+	return defaultReservationTTL
+}
+
+// ReservationCheckInterval возвращает, как часто нужно искать просроченные резервы
+func ReservationCheckInterval() time.Duration {
+	if seconds := security.AppSettings.OrderParams.ReservationCheckIntervalSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	return defaultReservationCheckInterval
+}
+
+// reserveOrderStock резервирует позиции нового заказа до истечения срока оплаты.
+// Товары к этому моменту уже списаны со склада под блокировкой строк, поэтому последний товар не может уйти в два заказа.
+func reserveOrderStock(uow *repository.UnitOfWork, order models.Order) error {
+	expiresAt := time.Now().Add(reservationTTL())
+
+	reservations := make([]models.StockReservation, 0, len(order.Items))
+	for _, item := range order.Items {
+		reservations = append(reservations, models.StockReservation{
+			OrderID:     order.ID,
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			VariantID:   item.VariantID,
+			Quantity:    item.Quantity,
+			Status:      models.ReservationActive,
+			ExpiresAt:   expiresAt,
+		})
+	}
+
+	return uow.CreateStockReservations(reservations)
+}
+
+// convertOrderReservations превращает резервы заказа в продажу при оплате
+func convertOrderReservations(uow *repository.UnitOfWork, orderID uint) error {
+	reservations, err := uow.LockActiveReservations(orderID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, reservation := range reservations {
+		if reservation.ExpiresAt.Before(now) {
+			return errs.ErrOrderReservationExpired
+		}
+	}
+
+	return uow.CloseActiveReservations(orderID, models.ReservationConverted)
+}
+
+// releaseOrderStock возвращает товары неоплаченного заказа на склад и снимает его резервы
+func releaseOrderStock(uow *repository.UnitOfWork, order models.Order, userID uint, reason string) error {
+	if _, err := uow.LockActiveReservations(order.ID); err != nil {
+		return err
+	}
+
+	if err := restockOrderItems(uow, order, userID, reason); err != nil {
+		return err
+	}
+
+	return uow.CloseActiveReservations(order.ID, models.ReservationReleased)
+}
+
+// ExpireOrder снимает просроченные резервы неоплаченного заказа и переводит его в статус expired
+func ExpireOrder(orderID uint) error {
+	return repository.RunInTransaction(func(uow *repository.UnitOfWork) error {
+		order, err := uow.LockOrder(orderID)
+		if err != nil {
+			// Заказ удалён, его резервы просто закрываются
+			if errors.Is(err, errs.ErrRecordNotFound) {
+				return uow.CloseActiveReservations(orderID, models.ReservationReleased)
+			}
+
+			return err
+		}
+
+		reservations, err := uow.LockActiveReservations(order.ID)
+		if err != nil {
+			return err
+		}
+
+		// Заказ успели оплатить или отменить, пока мы ждали блокировку
+		if len(reservations) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		for _, reservation := range reservations {
+			if !reservation.ExpiresAt.Before(now) {
+				return nil
+			}
+		}
+
+		statusCode, err := getOrderStatusCode(order.StatusID)
+		if err != nil {
+			return err
+		}
+
+		// Заказ уже не ждёт оплаты, резервы больше не держат товар
+		if statusCode != models.OrderStatusPending {
+			return uow.CloseActiveReservations(order.ID, models.ReservationReleased)
+		}
+
+		const reason = "stock reservation expired"
+		if err = releaseOrderStock(uow, order, 0, reason); err != nil {
+			return err
+		}
+
+		return transitOrder(uow, &order, models.OrderStatusExpired, orderActorSystem, 0, reason)
+	})
+}
+
+// ExpireReservations находит заказы с просроченными резервами и переводит их в статус expired.
+// Заказы обходятся пачками по batchSize в порядке ID, поэтому заказ, который не удалось обработать,
+// не мешает остальным и будет повторён при следующем запуске. Возвращает количество обработанных заказов.
+func ExpireReservations(batchSize int) (int, error) {
+	before := time.Now()
+	expired := 0
+
+	var lastOrderID uint
+	for {
+		orderIDs, err := repository.GetExpiredReservationOrderIDs(before, lastOrderID, batchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, orderID := range orderIDs {
+			lastOrderID = orderID
+			if err = ExpireOrder(orderID); err != nil {
+				logger.Error.Printf("[service.ExpireReservations] error expiring order %d: %v", orderID, err)
+				continue
+			}
+			expired++
+		}
+
+		if len(orderIDs) < batchSize {
+			return expired, nil
+		}
+	}
+}
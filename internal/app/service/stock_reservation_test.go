@@ -0,0 +1,62 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/testutil"
+	"BizMart/pkg/db"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestExpireReservationsSkipsFailedOrders(t *testing.T) {
+	testutil.RequireDB(t)
+
+	var orderIDs []uint
+	for i := 0; i < 3; i++ {
+		f := testutil.NewFixture(t, 10, 25, 0)
+		if err := AddCartItem(f.Buyer.ID, models.CartItemRequest{
+			ProductID: f.Product.ID,
+			VariantID: f.Variant.ID,
+			Quantity:  1,
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		orderID, err := Checkout(f.Buyer.ID, f.Address.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+
+	if err := db.GetDBConn().Model(&models.StockReservation{}).
+		Where("order_id IN ?", orderIDs).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Первый обработанный заказ падает, с пачкой из одного заказа раньше на этом обработка и заканчивалась
+	testutil.InjectError(t, func(tx *gorm.DB) bool {
+		return tx.Statement.Table == "orderapp_stockreservation"
+	})
+
+	expired, err := ExpireReservations(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired < len(orderIDs)-1 {
+		t.Fatalf("expected the orders after the failed one to expire, got %d", expired)
+	}
+
+	var active int64
+	if err = db.GetDBConn().Model(&models.StockReservation{}).
+		Where("order_id IN ? AND status = ?", orderIDs[1:], models.ReservationActive).
+		Count(&active).Error; err != nil {
+		t.Fatal(err)
+	}
+	if active != 0 {
+		t.Fatalf("expected no active reservations after the failed order, got %d", active)
+	}
+}
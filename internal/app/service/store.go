@@ -21,7 +21,12 @@ func GetStoreByID(storeID uint) (models.Store, error) {
 	return store, nil
 }
 
+// CreateStore создаёт магазин, открыть магазин может только пользователь с подтверждённым email
 func CreateStore(store models.Store) error {
+	if err := RequireVerifiedEmail(store.OwnerID); err != nil {
+		return err
+	}
+
 	storeCheck, err := repository.GetStoreByName(store.Name)
 	if storeCheck.ID != 0 {
 		return errs.ErrStoreNameUniquenessFailed
@@ -36,5 +41,7 @@ func CreateStore(store models.Store) error {
 		return err
 	}
 
+	grantSystemRole(store.OwnerID, models.RoleSeller, "granted on store creation")
+
 	return nil
 }
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/errs"
+	"errors"
+	"time"
+)
+
+// StoreInvitationTTL время, в течение которого приглашение в магазин можно принять
+const StoreInvitationTTL = 7 * 24 * time.Hour
+
+// storeRoleAllows проверяет, даёт ли роль в магазине право permission
+func storeRoleAllows(role, permission string) bool {
+	for _, code := range models.StoreRolePermissions[role] {
+		if code == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// canAssignStoreRole проверяет, может ли сотрудник с ролью actorRole выдавать и отбирать роль role.
+// Владелец назначает любую роль, кроме владельца, менеджер — только роли ниже своей
+func canAssignStoreRole(actorRole, role string) bool {
+	switch actorRole {
+	case models.StoreRoleOwner:
+		return role == models.StoreRoleManager || role == models.StoreRoleCatalogEditor || role == models.StoreRoleOrderOperator
+	case models.StoreRoleManager:
+		return role == models.StoreRoleCatalogEditor || role == models.StoreRoleOrderOperator
+	default:
+		return false
+	}
+}
+
+func isStoreRole(role string) bool {
+	_, ok := models.StoreRolePermissions[role]
+	return ok
+}
+
+// GetStoreRole возвращает роль пользователя в магазине или пустую строку, если он там не работает
+func GetStoreRole(userID uint, store models.Store) (string, error) {
+	if userID == 0 {
+		return "", nil
+	}
+
+	if store.OwnerID == userID {
+		return models.StoreRoleOwner, nil
+	}
+
+	return repository.GetStoreMemberRole(store.ID, userID)
+}
+
+// CheckStorePermission проверяет, что роль пользователя в магазине даёт право permission
+func CheckStorePermission(userID uint, store models.Store, permission string) error {
+	role, err := GetStoreRole(userID, store)
+	if err != nil {
+		return err
+	}
+
+	if !storeRoleAllows(role, permission) {
+		return errs.ErrPermissionDenied
+	}
+
+	return nil
+}
+
+// GetAuthorizedStore возвращает магазин, если роль пользователя в нём даёт право permission
+func GetAuthorizedStore(userID, storeID uint, permission string) (models.Store, error) {
+	store, err := GetStoreByID(storeID)
+	if err != nil {
+		return models.Store{}, err
+	}
+
+	if err = CheckStorePermission(userID, store, permission); err != nil {
+		return models.Store{}, err
+	}
+
+	return store, nil
+}
+
+// getStoreIDsWithPermission возвращает магазины, в которых роль пользователя даёт право permission
+func getStoreIDsWithPermission(userID uint, permission string) (map[uint]bool, error) {
+	roles, err := repository.GetUserStoreRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	storeIDs := make(map[uint]bool, len(roles))
+	for storeID, role := range roles {
+		if storeRoleAllows(role, permission) {
+			storeIDs[storeID] = true
+		}
+	}
+
+	return storeIDs, nil
+}
+
+// GetStoreMembers возвращает сотрудников магазина, список видят только сами сотрудники
+func GetStoreMembers(userID, storeID uint) ([]models.StoreMember, error) {
+	if _, err := GetAuthorizedStore(userID, storeID, models.StorePermissionViewStaff); err != nil {
+		return nil, err
+	}
+
+	return repository.GetStoreMembers(storeID)
+}
+
+// InviteStoreMember приглашает пользователя по имени или email работать в магазине.
+// Пользователь становится сотрудником, только когда примет приглашение
+func InviteStoreMember(userID, storeID uint, request models.StoreInvitationRequest) (models.StoreInvitation, error) {
+	store, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageStaff)
+	if err != nil {
+		return models.StoreInvitation{}, err
+	}
+
+	if !isStoreRole(request.Role) || request.Role == models.StoreRoleOwner {
+		return models.StoreInvitation{}, errs.ErrInvalidStoreRole
+	}
+
+	actorRole, err := GetStoreRole(userID, store)
+	if err != nil {
+		return models.StoreInvitation{}, err
+	}
+
+	if !canAssignStoreRole(actorRole, request.Role) {
+		return models.StoreInvitation{}, errs.ErrPermissionDenied
+	}
+
+	invitee, err := repository.GetUserByLogin(request.Login)
+	if err != nil {
+		return models.StoreInvitation{}, err
+	}
+
+	inviteeRole, err := GetStoreRole(invitee.ID, store)
+	if err != nil {
+		return models.StoreInvitation{}, err
+	}
+
+	if inviteeRole != "" {
+		return models.StoreInvitation{}, errs.ErrAlreadyStoreMember
+	}
+
+	invitation := models.StoreInvitation{
+		StoreID:     store.ID,
+		StoreName:   store.Name,
+		UserID:      invitee.ID,
+		Username:    invitee.Username,
+		Role:        request.Role,
+		InvitedByID: userID,
+		Status:      models.StoreInvitationPending,
+		ExpiresAt:   time.Now().Add(StoreInvitationTTL),
+	}
+
+	if err = repository.CreateStoreInvitation(&invitation); err != nil {
+		return models.StoreInvitation{}, err
+	}
+
+	return invitation, nil
+}
+
+// GetStoreInvitations возвращает приглашения магазина, на которые ещё не ответили
+func GetStoreInvitations(userID, storeID uint) ([]models.StoreInvitation, error) {
+	if _, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageStaff); err != nil {
+		return nil, err
+	}
+
+	return repository.GetPendingStoreInvitations(storeID)
+}
+
+// CancelStoreInvitation отменяет приглашение, отменить его может тот, кто вправе выдать его роль
+func CancelStoreInvitation(userID, storeID, invitationID uint) error {
+	store, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageStaff)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := repository.GetStoreInvitationByID(invitationID)
+	if err != nil {
+		return err
+	}
+
+	if invitation.StoreID != store.ID {
+		return errs.ErrStoreInvitationNotFound
+	}
+
+	actorRole, err := GetStoreRole(userID, store)
+	if err != nil {
+		return err
+	}
+
+	if !canAssignStoreRole(actorRole, invitation.Role) {
+		return errs.ErrPermissionDenied
+	}
+
+	return repository.UpdateStoreInvitationStatus(invitation.ID, models.StoreInvitationCancelled)
+}
+
+// GetUserStoreInvitations возвращает приглашения пользователя, на которые он ещё не ответил
+func GetUserStoreInvitations(userID uint) ([]models.StoreInvitation, error) {
+	return repository.GetUserStoreInvitations(userID)
+}
+
+// getOwnInvitation возвращает приглашение, адресованное пользователю. Чужое приглашение не отличается от несуществующего
+func getOwnInvitation(userID, invitationID uint) (models.StoreInvitation, error) {
+	invitation, err := repository.GetStoreInvitationByID(invitationID)
+	if err != nil {
+		return models.StoreInvitation{}, err
+	}
+
+	if invitation.UserID != userID || invitation.Status != models.StoreInvitationPending {
+		return models.StoreInvitation{}, errs.ErrStoreInvitationNotFound
+	}
+
+	return invitation, nil
+}
+
+// AcceptStoreInvitation делает пользователя сотрудником магазина с ролью из приглашения
+func AcceptStoreInvitation(userID, invitationID uint) (models.StoreMember, error) {
+	invitation, err := getOwnInvitation(userID, invitationID)
+	if err != nil {
+		return models.StoreMember{}, err
+	}
+
+	if invitation.ExpiresAt.Before(time.Now()) {
+		return models.StoreMember{}, errs.ErrStoreInvitationExpired
+	}
+
+	// Магазин мог быть удалён после приглашения
+	if _, err = GetStoreByID(invitation.StoreID); err != nil {
+		if errors.Is(err, errs.ErrStoreNotFound) {
+			return models.StoreMember{}, errs.ErrStoreInvitationNotFound
+		}
+		return models.StoreMember{}, err
+	}
+
+	if err = repository.AcceptStoreInvitation(invitation); err != nil {
+		return models.StoreMember{}, err
+	}
+
+	return repository.GetStoreMember(invitation.StoreID, userID)
+}
+
+// DeclineStoreInvitation отклоняет приглашение в магазин
+func DeclineStoreInvitation(userID, invitationID uint) error {
+	invitation, err := getOwnInvitation(userID, invitationID)
+	if err != nil {
+		return err
+	}
+
+	return repository.UpdateStoreInvitationStatus(invitation.ID, models.StoreInvitationDeclined)
+}
+
+// getManagedMember возвращает сотрудника, роль которого пользователь вправе менять
+func getManagedMember(userID, storeID, memberID uint) (actorRole string, member models.StoreMember, err error) {
+	store, err := GetAuthorizedStore(userID, storeID, models.StorePermissionManageStaff)
+	if err != nil {
+		return "", models.StoreMember{}, err
+	}
+
+	if memberID == store.OwnerID {
+		return "", models.StoreMember{}, errs.ErrCannotChangeStoreOwner
+	}
+
+	member, err = repository.GetStoreMember(storeID, memberID)
+	if err != nil {
+		return "", models.StoreMember{}, err
+	}
+
+	actorRole, err = GetStoreRole(userID, store)
+	if err != nil {
+		return "", models.StoreMember{}, err
+	}
+
+	if !canAssignStoreRole(actorRole, member.Role) {
+		return "", models.StoreMember{}, errs.ErrPermissionDenied
+	}
+
+	return actorRole, member, nil
+}
+
+// UpdateStoreMemberRole меняет роль сотрудника магазина
+func UpdateStoreMemberRole(userID, storeID, memberID uint, role string) error {
+	if !isStoreRole(role) {
+		return errs.ErrInvalidStoreRole
+	}
+
+	actorRole, member, err := getManagedMember(userID, storeID, memberID)
+	if err != nil {
+		return err
+	}
+
+	if !canAssignStoreRole(actorRole, role) {
+		return errs.ErrPermissionDenied
+	}
+
+	return repository.UpdateStoreMemberRole(storeID, member.UserID, role)
+}
+
+// RemoveStoreMember убирает сотрудника из магазина. Сотрудник может уйти сам, владелец уйти не может
+func RemoveStoreMember(userID, storeID, memberID uint) error {
+	if userID != memberID {
+		if _, _, err := getManagedMember(userID, storeID, memberID); err != nil {
+			return err
+		}
+
+		return repository.DeleteStoreMember(storeID, memberID)
+	}
+
+	store, err := GetStoreByID(storeID)
+	if err != nil {
+		return err
+	}
+
+	if store.OwnerID == userID {
+		return errs.ErrCannotChangeStoreOwner
+	}
+
+	if _, err = repository.GetStoreMember(storeID, userID); err != nil {
+		return err
+	}
+
+	return repository.DeleteStoreMember(storeID, userID)
+}
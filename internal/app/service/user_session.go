@@ -0,0 +1,355 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/utils"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"strconv"
+	"time"
+)
+
+const (
+	sessionStateActive  = "active"
+	sessionStateRevoked = "revoked"
+	// revocationStateTTL сколько хранится в кэше прочитанное из базы состояние сессии или пользователя.
+	// Отзыв перезаписывает его сразу, срок лишь ограничивает устаревание, если запись отзыва в кэш не удалась
+	revocationStateTTL = 5 * time.Minute
+)
+
+// sessionStateCacheKey хранит состояние сессии: active или revoked. Отметка revoked живёт, пока не истекут
+// выданные сессии access-токены. Отсутствие ключа ничего не означает, состояние берётся из базы
+func sessionStateCacheKey(sessionID uint) string {
+	return fmt.Sprintf("auth:session_state:%d", sessionID)
+}
+
+// tokensRevokedCacheKey хранит время смены пароля или отзыва роли в микросекундах (0, если их не было): access-токены,
+// выданные не позже, недействительны. Отсутствие ключа ничего не означает, время берётся из базы
+func tokensRevokedCacheKey(userID uint) string {
+	return fmt.Sprintf("auth:tokens_revoked_us:%d", userID)
+}
+
+// newSecretToken создаёт случайный токен: номер refresh-токена или токен из письма. В базе хранится только его хеш
+func newSecretToken() (token, tokenHash string, err error) {
+	id := make([]byte, 32)
+	if _, err = rand.Read(id); err != nil {
+		return "", "", err
+	}
+
+	token = hex.EncodeToString(id)
+	return token, hashSecretToken(token), nil
+}
+
+func hashSecretToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func truncateRunes(value string, length int) string {
+	if runes := []rune(value); len(runes) > length {
+		return string(runes[:length])
+	}
+	return value
+}
+
+// StartSession создаёт сессию пользователя на устройстве и выдаёт её токены
+func StartSession(user models.User, client models.SessionClient) (accessToken, refreshToken string, err error) {
+	refreshID, tokenHash, err := newSecretToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	session := models.UserSession{
+		UserID:     user.ID,
+		TokenHash:  tokenHash,
+		DeviceName: truncateRunes(client.DeviceName, 100),
+		IP:         truncateRunes(client.IP, 45),
+		UserAgent:  truncateRunes(client.UserAgent, 255),
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(utils.RefreshTokenTTL),
+	}
+
+	if err = repository.CreateUserSession(&session); err != nil {
+		return "", "", err
+	}
+
+	roles, err := repository.GetUserRoleCodes(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return utils.GenerateToken(user.ID, user.Username, roles, session.ID, refreshID)
+}
+
+// RefreshSession обменивает refresh-токен на новую пару токенов, прежний refresh-токен перестаёт действовать.
+// Повторное предъявление уже обменянного токена означает, что его украли: сессия отзывается целиком
+func RefreshSession(refreshToken string, client models.SessionClient) (accessToken, newRefreshToken string, err error) {
+	claims, err := utils.ParseToken(refreshToken)
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&jwt.ValidationErrorExpired != 0 {
+			return "", "", errs.ErrRefreshTokenExpired
+		}
+		return "", "", errs.ErrInvalidToken
+	}
+
+	// Refresh-токены, выданные до появления сессий, не принимаются: пользователь входит заново
+	if claims.TokenType != utils.TokenTypeRefresh || claims.SessionID == 0 || claims.Id == "" {
+		return "", "", errs.ErrInvalidToken
+	}
+
+	session, err := repository.GetUserSessionByID(claims.SessionID)
+	if err != nil {
+		if errors.Is(err, errs.ErrSessionNotFound) {
+			return "", "", errs.ErrInvalidToken
+		}
+		return "", "", err
+	}
+
+	if session.UserID != claims.UserID {
+		return "", "", errs.ErrInvalidToken
+	}
+	if session.RevokedAt != nil {
+		return "", "", errs.ErrSessionRevoked
+	}
+	if session.ExpiresAt.Before(time.Now()) {
+		return "", "", errs.ErrRefreshTokenExpired
+	}
+
+	currentHash := hashSecretToken(claims.Id)
+	if currentHash != session.TokenHash {
+		return "", "", revokeReusedSession(session)
+	}
+
+	refreshID, tokenHash, err := newSecretToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	session.TokenHash = tokenHash
+	session.IP = truncateRunes(client.IP, 45)
+	session.UserAgent = truncateRunes(client.UserAgent, 255)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(utils.RefreshTokenTTL)
+
+	rotated, err := repository.RotateUserSession(session, currentHash)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Тот же токен только что обменял другой запрос
+	if !rotated {
+		return "", "", revokeReusedSession(session)
+	}
+
+	// Роли читаются заново: выданные после входа роли попадают в токен при обновлении
+	roles, err := repository.GetUserRoleCodes(claims.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return utils.GenerateToken(claims.UserID, claims.Username, roles, session.ID, refreshID)
+}
+
+func revokeReusedSession(session models.UserSession) error {
+	logger.Warn.Printf("[service.revokeReusedSession] refresh token of session %d of user %d was reused, revoking the session",
+		session.ID, session.UserID)
+
+	if err := revokeSessions([]uint{session.ID}, models.SessionRevokedTokenReuse); err != nil {
+		return err
+	}
+
+	return errs.ErrRefreshTokenReused
+}
+
+// revokeSessions отзывает сессии и запрещает уже выданные им access-токены
+func revokeSessions(sessionIDs []uint, reason string) error {
+	for _, sessionID := range sessionIDs {
+		if err := repository.RevokeUserSession(sessionID, reason); err != nil {
+			return err
+		}
+	}
+
+	denySessionAccessTokens(sessionIDs)
+	return nil
+}
+
+func denySessionAccessTokens(sessionIDs []uint) {
+	for _, sessionID := range sessionIDs {
+		_ = db.SetCache(sessionStateCacheKey(sessionID), sessionStateRevoked, utils.AccessTokenTTL)
+	}
+}
+
+// denyUserAccessTokens запрещает access-токены пользователя, выданные не позже revokedAt
+func denyUserAccessTokens(userID uint, revokedAt time.Time) {
+	_ = db.SetCache(tokensRevokedCacheKey(userID), revokedAt.UnixMicro(), utils.AccessTokenTTL)
+}
+
+// getOwnedSession возвращает активную сессию пользователя. Чужая сессия не отличается от несуществующей
+func getOwnedSession(userID, sessionID uint) (models.UserSession, error) {
+	session, err := repository.GetUserSessionByID(sessionID)
+	if err != nil {
+		return models.UserSession{}, err
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil {
+		return models.UserSession{}, errs.ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+// Logout завершает сессию, которой выдан access-токен
+func Logout(userID, sessionID uint) error {
+	// У токенов, выданных до появления сессий, сессии нет
+	if sessionID == 0 {
+		return nil
+	}
+
+	if _, err := getOwnedSession(userID, sessionID); err != nil {
+		if errors.Is(err, errs.ErrSessionNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return revokeSessions([]uint{sessionID}, models.SessionRevokedLogout)
+}
+
+// GetUserSessions возвращает активные сессии пользователя, текущая сессия отмечена
+func GetUserSessions(userID, currentSessionID uint) ([]models.UserSession, error) {
+	sessions, err := repository.GetActiveUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя, например на потерянном устройстве
+func RevokeSession(userID, sessionID uint) error {
+	if _, err := getOwnedSession(userID, sessionID); err != nil {
+		return err
+	}
+
+	return revokeSessions([]uint{sessionID}, models.SessionRevokedByUser)
+}
+
+// endPasswordSessions завершает все сессии пользователя после смены пароля и запрещает выданные им access-токены
+func endPasswordSessions(userID uint, changedAt time.Time) error {
+	denyUserAccessTokens(userID, changedAt)
+
+	sessionIDs, err := repository.RevokeUserSessions(userID, models.SessionRevokedPasswordChanged)
+	if err != nil {
+		return err
+	}
+
+	denySessionAccessTokens(sessionIDs)
+	return nil
+}
+
+// ChangePassword меняет пароль, завершает все сессии пользователя и запрещает выданные им access-токены.
+// Для устройства, с которого сменили пароль, открывается новая сессия
+func ChangePassword(userID uint, currentPassword, newPassword string, client models.SessionClient) (accessToken, refreshToken string, err error) {
+	if newPassword == "" {
+		return "", "", errs.ErrPasswordIsEmpty
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if match, _ := utils.VerifyPassword(currentPassword, user.HashPassword); !match {
+		return "", "", errs.ErrPasswordIncorrect
+	}
+
+	passwordHash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return "", "", err
+	}
+
+	changedAt := time.Now()
+	if err = repository.UpdateUserPassword(userID, passwordHash, changedAt); err != nil {
+		return "", "", err
+	}
+
+	if err = endPasswordSessions(userID, changedAt); err != nil {
+		return "", "", err
+	}
+
+	return StartSession(user, client)
+}
+
+// IsAccessTokenRevoked проверяет, не отозвана ли сессия токена и не сменён ли пароль или отозвана роль после его выдачи.
+// Состояние берётся из кэша, при промахе или недоступности кэша — из базы
+func IsAccessTokenRevoked(claims *utils.CustomClaims) bool {
+	if claims.SessionID != 0 && isSessionRevoked(claims.SessionID) {
+		return true
+	}
+
+	revokedAt, userDeleted := getTokensRevokedAt(claims.UserID)
+	return userDeleted || claims.IssuedAtMicros() <= revokedAt
+}
+
+// isSessionRevoked возвращает состояние сессии и кэширует прочитанное из базы. Кэш заполняется через SetCacheNX,
+// чтобы состояние, прочитанное до отзыва, не перезаписало отметку, поставленную отзывом
+func isSessionRevoked(sessionID uint) bool {
+	state, err := db.GetCache(sessionStateCacheKey(sessionID))
+	if err == nil && state != "" {
+		return state != sessionStateActive
+	}
+
+	session, err := repository.GetUserSessionByID(sessionID)
+	if err != nil {
+		return errors.Is(err, errs.ErrSessionNotFound)
+	}
+
+	state = sessionStateActive
+	if session.RevokedAt != nil {
+		state = sessionStateRevoked
+	}
+	_, _ = db.SetCacheNX(sessionStateCacheKey(sessionID), state, revocationStateTTL)
+
+	return state == sessionStateRevoked
+}
+
+// getTokensRevokedAt возвращает время последней смены пароля или отзыва роли пользователя в микросекундах
+// и кэширует прочитанное из базы так же, как isSessionRevoked
+func getTokensRevokedAt(userID uint) (revokedAt int64, userDeleted bool) {
+	cached, err := db.GetCache(tokensRevokedCacheKey(userID))
+	if err == nil && cached != "" {
+		if revokedAt, err = strconv.ParseInt(cached, 10, 64); err == nil {
+			return revokedAt, false
+		}
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return 0, errors.Is(err, errs.ErrRecordNotFound)
+	}
+
+	revokedAt = 0
+	for _, changedAt := range []*time.Time{user.PasswordChangedAt, user.RolesChangedAt} {
+		if changedAt != nil && changedAt.UnixMicro() > revokedAt {
+			revokedAt = changedAt.UnixMicro()
+		}
+	}
+	_, _ = db.SetCacheNX(tokensRevokedCacheKey(userID), revokedAt, revocationStateTTL)
+
+	return revokedAt, false
+}
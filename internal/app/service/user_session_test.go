@@ -0,0 +1,182 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/internal/testutil"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/utils"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func TestIsAccessTokenRevokedInTheSameSecond(t *testing.T) {
+	const userID = 900001
+
+	revokedAt := time.Now().Truncate(time.Second).Add(500 * time.Millisecond)
+	denyUserAccessTokens(userID, revokedAt)
+
+	claims := func(issuedAt time.Time, withMicros bool) *utils.CustomClaims {
+		c := &utils.CustomClaims{
+			UserID:         userID,
+			StandardClaims: jwt.StandardClaims{IssuedAt: issuedAt.Unix()},
+		}
+		if withMicros {
+			c.IssuedAtMicro = issuedAt.UnixMicro()
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		claims *utils.CustomClaims
+		want   bool
+	}{
+		{name: "issued earlier in the same second", claims: claims(revokedAt.Add(-time.Millisecond), true), want: true},
+		{name: "issued at the revocation", claims: claims(revokedAt, true), want: true},
+		{name: "issued later in the same second", claims: claims(revokedAt.Add(time.Millisecond), true), want: false},
+		{name: "legacy token from the same second", claims: claims(revokedAt.Add(time.Millisecond), false), want: true},
+		{name: "legacy token from the next second", claims: claims(revokedAt.Add(time.Second), false), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAccessTokenRevoked(tt.claims); got != tt.want {
+				t.Fatalf("expected revoked %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsAccessTokenRevokedForDeniedSession(t *testing.T) {
+	const sessionID = 900002
+
+	claims := &utils.CustomClaims{UserID: 900002, SessionID: sessionID, IssuedAtMicro: time.Now().UnixMicro()}
+	denyUserAccessTokens(claims.UserID, time.Unix(0, 0))
+
+	if err := db.SetCache(sessionStateCacheKey(sessionID), sessionStateActive, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if IsAccessTokenRevoked(claims) {
+		t.Fatal("expected a token of an active session to be accepted")
+	}
+
+	denySessionAccessTokens([]uint{sessionID})
+	if !IsAccessTokenRevoked(claims) {
+		t.Fatal("expected a token of a denied session to be revoked")
+	}
+}
+
+func TestRefreshSession(t *testing.T) {
+	testutil.RequireDB(t)
+	t.Setenv("JWT_SECRET_KEY", "test secret")
+
+	client := models.SessionClient{DeviceName: "test", IP: "127.0.0.1", UserAgent: "go test"}
+
+	startSession := func(t *testing.T) (accessToken, refreshToken string, claims *utils.CustomClaims) {
+		t.Helper()
+
+		f := testutil.NewFixture(t, 1, 1, 0)
+		accessToken, refreshToken, err := StartSession(f.Buyer, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if claims, err = utils.ParseAccessToken(accessToken); err != nil {
+			t.Fatal(err)
+		}
+
+		return accessToken, refreshToken, claims
+	}
+
+	t.Run("rotation replaces the refresh token", func(t *testing.T) {
+		_, refreshToken, claims := startSession(t)
+
+		_, rotated, err := RefreshSession(refreshToken, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rotated == refreshToken {
+			t.Fatal("expected a new refresh token")
+		}
+
+		if _, _, err = RefreshSession(rotated, client); err != nil {
+			t.Fatalf("expected the rotated token to be accepted, got %v", err)
+		}
+
+		if IsAccessTokenRevoked(claims) {
+			t.Fatal("expected the session's access token to stay valid after rotation")
+		}
+	})
+
+	t.Run("reuse revokes the session", func(t *testing.T) {
+		_, refreshToken, claims := startSession(t)
+
+		_, rotated, err := RefreshSession(refreshToken, client)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err = RefreshSession(refreshToken, client); !errors.Is(err, errs.ErrRefreshTokenReused) {
+			t.Fatalf("expected %v, got %v", errs.ErrRefreshTokenReused, err)
+		}
+
+		if _, _, err = RefreshSession(rotated, client); !errors.Is(err, errs.ErrSessionRevoked) {
+			t.Fatalf("expected the rotated token to die with the session, got %v", err)
+		}
+
+		session, err := repository.GetUserSessionByID(claims.SessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if session.RevokedAt == nil || session.RevokeReason != models.SessionRevokedTokenReuse {
+			t.Fatalf("expected the session to be revoked for token reuse, got %+v", session)
+		}
+
+		if !IsAccessTokenRevoked(claims) {
+			t.Fatal("expected the session's access token to be denied")
+		}
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		_, refreshToken, claims := startSession(t)
+
+		if err := db.GetDBConn().Model(&models.UserSession{}).
+			Where("id = ?", claims.SessionID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := RefreshSession(refreshToken, client); !errors.Is(err, errs.ErrRefreshTokenExpired) {
+			t.Fatalf("expected %v, got %v", errs.ErrRefreshTokenExpired, err)
+		}
+	})
+
+	t.Run("logout denies the access token", func(t *testing.T) {
+		_, refreshToken, claims := startSession(t)
+
+		if err := Logout(claims.UserID, claims.SessionID); err != nil {
+			t.Fatal(err)
+		}
+
+		if !IsAccessTokenRevoked(claims) {
+			t.Fatal("expected the access token of a logged out session to be denied")
+		}
+
+		if _, _, err := RefreshSession(refreshToken, client); !errors.Is(err, errs.ErrSessionRevoked) {
+			t.Fatalf("expected %v, got %v", errs.ErrSessionRevoked, err)
+		}
+	})
+
+	t.Run("access token can't refresh", func(t *testing.T) {
+		accessToken, _, _ := startSession(t)
+
+		if _, _, err := RefreshSession(accessToken, client); !errors.Is(err, errs.ErrInvalidToken) {
+			t.Fatalf("expected %v, got %v", errs.ErrInvalidToken, err)
+		}
+	})
+}
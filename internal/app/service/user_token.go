@@ -0,0 +1,197 @@
+package service
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/repository"
+	"BizMart/internal/security"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/mail"
+	"BizMart/pkg/utils"
+	"errors"
+	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	EmailVerificationTTL = 24 * time.Hour
+	PasswordResetTTL     = time.Hour
+	// mailResendInterval не даёт слать письма одному пользователю чаще раза в минуту
+	mailResendInterval = time.Minute
+)
+
+func mailSentCacheKey(purpose string, userID uint) string {
+	return fmt.Sprintf("auth:mail_sent:%s:%d", purpose, userID)
+}
+
+// validateEmail проверяет, что email — один адрес без имени, например user@example.com
+func validateEmail(email string) error {
+	address, err := netmail.ParseAddress(email)
+	if err != nil || address.Address != email || !strings.Contains(email[strings.LastIndex(email, "@"):], ".") {
+		return errs.ErrInvalidEmail
+	}
+	return nil
+}
+
+// RequireVerifiedEmail возвращает ErrEmailNotVerified, если пользователь не подтвердил email
+func RequireVerifiedEmail(userID uint) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt == nil {
+		return errs.ErrEmailNotVerified
+	}
+
+	return nil
+}
+
+// claimMailSlot разрешает отправить письмо, если пользователю недавно не отправляли такое же.
+// Без кэша ограничение не действует
+func claimMailSlot(purpose string, userID uint) bool {
+	claimed, err := db.SetCacheNX(mailSentCacheKey(purpose, userID), "1", mailResendInterval)
+	return err != nil || claimed
+}
+
+// appLink возвращает ссылку на страницу клиентского приложения с токеном
+func appLink(page, token string) string {
+	return fmt.Sprintf("%s/%s?token=%s", strings.TrimSuffix(security.AppSettings.MailParams.AppURL, "/"), page, url.QueryEscape(token))
+}
+
+// sendUserToken создаёт токен с назначением purpose и отправляет его пользователю письмом
+func sendUserToken(user models.User, purpose string, ttl time.Duration, message func(token string) mail.Message) error {
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		return err
+	}
+
+	if err = repository.CreateUserToken(&models.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: tokenHash,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	return mail.Get().Send(message(token))
+}
+
+func sendEmailVerification(user models.User) error {
+	return sendUserToken(user, models.TokenPurposeEmailVerification, EmailVerificationTTL, func(token string) mail.Message {
+		return mail.Message{
+			To:      user.Email,
+			Subject: "Confirm your email",
+			Body: fmt.Sprintf("Hello, %s!\n\nConfirm your email by opening the link:\n%s\n\n"+
+				"The link is valid for 24 hours. If you didn't sign up, ignore this email.\n",
+				user.Username, appLink("verify-email", token)),
+		}
+	})
+}
+
+// sendSignUpVerification отправляет письмо для подтверждения email новому пользователю.
+// Ошибка только пишется в лог: письмо можно запросить повторно
+func sendSignUpVerification(user models.User) {
+	if !claimMailSlot(models.TokenPurposeEmailVerification, user.ID) {
+		return
+	}
+
+	if err := sendEmailVerification(user); err != nil {
+		logger.Error.Printf("[service.sendSignUpVerification] error sending verification email to user %d: %v", user.ID, err)
+	}
+}
+
+// SendEmailVerification повторно отправляет письмо для подтверждения email, прежние ссылки перестают действовать
+func SendEmailVerification(userID uint) error {
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return errs.ErrEmailAlreadyVerified
+	}
+
+	if !claimMailSlot(models.TokenPurposeEmailVerification, user.ID) {
+		return errs.ErrMailRecentlySent
+	}
+
+	return sendEmailVerification(user)
+}
+
+// VerifyEmail подтверждает email по токену из письма, токен действует один раз
+func VerifyEmail(token string) error {
+	_, err := repository.VerifyUserEmail(hashSecretToken(token))
+	return err
+}
+
+// ForgotPassword отправляет письмо со ссылкой для сброса пароля. Ответ не зависит от того,
+// есть ли пользователь с таким email, поэтому письмо отправляется в фоне
+func ForgotPassword(email string) error {
+	if err := validateEmail(email); err != nil {
+		return err
+	}
+
+	go sendPasswordReset(email)
+	return nil
+}
+
+func sendPasswordReset(email string) {
+	user, err := repository.GetUserByEmail(email)
+	if err != nil {
+		if !errors.Is(err, errs.ErrUserNotFound) {
+			logger.Error.Printf("[service.sendPasswordReset] error getting user: %v", err)
+		}
+		return
+	}
+
+	if !claimMailSlot(models.TokenPurposePasswordReset, user.ID) {
+		return
+	}
+
+	err = sendUserToken(user, models.TokenPurposePasswordReset, PasswordResetTTL, func(token string) mail.Message {
+		return mail.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hello, %s!\n\nSet a new password by opening the link:\n%s\n\n"+
+				"The link is valid for 1 hour and works once. If you didn't ask to reset the password, ignore this email.\n",
+				user.Username, appLink("reset-password", token)),
+		}
+	})
+	if err != nil {
+		logger.Error.Printf("[service.sendPasswordReset] error sending password reset email to user %d: %v", user.ID, err)
+	}
+}
+
+// ResetPassword устанавливает новый пароль по токену из письма, завершает все сессии пользователя
+// и запрещает выданные им access-токены. Токен действует один раз
+func ResetPassword(token, newPassword string) error {
+	if newPassword == "" {
+		return errs.ErrPasswordIsEmpty
+	}
+
+	// Токен проверяется до дорогого хеширования пароля
+	tokenHash := hashSecretToken(token)
+	if _, err := repository.GetValidUserToken(tokenHash, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	passwordHash, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	changedAt := time.Now()
+	userID, err := repository.ResetUserPassword(tokenHash, passwordHash, changedAt)
+	if err != nil {
+		return err
+	}
+
+	return endPasswordSessions(userID, changedAt)
+}
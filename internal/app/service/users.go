@@ -5,14 +5,15 @@ import (
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
 	"BizMart/pkg/utils"
 	"fmt"
 )
 
-func GetAllUsers() (users []models.User, err error) {
-	users, err = repository.GetAllUsers()
+func GetAllUsers(params pagination.Params) (users pagination.Page[models.User], err error) {
+	users, err = repository.GetAllUsers(params)
 	if err != nil {
-		return nil, err
+		return pagination.Page[models.User]{}, err
 	}
 
 	return users, nil
@@ -37,6 +38,10 @@ func CreateUser(user models.User) (uint, error) {
 		return 0, errs.ErrInvalidData
 	}
 
+	if err = validateEmail(user.Email); err != nil {
+		return 0, err
+	}
+
 	if usernameExists {
 		logger.Error.Printf("user with username %s already exists", user.Username)
 		return 0, errs.ErrUsernameUniquenessFailed
@@ -47,7 +52,12 @@ func CreateUser(user models.User) (uint, error) {
 		return 0, errs.ErrEmailUniquenessFailed
 	}
 
-	user.HashPassword = utils.GenerateHash(user.HashPassword)
+	// Email подтверждается только по ссылке из письма
+	user.EmailVerifiedAt = nil
+
+	if user.HashPassword, err = utils.HashPassword(user.HashPassword); err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
 
 	var userID uint
 
@@ -55,5 +65,10 @@ func CreateUser(user models.User) (uint, error) {
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
 
+	grantSystemRole(userID, models.RoleBuyer, "granted on sign up")
+
+	user.ID = userID
+	go sendSignUpVerification(user)
+
 	return userID, nil
 }
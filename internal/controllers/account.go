@@ -10,7 +10,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
-	"time"
 )
 
 // GetAccountsByUserID godoc
@@ -122,6 +121,8 @@ func CreateAccount(c *gin.Context) {
 	}
 
 	account.UserID = userID
+	// Баланс меняется только через проводки журнала
+	account.Balance = 0
 
 	if _, err := repository.GetAccountByNumber(account.AccountNumber); err == nil {
 		HandleError(c, errs.ErrAccountNumberUniquenessFailed)
@@ -202,7 +203,6 @@ func UpdateAccount(c *gin.Context) {
 		return
 	}
 
-	account.UserID = userID
 	account.ID = uint(accountID)
 
 	err = repository.UpdateAccount(account)
@@ -221,7 +221,7 @@ func UpdateAccount(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Account ID"
 // @Success 200 {object} models.DefaultResponse "Account deleted successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid Account ID"
+// @Failure 400 {object} models.ErrorResponse "Invalid Account ID or Account Has Balance"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
 // @Failure 403 {object} models.ErrorResponse "Permission Denied"
 // @Failure 404 {object} models.ErrorResponse "Account Not Found"
@@ -262,8 +262,13 @@ func DeleteAccount(c *gin.Context) {
 		return
 	}
 
-	err = repository.DeleteAccount(accountData)
+	err = service.DeleteAccount(accountData.ID)
 	if err != nil {
+		if errors.Is(err, errs.ErrAccountHasBalance) {
+			HandleError(c, err)
+			return
+		}
+
 		HandleError(c, errs.ErrDeleteFailed)
 		return
 	}
@@ -321,7 +326,7 @@ func FillAccountBalance(c *gin.Context) {
 		return
 	}
 
-	if account.Balance < 0 || account.Balance > 20000 {
+	if account.Balance <= 0 || account.Balance > 20000 {
 		HandleError(c, errs.ErrInvalidBalance)
 		return
 	}
@@ -331,14 +336,83 @@ func FillAccountBalance(c *gin.Context) {
 		return
 	}
 
-	account.ID = uint(accountID)
-	account.AccountNumber = accountData.AccountNumber
-	account.UpdatedAt = time.Now()
-
-	if err = repository.FillAccountBalance(account.AccountNumber, account.Balance); err != nil {
+	if err = service.TopUpAccount(accountData.ID, account.Balance); err != nil {
 		HandleError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Account filled successfully"})
 }
+
+// GetAccountTransactions godoc
+// @Summary Get account transactions
+// @Description Retrieve the ledger postings (top-ups, purchases, payouts, refunds) of an account of the authenticated user
+// @Tags accounts
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} models.Posting
+// @Failure 400 {object} models.ErrorResponse "Invalid Account ID"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission Denied"
+// @Failure 404 {object} models.ErrorResponse "Account Not Found"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /accounts/{id}/transactions [get]
+// @Security ApiKeyAuth
+func GetAccountTransactions(c *gin.Context) {
+	accountID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		HandleError(c, errs.ErrInvalidAccountID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	account, err := repository.GetAccountByID(uint(accountID))
+	if err != nil {
+		if errors.Is(err, errs.ErrRecordNotFound) {
+			HandleError(c, errs.ErrAccountNotFound)
+			return
+		}
+
+		HandleError(c, err)
+		return
+	}
+
+	if account.UserID != userID {
+		HandleError(c, errs.ErrPermissionDenied)
+		return
+	}
+
+	transactions, err := service.GetAccountTransactions(account.ID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transactions": transactions})
+}
+
+// ReconcileAccounts godoc
+// @Summary Reconcile account balances
+// @Description Lists every account whose stored balance diverges from the sum of its ledger postings
+// @Tags accounts
+// @Produce json
+// @Success 200 {object} models.AccountReconciliation
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission Denied"
+// @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Router /ledger/reconciliation [get]
+// @Security ApiKeyAuth
+func ReconcileAccounts(c *gin.Context) {
+	divergentAccounts, err := service.ReconcileAccounts()
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"divergent_accounts": divergentAccounts})
+}
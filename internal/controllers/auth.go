@@ -3,20 +3,31 @@ package controllers
 import (
 	"BizMart/internal/app/models"
 	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
 	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
-	utils2 "BizMart/pkg/utils"
 	"errors"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 	"net/http"
-	"os"
-	"time"
+	"strconv"
 )
 
+// DeviceNameHeader необязательное название устройства, под которым сессия показывается в списке сессий
+const DeviceNameHeader = "X-Device-Name"
+
+// sessionClient описывает устройство, с которого пришёл запрос
+func sessionClient(c *gin.Context) models.SessionClient {
+	return models.SessionClient{
+		DeviceName: c.GetHeader(DeviceNameHeader),
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+}
+
 // SignUp godoc
 // @Summary Register a new user
 // @Description This endpoint registers a new user with a username, email, and password.
+// @Description A verification link is sent to the email, opening stores and paying require a verified email.
 // @Tags auth
 // @Accept  json
 // @Produce  json
@@ -25,13 +36,14 @@ import (
 // @Failure 400 {object} models.ErrorResponse
 // @Router /auth/sign-up [post]
 func SignUp(c *gin.Context) {
-	var user models.User
+	var request models.UserRequest
 
-	if err := c.BindJSON(&user); err != nil {
+	if err := c.BindJSON(&request); err != nil {
 		HandleError(c, errs.ErrValidationFailed)
 		return
 	}
 
+	user := request.ToUser()
 	if user.HashPassword == "" {
 		HandleError(c, errs.ErrPasswordIsEmpty)
 		return
@@ -59,7 +71,7 @@ func SignUp(c *gin.Context) {
 
 	user.ID = userID
 
-	accessToken, refreshToken, err := utils2.GenerateToken(user.ID, user.Username)
+	accessToken, refreshToken, err := service.StartSession(user, sessionClient(c))
 	if err != nil {
 		logger.Error.Printf("Error generating access token: %s", err)
 		HandleError(c, err)
@@ -84,31 +96,29 @@ func SignUp(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Router /auth/sign-in [post]
 func SignIn(c *gin.Context) {
-	var user models.User
+	var login models.UserLogin
 	isEmailEmpty := false
 
-	if err := c.BindJSON(&user); err != nil {
+	if err := c.BindJSON(&login); err != nil {
 		HandleError(c, errs.ErrValidationFailed)
 		return
 	}
 
-	if user.HashPassword == "" {
+	if login.Password == "" {
 		HandleError(c, errs.ErrPasswordIsEmpty)
 		return
 	}
 
-	if user.Email == "" {
+	if login.Email == "" {
 		isEmailEmpty = true
 	}
 
-	if user.Username == "" && isEmailEmpty {
+	if login.Username == "" && isEmailEmpty {
 		HandleError(c, errs.ErrUsernameIsEmpty)
 		return
 	}
 
-	user.HashPassword = utils2.GenerateHash(user.HashPassword)
-
-	user, accessToken, refreshToken, err := service.SignIn(user.Username, user.Email, user.HashPassword)
+	user, accessToken, refreshToken, err := service.SignIn(login.Username, login.Email, login.Password, sessionClient(c))
 	if err != nil {
 		if errors.Is(err, errs.ErrRecordNotFound) {
 			HandleError(c, errs.ErrIncorrectUsernameOrPassword)
@@ -128,45 +138,254 @@ func SignIn(c *gin.Context) {
 
 // RefreshToken godoc
 // @Summary Refresh Token
-// @Description This endpoint refreshes the access token.
+// @Description This endpoint exchanges a refresh token for a new pair of tokens, the refresh token can be used only once.
+// @Description Presenting a refresh token that was already exchanged revokes its whole session.
 // @Tags auth
 // @Accept  json
 // @Produce  json
-// @Param user body models.RefreshRequest true "User login information"
-// @Success 200 {object} models.RefreshTokenResponse
+// @Param user body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.TokenResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse "Invalid, expired or reused token, or revoked session"
 // @Router /auth/refresh [post]
 func RefreshToken(c *gin.Context) {
-	var requestBody struct {
-		RefreshToken string `json:"refresh_token"`
+	var requestBody models.RefreshRequest
+	if err := c.ShouldBindJSON(&requestBody); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
 	}
 
-	if err := c.ShouldBindJSON(&requestBody); err != nil {
+	accessToken, refreshToken, err := service.RefreshSession(requestBody.RefreshToken, sessionClient(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"access_token": accessToken, "refresh_token": refreshToken})
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Ends the current session: its refresh token and access tokens stop working.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.DefaultResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := service.Logout(userID, c.GetUint(middlewares.SessionIDCtx)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// GetSessions godoc
+// @Summary Get sessions
+// @Description Returns the active sessions of the user with their device, IP address, user agent and last use time.
+// @Description The session of the current access token is marked as current.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {array} models.UserSession
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/sessions [get]
+func GetSessions(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	sessions, err := service.GetUserSessions(userID, c.GetUint(middlewares.SessionIDCtx))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession godoc
+// @Summary Revoke session
+// @Description Ends a session of the user, e.g. on a lost device: its refresh token and access tokens stop working.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Session ID"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Session not found"
+// @Router /auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || sessionID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err = service.RevokeSession(userID, uint(sessionID)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked successfully"})
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Changes the password of the user. All sessions are ended and access tokens issued before the change stop working,
+// @Description a new session is started for the current device.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param request body models.ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} models.ErrorResponse "Current password is incorrect or new password is empty"
+// @Failure 401 {object} models.ErrorResponse
+// @Router /auth/password [put]
+func ChangePassword(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
 		HandleError(c, errs.ErrValidationFailed)
 		return
 	}
 
-	// Проверка валидности refresh_token
-	token, err := jwt.ParseWithClaims(requestBody.RefreshToken, &utils2.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET_KEY")), nil
+	accessToken, refreshToken, err := service.ChangePassword(userID, request.CurrentPassword, request.NewPassword, sessionClient(c))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		UserID:       userID,
 	})
-	if err != nil || !token.Valid {
-		HandleError(c, errs.ErrInvalidToken)
+}
+
+// SendEmailVerification godoc
+// @Summary Resend email verification
+// @Description Sends a new email verification link to the user, earlier links stop working. The link is valid for 24 hours.
+// @Description Opening stores and paying require a verified email.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse "Email is already verified"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "An email was sent less than a minute ago"
+// @Router /auth/email/verification [post]
+func SendEmailVerification(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
 		return
 	}
 
-	// Генерация нового access_token
-	claims, ok := token.Claims.(*utils2.CustomClaims)
-	if !ok || claims.ExpiresAt < time.Now().Unix() {
-		HandleError(c, errs.ErrRefreshTokenExpired)
+	if err := service.SendEmailVerification(userID); err != nil {
+		HandleError(c, err)
 		return
 	}
 
-	accessToken, refreshToken, err := utils2.GenerateToken(claims.UserID, claims.Username)
-	if err != nil {
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// VerifyEmail godoc
+// @Summary Verify email
+// @Description Verifies the email of the user with the token from the verification email. The token works once.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body models.VerifyEmailRequest true "Token from the email"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse "Token is invalid, used or expired"
+// @Router /auth/email/verify [post]
+func VerifyEmail(c *gin.Context) {
+	var request models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err := service.VerifyEmail(request.Token); err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"access_token": accessToken, "refresh_token": refreshToken})
+	c.JSON(http.StatusOK, gin.H{"message": "email verified successfully"})
+}
+
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Sends a password reset link to the email if a user with it exists. The response is the same for unknown emails.
+// @Description The link is valid for 1 hour and works once.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body models.ForgotPasswordRequest true "Email of the user"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse "Invalid email"
+// @Router /auth/password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var request models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err := service.ForgotPassword(request.Email); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the email is registered, a password reset link has been sent to it"})
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Sets a new password with the token from the password reset email. All sessions of the user are ended
+// @Description and access tokens issued before the reset stop working. The token works once.
+// @Tags auth
+// @Accept  json
+// @Produce  json
+// @Param request body models.ResetPasswordRequest true "Token from the email and new password"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse "Token is invalid, used or expired"
+// @Router /auth/password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var request models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err := service.ResetPassword(request.Token, request.NewPassword); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
 }
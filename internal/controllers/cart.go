@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetCart godoc
+// @Summary Get cart
+// @Description Retrieves the shopping cart of the authenticated user with current prices and total.
+// @Tags cart
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} models.Cart "cart"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /cart [get]
+func GetCart(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	cart, err := service.GetCart(userID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cart": cart})
+}
+
+// AddCartItem godoc
+// @Summary Add product to cart
+// @Description Adds a product variant to the cart of the authenticated user or increases its quantity.
+// @Description Without variant_id the default variant of the product is added.
+// @Description The same variant with different modifiers (toppings, "no onions") makes separate cart lines.
+// @Tags cart
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param item body models.CartItemRequest true "Cart item"
+// @Success 200 {object} models.DefaultResponse "Item added to cart"
+// @Failure 400 {object} models.ErrorResponse "Validation failed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /cart [post]
+func AddCartItem(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.CartItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if request.ProductID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	if err := service.AddCartItem(userID, request); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "item added to cart successfully"})
+}
+
+// UpdateCartItem godoc
+// @Summary Update cart item quantity
+// @Description Sets the quantity of a product in the cart. Quantity 0 removes the product.
+// @Tags cart
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param product_id path int true "Product ID"
+// @Param variant_id query int false "Variant ID, the default variant is used if omitted"
+// @Param modifier_ids query string false "Comma-separated IDs of the modifiers chosen for the cart line"
+// @Param item body models.CartItemRequest true "Cart item"
+// @Success 200 {object} models.DefaultResponse "Cart item updated"
+// @Failure 400 {object} models.ErrorResponse "Validation failed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Cart item not found"
+// @Router /cart/{product_id} [put]
+func UpdateCartItem(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	variantID, err := strconv.ParseUint(c.DefaultQuery("variant_id", "0"), 10, 64)
+	if err != nil {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	modifierIDs, err := parseIDList(c.Query("modifier_ids"))
+	if err != nil {
+		HandleError(c, errs.ErrInvalidModifier)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.CartItemRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err = service.UpdateCartItem(userID, uint(productID), uint(variantID), modifierIDs, request.Quantity); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cart item updated successfully"})
+}
+
+// RemoveCartItem godoc
+// @Summary Remove product from cart
+// @Description Removes a product from the cart of the authenticated user.
+// @Tags cart
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param product_id path int true "Product ID"
+// @Param variant_id query int false "Variant ID, the default variant is used if omitted"
+// @Param modifier_ids query string false "Comma-separated IDs of the modifiers chosen for the cart line"
+// @Success 200 {object} models.DefaultResponse "Cart item removed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Cart item not found"
+// @Router /cart/{product_id} [delete]
+func RemoveCartItem(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	variantID, err := strconv.ParseUint(c.DefaultQuery("variant_id", "0"), 10, 64)
+	if err != nil {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	modifierIDs, err := parseIDList(c.Query("modifier_ids"))
+	if err != nil {
+		HandleError(c, errs.ErrInvalidModifier)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err = service.RemoveCartItem(userID, uint(productID), uint(variantID), modifierIDs); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cart item removed successfully"})
+}
+
+// ClearCart godoc
+// @Summary Clear cart
+// @Description Removes all products from the cart of the authenticated user.
+// @Tags cart
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} models.DefaultResponse "Cart cleared"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Router /cart [delete]
+func ClearCart(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err := service.ClearCart(userID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "cart cleared successfully"})
+}
+
+// Checkout godoc
+// @Summary Checkout cart
+// @Description Turns the cart of the authenticated user into a single order with many line items.
+// @Tags cart
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param checkout body models.CheckoutRequest true "Checkout data"
+// @Param Idempotency-Key header string false "Unique key of the request, retries with the same key replay the first response"
+// @Success 201 {object} models.DefaultResponse "Order created successfully"
+// @Failure 400 {object} models.ErrorResponse "Cart is empty or not enough product in stock"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 404 {object} models.ErrorResponse "Address not found"
+// @Failure 409 {object} models.ErrorResponse "Request with this idempotency key is in progress"
+// @Failure 422 {object} models.ErrorResponse "Idempotency key is reused with a different request"
+// @Router /cart/checkout [post]
+func Checkout(c *gin.Context) {
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.CheckoutRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if request.AddressID == 0 {
+		HandleError(c, errs.ErrInvalidAddressID)
+		return
+	}
+
+	orderID, err := service.Checkout(userID, request.AddressID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "order created successfully",
+		"order_id": orderID,
+	})
+}
+
+// parseIDList разбирает список ID через запятую, например "3,7,12"
+func parseIDList(value string) ([]uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []uint
+	for _, part := range strings.Split(value, ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+		if err != nil || id == 0 {
+			return nil, errs.ErrInvalidID
+		}
+		ids = append(ids, uint(id))
+	}
+
+	return ids, nil
+}
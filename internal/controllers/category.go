@@ -134,16 +134,18 @@ func UpdateCategory(c *gin.Context) {
 	})
 }
 
-// DeleteCategory deletes a category by its ID from the repository
+// DeleteCategory deletes a category by its ID
 // @Summary      Delete category by ID
-// @Description  Deletes a category by its ID
+// @Description  Deletes a category by its ID. A category with products or subcategories can't be deleted
+// @Description  unless reassign_to is set: its products and direct subcategories are moved to that category first.
 // @Tags         Category
 // @Security ApiKeyAuth
 // @Accept       json
 // @Produce      json
 // @Param        id   path      int  true  "Category ID"
+// @Param        reassign_to   query      int  false  "Category to move products and subcategories to"
 // @Success      200  {object}  models.DefaultResponse
-// @Failure      400  {object}  models.ErrorResponse
+// @Failure      400  {object}  models.ErrorResponse "Category is not empty or reassign_to is invalid"
 // @Failure      404  {object}  models.ErrorResponse
 // @Router       /category/{id} [delete]
 func DeleteCategory(c *gin.Context) {
@@ -154,7 +156,15 @@ func DeleteCategory(c *gin.Context) {
 		return
 	}
 
-	err = repository.DeleteCategory(uint(id))
+	var reassignTo uint64
+	if value := c.Query("reassign_to"); value != "" {
+		if reassignTo, err = strconv.ParseUint(value, 10, 64); err != nil || reassignTo == 0 {
+			HandleError(c, errs.ErrInvalidTargetCategory)
+			return
+		}
+	}
+
+	err = service.DeleteCategory(uint(id), uint(reassignTo))
 	if err != nil {
 		HandleError(c, err)
 		return
@@ -164,3 +174,96 @@ func DeleteCategory(c *gin.Context) {
 		"message": "category deleted successfully",
 	})
 }
+
+// GetCategoryTree returns the category tree
+// @Summary      Get category tree
+// @Description  Returns categories as a tree, subcategories are sorted by name. With root_id only the subtree of that category is returned.
+// @Tags         Category
+// @Produce      json
+// @Param        root_id   query      int  false  "Root category ID"
+// @Success      200  {array}   models.CategoryNode
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /category/tree [get]
+func GetCategoryTree(c *gin.Context) {
+	var rootID uint64
+	if value := c.Query("root_id"); value != "" {
+		var err error
+		if rootID, err = strconv.ParseUint(value, 10, 64); err != nil || rootID == 0 {
+			HandleError(c, errs.ErrInvalidID)
+			return
+		}
+	}
+
+	tree, err := service.GetCategoryTree(uint(rootID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
+// GetCategoryPath returns the breadcrumbs of a category
+// @Summary      Get category breadcrumbs
+// @Description  Returns the ancestors of a category starting from the root, followed by the category itself
+// @Tags         Category
+// @Produce      json
+// @Param        id   path      int  true  "Category ID"
+// @Success      200  {array}   models.Category
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /category/{id}/path [get]
+func GetCategoryPath(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		HandleError(c, errs.ErrPathParametrized)
+		return
+	}
+
+	path, err := service.GetCategoryPath(uint(id))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": path})
+}
+
+// MoveCategory moves a category with its subcategories
+// @Summary Переместить категорию
+// @Description Переносит категорию вместе со всеми подкатегориями под другого родителя, parent_id 0 делает её корневой.
+// @Description Категорию нельзя перенести в неё саму или в её подкатегорию
+// @Tags Category
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID категории"
+// @Param category body models.MoveCategoryRequest true "Новый родитель"
+// @Success 200 {object} models.DefaultResponse "Успешное перемещение"
+// @Failure 400 {object} models.ErrorResponse "Родитель не найден или перемещение создаёт цикл"
+// @Failure 404 {object} models.ErrorResponse "Категория не найдена"
+// @Router /category/{id}/move [put]
+func MoveCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		HandleError(c, errs.ErrPathParametrized)
+		return
+	}
+
+	var request models.MoveCategoryRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err = service.MoveCategory(uint(id), request.ParentID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "category moved successfully",
+		"category_id": id,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"BizMart/internal/controllers/middlewares"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/pagination"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -12,12 +13,15 @@ import (
 
 // GetProductComments godoc
 // @Summary Get all comments for a product
-// @Description Fetches all comments for a product and builds comment tree.
+// @Description Fetches a page of top-level comments for a product and builds a comment tree with all their replies.
 // @Tags comments
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Product ID"
-// @Success 200 {object} models.Comment
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param order query string false "asc or desc, newest first by default"
+// @Success 200 {object} pagination.Page[models.CommentTree]
 // @Failure 404 {object} models.ErrorResponse
 // @Router /product/comments/{id} [get]
 func GetProductComments(c *gin.Context) {
@@ -28,17 +32,25 @@ func GetProductComments(c *gin.Context) {
 		return
 	}
 
-	// Получаем комментарии к продукту
-	mainComments, commentsDict, err := repository.GetProductComments(uint(productID))
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// Получаем страницу корневых комментариев к продукту
+	mainComments, commentsDict, err := repository.GetProductComments(uint(productID), params)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
 	// Строим дерево комментариев
-	commentTree := repository.BuildCommentTree(mainComments, commentsDict)
+	commentTree := pagination.Map(mainComments, func(comments []models.Comment) []models.CommentTree {
+		return repository.BuildCommentTree(comments, commentsDict)
+	})
 
-	c.JSON(http.StatusOK, gin.H{"comments": commentTree})
+	c.JSON(http.StatusOK, commentTree)
 }
 
 // CreateProductComment godoc
@@ -12,6 +12,7 @@ import (
 func handleBadRequestErrors(err error) bool {
 	return errors.Is(err, errs.ErrUsernameUniquenessFailed) ||
 		errors.Is(err, errs.ErrIncorrectUsernameOrPassword) ||
+		errors.Is(err, errs.ErrPasswordIncorrect) ||
 		errors.Is(err, errs.ErrCategoryNameUniquenessFailed) ||
 		errors.Is(err, errs.ErrOrderStatusNameUniquenessFailed) ||
 		errors.Is(err, errs.ErrOrderNotFound) ||
@@ -51,13 +52,55 @@ func handleBadRequestErrors(err error) bool {
 		errors.Is(err, errs.ErrInvalidDescription) ||
 		errors.Is(err, errs.ErrInvalidAmount) ||
 		errors.Is(err, errs.ErrInvalidQuantity) ||
-		errors.Is(err, errs.ErrInsufficientFunds)
+		errors.Is(err, errs.ErrCartIsEmpty) ||
+		errors.Is(err, errs.ErrOrderNotEditable) ||
+		errors.Is(err, errs.ErrOrderCannotBeCancelled) ||
+		errors.Is(err, errs.ErrOrderCannotBeRefunded) ||
+		errors.Is(err, errs.ErrNothingToRefund) ||
+		errors.Is(err, errs.ErrInvalidOrderStatusTransition) ||
+		errors.Is(err, errs.ErrOrderReservationExpired) ||
+		errors.Is(err, errs.ErrInvalidMovementKind) ||
+		errors.Is(err, errs.ErrVariantRequired) ||
+		errors.Is(err, errs.ErrInvalidSKU) ||
+		errors.Is(err, errs.ErrSKUUniquenessFailed) ||
+		errors.Is(err, errs.ErrCannotDeleteLastVariant) ||
+		errors.Is(err, errs.ErrInvalidModifier) ||
+		errors.Is(err, errs.ErrInvalidModifierSelection) ||
+		errors.Is(err, errs.ErrInvalidModifierGroup) ||
+		errors.Is(err, errs.ErrInvalidSearchQuery) ||
+		errors.Is(err, errs.ErrInvalidGTIN) ||
+		errors.Is(err, errs.ErrInvalidSort) ||
+		errors.Is(err, errs.ErrInvalidCursor) ||
+		errors.Is(err, errs.ErrInvalidLimit) ||
+		errors.Is(err, errs.ErrInvalidFields) ||
+		errors.Is(err, errs.ErrNoImagesUploaded) ||
+		errors.Is(err, errs.ErrTooManyImages) ||
+		errors.Is(err, errs.ErrInvalidImportFile) ||
+		errors.Is(err, errs.ErrTooManyImportRows) ||
+		errors.Is(err, errs.ErrInvalidExportFormat) ||
+		errors.Is(err, errs.ErrInvalidTargetCategory) ||
+		errors.Is(err, errs.ErrCategoryCycle) ||
+		errors.Is(err, errs.ErrCategoryNotEmpty) ||
+		errors.Is(err, errs.ErrCannotRevokeOwnAdminRole) ||
+		errors.Is(err, errs.ErrInvalidStoreRole) ||
+		errors.Is(err, errs.ErrAlreadyStoreMember) ||
+		errors.Is(err, errs.ErrStoreInvitationExists) ||
+		errors.Is(err, errs.ErrStoreInvitationExpired) ||
+		errors.Is(err, errs.ErrCannotChangeStoreOwner) ||
+		errors.Is(err, errs.ErrInvalidEmail) ||
+		errors.Is(err, errs.ErrInvalidUserToken) ||
+		errors.Is(err, errs.ErrUserTokenExpired) ||
+		errors.Is(err, errs.ErrEmailAlreadyVerified) ||
+		errors.Is(err, errs.ErrInsufficientFunds) ||
+		errors.Is(err, errs.ErrAccountHasBalance)
 }
 
 // Обработка ошибок, которые приводят к статусу 404 (Not Found)
 func handleNotFoundErrors(err error) bool {
 	return errors.Is(err, errs.ErrRecordNotFound) ||
 		errors.Is(err, errs.ErrCategoryNotFound) ||
+		errors.Is(err, errs.ErrSessionNotFound) ||
+		errors.Is(err, errs.ErrRoleNotFound) ||
 		errors.Is(err, errs.ErrOrderStatusNotFound) ||
 		errors.Is(err, errs.ErrOrderNotFound) ||
 		errors.Is(err, errs.ErrProductReviewNotFound) ||
@@ -67,22 +110,40 @@ func handleNotFoundErrors(err error) bool {
 		errors.Is(err, errs.ErrPaymentNotFound) ||
 		errors.Is(err, errs.ErrAccountNotFound) ||
 		errors.Is(err, errs.ErrStoreNotFound) ||
-		errors.Is(err, errs.ErrStoreReviewNotFound)
+		errors.Is(err, errs.ErrStoreReviewNotFound) ||
+		errors.Is(err, errs.ErrCartItemNotFound) ||
+		errors.Is(err, errs.ErrVariantNotFound) ||
+		errors.Is(err, errs.ErrModifierGroupNotFound) ||
+		errors.Is(err, errs.ErrCanonicalProductNotFound) ||
+		errors.Is(err, errs.ErrProductImportNotFound) ||
+		errors.Is(err, errs.ErrUserNotFound) ||
+		errors.Is(err, errs.ErrStoreMemberNotFound) ||
+		errors.Is(err, errs.ErrStoreInvitationNotFound)
 }
 
 // Обработка ошибок, которые приводят к статусу 401 (Unauthorized)
 func handleUnauthorizedErrors(err error) bool {
 	return errors.Is(err, errs.ErrInvalidToken) ||
 		errors.Is(err, errs.ErrUnauthorized) ||
-		errors.Is(err, errs.ErrRefreshTokenExpired)
+		errors.Is(err, errs.ErrRefreshTokenExpired) ||
+		errors.Is(err, errs.ErrRefreshTokenReused) ||
+		errors.Is(err, errs.ErrSessionRevoked)
 }
 
 // HandleError Основная функция обработки ошибок
 func HandleError(c *gin.Context, err error) {
 	if handleBadRequestErrors(err) {
 		c.JSON(http.StatusBadRequest, newErrorResponse(err.Error()))
-	} else if errors.Is(err, errs.ErrPermissionDenied) {
+	} else if errors.Is(err, errs.ErrPermissionDenied) || errors.Is(err, errs.ErrEmailNotVerified) {
 		c.JSON(http.StatusForbidden, newErrorResponse(err.Error()))
+	} else if errors.Is(err, errs.ErrImageTooLarge) || errors.Is(err, errs.ErrImportFileTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, newErrorResponse(err.Error()))
+	} else if errors.Is(err, errs.ErrPaymentImmutable) {
+		c.JSON(http.StatusGone, newErrorResponse(err.Error()))
+	} else if errors.Is(err, errs.ErrMailRecentlySent) {
+		c.JSON(http.StatusTooManyRequests, newErrorResponse(err.Error()))
+	} else if errors.Is(err, errs.ErrUnsupportedImageType) {
+		c.JSON(http.StatusUnsupportedMediaType, newErrorResponse(err.Error()))
 	} else if handleNotFoundErrors(err) {
 		c.JSON(http.StatusNotFound, newErrorResponse(err.Error()))
 	} else if errors.Is(err, errs.ErrFetchingProducts) {
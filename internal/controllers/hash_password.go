@@ -19,7 +19,12 @@ func HashPassword(c *gin.Context) {
 
 	password := c.Query("password")
 	password = strings.TrimSpace(password)
-	password = utils.GenerateHash(password)
+	password, err := utils.HashPassword(password)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
 	c.JSON(200, gin.H{
 		"password": password,
 	})
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+// multipartOverhead запас на поля и заголовки multipart-запроса сверх размера файлов
+const multipartOverhead = 1 << 20
+
+// parseImageUpload ограничивает размер тела запроса и читает из него файлы поля field
+func parseImageUpload(c *gin.Context, field string, maxFiles int) ([]*multipart.FileHeader, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxImageUploadSize()*int64(maxFiles)+multipartOverhead)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, errs.ErrImageTooLarge
+		}
+		return nil, errs.ErrNoImagesUploaded
+	}
+
+	files := form.File[field]
+	if len(files) == 0 {
+		return nil, errs.ErrNoImagesUploaded
+	}
+
+	return files, nil
+}
+
+// UploadProductImages godoc
+// @Summary Upload product images
+// @Description Uploads JPEG, PNG or GIF images and adds them to the product. The type is detected from the file content.
+// @Description Small, medium and large thumbnails are generated for every image. Only the owner, managers and catalog editors of the product's store can upload images.
+// @Tags products
+// @Security ApiKeyAuth
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Param images formData file true "Image files, the field can be repeated"
+// @Success 201 {array} models.UploadedImage "images"
+// @Failure 400 {object} models.ErrorResponse "No images or too many images"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Failure 413 {object} models.ErrorResponse "Image is too large"
+// @Failure 415 {object} models.ErrorResponse "Unsupported image type"
+// @Router /products/images/{id} [post]
+func UploadProductImages(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	files, err := parseImageUpload(c, "images", service.MaxProductImages())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	images, err := service.UploadProductImages(userID, uint(productID), files)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"images": images})
+}
+
+// UploadStoreImage godoc
+// @Summary Upload store image
+// @Description Replaces the image of a store with an uploaded JPEG, PNG or GIF file, the previous image is deleted.
+// @Description Small, medium and large thumbnails are generated. Only the owner and managers of the store can upload its image.
+// @Tags stores
+// @Security ApiKeyAuth
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Param image formData file true "Image file"
+// @Success 200 {object} models.UploadedImage "image"
+// @Failure 400 {object} models.ErrorResponse "No image"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store not found"
+// @Failure 413 {object} models.ErrorResponse "Image is too large"
+// @Failure 415 {object} models.ErrorResponse "Unsupported image type"
+// @Router /store/{id}/image [put]
+func UploadStoreImage(c *gin.Context) {
+	storeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || storeID == 0 {
+		HandleError(c, errs.ErrInvalidStoreID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	files, err := parseImageUpload(c, "image", 1)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	image, err := service.UploadStoreImage(userID, uint(storeID), files[0])
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"image": image})
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// AdjustProductStock godoc
+// @Summary Adjust product stock
+// @Description Records a stock receipt or a manual adjustment of a product. Quantity is signed, a receipt must be positive.
+// @Description Only the owner, managers and catalog editors of the product's store can adjust its stock.
+// @Tags inventory
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Param adjustment body models.StockAdjustmentRequest true "Adjustment data, kind is receipt or adjustment (default)"
+// @Success 201 {object} models.InventoryMovement "movement"
+// @Failure 400 {object} models.ErrorResponse "Invalid quantity or not enough product in stock"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /products/stock/{id}/adjustments [post]
+func AdjustProductStock(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.StockAdjustmentRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	movement, err := service.AdjustProductStock(userID, uint(productID), request)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"movement": movement})
+}
+
+// GetProductStockHistory godoc
+// @Summary Get product stock history
+// @Description Returns every stock movement of a product (receipts, sales, returns, adjustments, reservation releases), newest first.
+// @Tags inventory
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.InventoryMovement "movements"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /products/stock/{id}/history [get]
+func GetProductStockHistory(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	movements, err := service.GetProductStockHistory(userID, uint(productID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"movements": movements})
+}
+
+// GetLowStockProducts godoc
+// @Summary Get products that need restock
+// @Description Returns products of a store whose stock is at or below their low-stock threshold.
+// @Tags inventory
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Success 200 {array} models.Product "products"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store not found"
+// @Router /store/{id}/low-stock [get]
+func GetLowStockProducts(c *gin.Context) {
+	storeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || storeID == 0 {
+		HandleError(c, errs.ErrInvalidStoreID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	products, err := service.GetLowStockProducts(userID, uint(storeID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"products": products})
+}
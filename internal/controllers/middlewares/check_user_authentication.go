@@ -1,8 +1,9 @@
 package middlewares
 
 import (
+	"BizMart/internal/app/service"
+	"BizMart/pkg/errs"
 	"BizMart/pkg/utils"
-	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strings"
@@ -11,8 +12,24 @@ import (
 const (
 	authorizationHeader = "Authorization"
 	UserIDCtx           = "userID"
+	SessionIDCtx        = "sessionID"
+	RolesCtx            = "roles"
 )
 
+// parseAccessToken проверяет access-токен: подпись, срок, тип и то, что его сессия не отозвана
+func parseAccessToken(accessToken string) (*utils.CustomClaims, error) {
+	claims, err := utils.ParseAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if service.IsAccessTokenRevoked(claims) {
+		return nil, errs.ErrSessionRevoked
+	}
+
+	return claims, nil
+}
+
 func CheckUserAuthentication(c *gin.Context) {
 	header := c.GetHeader(authorizationHeader)
 
@@ -40,13 +57,30 @@ func CheckUserAuthentication(c *gin.Context) {
 
 	accessToken := headerParts[1]
 
-	claims, err := utils.ParseToken(accessToken)
+	claims, err := parseAccessToken(accessToken)
 	if err != nil {
 		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-	fmt.Println(claims)
 
 	c.Set(UserIDCtx, claims.UserID)
+	c.Set(SessionIDCtx, claims.SessionID)
+	c.Set(RolesCtx, claims.Roles)
 	c.Next()
 }
+
+// OptionalUserID возвращает ID пользователя из заголовка авторизации на публичных маршрутах
+// или 0, если заголовка нет или токен недействителен
+func OptionalUserID(c *gin.Context) uint {
+	headerParts := strings.Split(c.GetHeader(authorizationHeader), " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+		return 0
+	}
+
+	claims, err := parseAccessToken(headerParts[1])
+	if err != nil {
+		return 0
+	}
+
+	return claims.UserID
+}
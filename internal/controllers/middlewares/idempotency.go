@@ -0,0 +1,121 @@
+package middlewares
+
+import (
+	"BizMart/internal/app/service"
+	"BizMart/pkg/errs"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// responseRecorder копирует тело ответа, чтобы его можно было сохранить для повторов запроса
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency обрабатывает заголовок Idempotency-Key.
+// Первый запрос с ключом выполняется и его ответ сохраняется, повторы с тем же ключом и телом получают сохранённый ответ,
+// повторное использование ключа с другим телом запроса отклоняется. Запросы без заголовка выполняются как обычно.
+// Должен подключаться после CheckUserAuthentication, ключи хранятся отдельно для каждого пользователя.
+func Idempotency(c *gin.Context) {
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": errs.ErrInvalidIdempotencyKey.Error()})
+		return
+	}
+
+	userID := c.GetUint(UserIDCtx)
+	if userID == 0 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errs.ErrUnauthorized.Error()})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": errs.ErrInvalidData.Error()})
+		return
+	}
+
+	if len(body) > maxIdempotentRequestBytes {
+		c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": errs.ErrInvalidData.Error()})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), body)
+
+	stored, err := service.BeginIdempotentRequest(userID, key, fingerprint)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrIdempotencyKeyReused):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case errors.Is(err, errs.ErrIdempotentRequestInProgress):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": errs.ErrSomethingWentWrong.Error()})
+		}
+		return
+	}
+
+	if stored != nil {
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(stored.StatusCode, stored.ContentType, []byte(stored.ResponseBody))
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	func() {
+		stop := service.HoldIdempotentRequest(userID, key, fingerprint)
+		defer stop()
+
+		c.Next()
+	}()
+
+	// Ошибки сервера не сохраняются, чтобы клиент мог повторить запрос с тем же ключом
+	if recorder.Status() >= http.StatusInternalServerError {
+		service.ReleaseIdempotentRequest(userID, key)
+		return
+	}
+
+	service.CompleteIdempotentRequest(userID, key, fingerprint, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+}
+
+func requestFingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
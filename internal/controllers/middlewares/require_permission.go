@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"BizMart/internal/app/service"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// RequirePermission пропускает запрос, если одна из ролей токена даёт право permission.
+// Ставится после CheckUserAuthentication
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		roles, _ := c.Get(RolesCtx)
+		roleCodes, _ := roles.([]string)
+
+		if !service.RolesHavePermission(roleCodes, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": errs.ErrPermissionDenied.Error(),
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
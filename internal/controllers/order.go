@@ -96,14 +96,18 @@ func GetOrderByID(c *gin.Context) {
 // CreateOrder godoc
 // @Summary Create a new order
 // @Description Allows the authenticated user to create a new order.
+// @Description modifier_ids are the chosen product modifiers (toppings etc.), their price deltas are added to the unit price.
 // @Tags orders
 // @Security ApiKeyAuth
 // @Accept  json
 // @Produce  json
 // @Param order body models.OrderRequestJsonBind true "Order Data"
+// @Param Idempotency-Key header string false "Unique key of the request, retries with the same key replay the first response"
 // @Success 201 {object} models.DefaultResponse "Order created successfully"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
 // @Failure 400 {object} models.ErrorResponse "Validation failed"
+// @Failure 409 {object} models.ErrorResponse "Request with this idempotency key is in progress"
+// @Failure 422 {object} models.ErrorResponse "Idempotency key is reused with a different request"
 // @Router /orders [post]
 func CreateOrder(c *gin.Context) {
 	userID := c.GetUint(middlewares.UserIDCtx)
@@ -119,7 +123,6 @@ func CreateOrder(c *gin.Context) {
 	}
 
 	orderRequest.UserID = userID
-	orderRequest.StatusID = 1
 
 	if err := service.ValidateOrder(HandleError, orderRequest, c); err != nil {
 		return
@@ -143,6 +146,7 @@ func CreateOrder(c *gin.Context) {
 // @Param id path int true "Order ID"
 // @Param order body models.OrderRequestJsonBind true "Updated Order Data"
 // @Success 200 {object} models.DefaultResponse "Order updated successfully"
+// @Failure 400 {object} models.ErrorResponse "Validation failed, invalid quantity or not enough product in stock"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
 // @Failure 404 {object} models.ErrorResponse "Order not found"
 // @Router /orders/{id} [put]
@@ -198,7 +202,20 @@ func UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	orderRequest.ProductID = orderDetails.ProductID
+	if orderDetails.ProductID == nil {
+		HandleError(c, errs.ErrOrderNotEditable)
+		return
+	}
+
+	orderRequest.UserID = userID
+	orderRequest.ProductID = *orderDetails.ProductID
+	if len(order.Items) > 0 {
+		orderRequest.VariantID = order.Items[0].VariantID
+	}
+
+	if err = service.ValidateOrderUpdate(HandleError, orderRequest, c); err != nil {
+		return
+	}
 
 	if err = service.UpdateOrder(uint(orderId), orderRequest); err != nil {
 		HandleError(c, err)
@@ -246,3 +263,132 @@ func DeleteOrder(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "order deleted successfully"})
 }
+
+// CancelOrder godoc
+// @Summary Cancel an order
+// @Description Cancels an order. Unpaid orders are restocked, paid orders that are not delivered yet are fully refunded.
+// @Description The buyer can cancel own orders. Store staff who process orders can cancel unpaid orders that consist only of products of their stores
+// @Description and refund the items of their stores in paid orders, the order becomes refunded once every item is refunded.
+// @Tags orders
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param cancel body models.CancelOrderRequest false "Cancellation reason"
+// @Success 200 {object} models.DefaultResponse "Order cancelled successfully"
+// @Failure 400 {object} models.ErrorResponse "Order cannot be cancelled"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Order not found"
+// @Router /orders/{id}/cancel [post]
+func CancelOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || orderID == 0 {
+		HandleError(c, errs.ErrInvalidOrderID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.CancelOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err = c.ShouldBindJSON(&request); err != nil {
+			HandleError(c, errs.ErrValidationFailed)
+			return
+		}
+	}
+
+	refund, err := service.CancelOrder(userID, uint(orderID), request.Reason)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "order cancelled successfully",
+		"refund":  refund,
+	})
+}
+
+// ChangeOrderStatus godoc
+// @Summary Change order status
+// @Description Moves the items of an order to the next status (preparing, shipped, delivered) if the transition is allowed for the user.
+// @Description Store staff who process orders prepare and ship the items of their stores, buyers confirm delivery of all items or of the items of one store.
+// @Description The order itself moves on once the items of every store reached the status.
+// @Tags orders
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Param status body models.OrderStatusChangeRequest true "New status code and reason"
+// @Success 200 {object} models.DefaultResponse "Order status changed successfully"
+// @Failure 400 {object} models.ErrorResponse "Transition is not allowed"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Order not found"
+// @Router /orders/{id}/status [put]
+func ChangeOrderStatus(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || orderID == 0 {
+		HandleError(c, errs.ErrInvalidOrderID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.OrderStatusChangeRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err = service.ChangeOrderStatus(userID, uint(orderID), request); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "order status changed successfully"})
+}
+
+// GetOrderStatusHistory godoc
+// @Summary Get order status history
+// @Description Returns every status transition of an order with who made it, when and why.
+// @Tags orders
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Order ID"
+// @Success 200 {array} models.OrderStatusHistory "history"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Order not found"
+// @Router /orders/{id}/history [get]
+func GetOrderStatusHistory(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || orderID == 0 {
+		HandleError(c, errs.ErrInvalidOrderID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	history, err := service.GetOrderStatusHistory(userID, uint(orderID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
@@ -103,42 +103,9 @@ func GetOrderStatusByName(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": orderStatus})
 }
 
-// CreateOrderStatus godoc
-// @Summary Create a new order status
-// @Description Creates a new order status.
-// @Tags order status
-// @Security ApiKeyAuth
-// @Accept  json
-// @Produce  json
-// @Param order_status body models.OrderStatusRequest true "Order Status data"
-// @Success 201 {object} models.DefaultResponse "Order status created successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid input"
-// @Failure 500 {object} models.ErrorResponse
-// @Router /order/status [post]
-func CreateOrderStatus(c *gin.Context) {
-	var orderStatus models.OrderStatus
-	// Проверяем корректность входных данных
-	if err := c.ShouldBindJSON(&orderStatus); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": errs.ErrInvalidData.Error()})
-		return
-	}
-
-	// Создаем новый статус заказа
-	orderStatusID, err := service.CreateOrderStatus(orderStatus)
-	if err != nil {
-		HandleError(c, err)
-		return
-	}
-
-	logger.Info.Printf("[controllers.CreateOrderStatus] successfully created new order status with ID %d", orderStatusID)
-
-	// Возвращаем успешный результат
-	c.JSON(http.StatusCreated, gin.H{"message": "Order status created successfully"})
-}
-
 // UpdateOrderStatus godoc
 // @Summary Update an existing order status
-// @Description Updates the details of an existing order status.
+// @Description Updates the name and description of an existing order status. Status codes and transitions are fixed.
 // @Tags order status
 // @Security ApiKeyAuth
 // @Accept  json
@@ -181,47 +148,3 @@ func UpdateOrderStatus(c *gin.Context) {
 		"message": "Order Status updated",
 	})
 }
-
-// DeleteOrderStatus godoc
-// @Summary Delete an order status
-// @Description Deletes a specific order status by its ID.
-// @Tags order status
-// @Security ApiKeyAuth
-// @Accept  json
-// @Produce  json
-// @Param id path int true "Order Status ID"
-// @Success 200 {object} models.DefaultResponse "Order status deleted successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid ID"
-// @Failure 404 {object} models.ErrorResponse "Order status not found"
-// @Failure 500 {object} models.ErrorResponse
-// @Router /order/status/{id} [delete]
-func DeleteOrderStatus(c *gin.Context) {
-	// Преобразование параметра ID в число
-	orderStatusID, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errs.ErrInvalidID, // Точная ошибка
-		})
-		return
-	}
-
-	// Проверяем наличие статуса заказа
-	orderStatus, err := repository.GetOrderStatusByID(uint(orderStatusID))
-	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			HandleError(c, errs.ErrOrderStatusNotFound)
-			return
-		}
-		HandleError(c, err)
-		return
-	}
-
-	// Удаляем статус заказа
-	if err := repository.DeleteOrderStatus(orderStatus); err != nil {
-		HandleError(c, err)
-		return
-	}
-
-	// Возвращаем успешный результат
-	c.JSON(http.StatusOK, gin.H{"message": "Order status deleted successfully"})
-}
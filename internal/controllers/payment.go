@@ -88,15 +88,19 @@ func GetPaymentByID(c *gin.Context) {
 
 // CreatePayment godoc
 // @Summary Create a new payment
-// @Description Create a new payment for the authenticated user
+// @Description Create a new payment for the authenticated user. The user must have a verified email.
 // @Tags Payments
 // @Accept  json
 // @Produce  json
 // @Param payment body models.Payment true "Payment Data"
+// @Param Idempotency-Key header string false "Unique key of the request, retries with the same key replay the first response"
 // @Success 201 {object} models.DefaultResponse "Payment Created Successfully"
 // @Failure 400 {object} models.ErrorResponse "Validation Failed"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Email is not verified"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
+// @Failure 409 {object} models.ErrorResponse "Request with this idempotency key is in progress"
+// @Failure 422 {object} models.ErrorResponse "Idempotency key is reused with a different request"
 // @Router /payments [post]
 // @Security ApiKeyAuth
 func CreatePayment(c *gin.Context) {
@@ -126,30 +130,42 @@ func CreatePayment(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"message": "payment created successfully"})
 }
 
-// UpdatePayment godoc
-// @Summary Update payment by ID
-// @Description Update an existing payment by its ID for the authenticated user
+// PaymentChangeRemoved godoc
+// @Summary Removed: update or delete a payment
+// @Description Payments can no longer be changed or deleted, because they are backed by ledger postings.
+// @Description These endpoints always answer 410 Gone. Use POST /payments/{id}/refunds or POST /orders/{id}/cancel instead.
+// @Tags Payments
+// @Produce  json
+// @Param id path string true "Payment ID"
+// @Failure 410 {object} models.ErrorResponse "Payments can't be changed, use refunds"
+// @Router /payments/{id} [put]
+// @Router /payments/{id} [delete]
+// @Deprecated
+// @Security ApiKeyAuth
+func PaymentChangeRemoved(c *gin.Context) {
+	HandleError(c, errs.ErrPaymentImmutable)
+}
+
+// RefundPayment godoc
+// @Summary Refund a payment
+// @Description Makes a full or partial refund of a payment. Only the seller of the refunded items can refund them.
+// @Description An empty items list refunds every remaining item of the seller's stores.
 // @Tags Payments
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Payment ID"
-// @Param payment body models.PaymentRequest true "Payment Data"
-// @Success 200 {object} models.DefaultResponse "Payment Updated Successfully"
-// @Failure 400 {object} models.ErrorResponse "Invalid Payment ID or Validation Failed"
+// @Param refund body models.RefundRequest true "Refund Data"
+// @Success 201 {object} models.Refund "refund"
+// @Failure 400 {object} models.ErrorResponse "Validation Failed"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission Denied"
 // @Failure 404 {object} models.ErrorResponse "Payment Not Found"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
-// @Router /payments/{id} [put]
+// @Router /payments/{id}/refunds [post]
 // @Security ApiKeyAuth
-func UpdatePayment(c *gin.Context) {
-	paymentStrID := c.Param("id")
-	if paymentStrID == "" {
-		HandleError(c, errs.ErrInvalidPaymentID)
-		return
-	}
-
-	paymentID, err := strconv.ParseUint(paymentStrID, 10, 64)
-	if err != nil {
+func RefundPayment(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || paymentID == 0 {
 		HandleError(c, errs.ErrInvalidPaymentID)
 		return
 	}
@@ -160,69 +176,39 @@ func UpdatePayment(c *gin.Context) {
 		return
 	}
 
-	paymentData, err := repository.GetPaymentByID(uint(paymentID))
-	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			HandleError(c, errs.ErrPaymentNotFound)
-			return
-		}
-		HandleError(c, err)
-		return
-	}
-
-	if userID != paymentData.UserID {
-		HandleError(c, errs.ErrPermissionDenied)
-		return
-	}
-
-	var payment models.Payment
-	if err := c.ShouldBind(&payment); err != nil {
+	var request models.RefundRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
 		HandleError(c, errs.ErrValidationFailed)
 		return
 	}
 
-	payment.UserID = userID
-	payment.ID = uint(paymentID)
-
-	if err = service.ValidatePayment(HandleError, &payment, c); err != nil {
-		return
-	}
-
-	if err = repository.UpdatePayment(payment); err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			HandleError(c, errs.ErrPaymentNotFound)
-			return
-		}
+	refund, err := service.RefundPayment(userID, uint(paymentID), request)
+	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "payment updated successfully"})
+	c.JSON(http.StatusCreated, gin.H{"refund": refund})
 }
 
-// DeletePayment godoc
-// @Summary Delete payment by ID
-// @Description Delete a payment by its ID for the authenticated user
+// GetPaymentRefunds godoc
+// @Summary Get payment refunds
+// @Description Get all refunds of a payment. Available to the buyer and to the sellers of the order.
 // @Tags Payments
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Payment ID"
-// @Success 200 {object} models.DefaultResponse "Payment Deleted Successfully"
+// @Success 200 {object} models.Refund "refunds"
 // @Failure 400 {object} models.ErrorResponse "Invalid Payment ID"
 // @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission Denied"
 // @Failure 404 {object} models.ErrorResponse "Payment Not Found"
 // @Failure 500 {object} models.ErrorResponse "Internal Server Error"
-// @Router /payments/{id} [delete]
+// @Router /payments/{id}/refunds [get]
 // @Security ApiKeyAuth
-func DeletePayment(c *gin.Context) {
-	paymentStrID := c.Param("id")
-	if paymentStrID == "" {
-		HandleError(c, errs.ErrInvalidPaymentID)
-		return
-	}
-
-	paymentID, err := strconv.ParseUint(paymentStrID, 10, 64)
-	if err != nil {
+func GetPaymentRefunds(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || paymentID == 0 {
 		HandleError(c, errs.ErrInvalidPaymentID)
 		return
 	}
@@ -233,25 +219,11 @@ func DeletePayment(c *gin.Context) {
 		return
 	}
 
-	paymentData, err := repository.GetPaymentByID(uint(paymentID))
+	refunds, err := service.GetPaymentRefunds(userID, uint(paymentID))
 	if err != nil {
-		if errors.Is(err, errs.ErrRecordNotFound) {
-			HandleError(c, errs.ErrPaymentNotFound)
-			return
-		}
-		HandleError(c, err)
-		return
-	}
-
-	if userID != paymentData.UserID {
-		HandleError(c, errs.ErrPermissionDenied)
-		return
-	}
-
-	if err = repository.DeletePayment(paymentData); err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "payment deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
 }
@@ -4,11 +4,12 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/internal/app/service"
 	"BizMart/internal/controllers/middlewares"
-	"BizMart/internal/jobs"
 	"BizMart/internal/repository"
 	"BizMart/pkg/db"
 	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -16,16 +17,24 @@ import (
 
 // GetAllProducts godoc
 // @Summary Get all products
-// @Description Fetches all products with optional filtering by price, category, product name, and store.
+// @Description Fetches all products with optional filtering by price, category, product name, store and variant options.
+// @Description Pages are cached for up to 5 minutes and dropped when products of the listed store or category change,
+// @Description so stock and view counters in the list may lag behind the product page.
 // @Tags products
 // @Accept  json
 // @Produce  json
 // @Param min_price query number false "Minimum price filter"
 // @Param max_price query number false "Maximum price filter"
 // @Param category query int false "Category ID"
-// @Param product_name query string false "Product name"
+// @Param product_name query string false "Product name, matched with full-text search and typo tolerance (see /product/search)"
 // @Param store query int false "Store ID"
-// @Success 200 {object} models.ProductResponse "Returns a list of products"
+// @Param option query object false "Variant option filters, e.g. option[size]=42&option[color]=black"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param sort query string false "popularity (default), relevance (with product_name, then default), price, views, title or id"
+// @Param order query string false "asc or desc, desc by default"
+// @Param fields query string false "Comma-separated product fields to return, e.g. id,title,price"
+// @Success 200 {object} pagination.Page[models.Product] "Returns a page of products"
 // @Failure 400 {object} models.ErrorResponse
 // @Router /product [get]
 func GetAllProducts(c *gin.Context) {
@@ -34,19 +43,15 @@ func GetAllProducts(c *gin.Context) {
 	category := c.Query("category")
 	productName := c.Query("product_name")
 	store := c.Query("store")
+	options := c.QueryMap("option")
 
-	if minPriceStr == "" && maxPriceStr == "" && category == "" && store == "" && productName == "" {
-		products, err := jobs.GetCachedProducts()
-		if err != nil {
-			HandleError(c, err)
-		}
-
-		c.JSON(http.StatusOK, gin.H{"products": products})
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		HandleError(c, err)
 		return
 	}
 
 	var minPrice, maxPrice float64
-	var err error
 
 	if minPriceStr != "" {
 		minPrice, err = strconv.ParseFloat(minPriceStr, 64)
@@ -89,25 +94,33 @@ func GetAllProducts(c *gin.Context) {
 		}
 	}
 
-	var products []models.Product
-
-	products, err = repository.GetAllProducts(minPrice, maxPrice, uint(categoryId), productName, uint(storeId))
+	products, err := service.GetProducts(models.ProductFilter{
+		MinPrice:    minPrice,
+		MaxPrice:    maxPrice,
+		CategoryID:  uint(categoryId),
+		StoreID:     uint(storeId),
+		ProductName: productName,
+		Options:     options,
+	}, params)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	if len(products) == 0 {
+	if len(products.Items) == 0 && params.Cursor == "" {
 		HandleError(c, errs.WarningNoProductsFound)
 		return
 	}
 
-	c.JSON(200, gin.H{"products": products})
+	c.JSON(http.StatusOK, products)
 }
 
 // GetProductByID godoc
 // @Summary Get product by ID
 // @Description Retrieves a product by its ID along with the number of orders.
+// @Description Opening the page records a view, repeated views by the same visitor within 30 minutes are counted once
+// @Description and view counters are updated in the background, so views lag behind by up to a minute.
+// @Description The product is cached and dropped from the cache whenever it, its variants or its modifiers change.
 // @Tags products
 // @Accept  json
 // @Produce  json
@@ -123,12 +136,15 @@ func GetProductByID(c *gin.Context) {
 		return
 	}
 
-	getProductByID, err := repository.GetProductByID(uint(productId))
+	getProductByID, err := service.GetProduct(uint(productId))
 	if err != nil {
 		c.JSON(404, gin.H{"message": errs.ErrNoProductFound.Error()})
 		return
 	}
 
+	visitorID := service.ProductVisitorID(middlewares.OptionalUserID(c), c.ClientIP(), c.Request.UserAgent())
+	service.RecordProductView(getProductByID.ID, visitorID)
+
 	ordersNum, err := repository.GetNumberOfProductOrders(uint(productId))
 	if err != nil {
 		HandleError(c, err)
@@ -142,7 +158,8 @@ func GetProductByID(c *gin.Context) {
 
 // CreateProduct godoc
 // @Summary Create a new product
-// @Description Adds a new product to a specific store, with validation and ownership checks.
+// @Description Adds a new product to a specific store, with validation and a check that the user may manage the store's catalog.
+// @Description The product is matched to a canonical product for price comparison by canonical_product_id, GTIN or title.
 // @Tags products
 // @Security ApiKeyAuth
 // @Accept  json
@@ -187,9 +204,18 @@ func CreateProduct(c *gin.Context) {
 		return
 	}
 
-	// Проверка прав на создание продукта
-	if userID != productData.Store.OwnerID {
-		HandleError(c, errs.ErrStoreNotFound)
+	// Проверка прав на создание продукта: чужой магазин не отличается от несуществующего
+	if err = service.CheckStorePermission(userID, productData.Store, models.StorePermissionManageCatalog); err != nil {
+		if errors.Is(err, errs.ErrPermissionDenied) {
+			err = errs.ErrStoreNotFound
+		}
+		HandleError(c, err)
+		return
+	}
+
+	// Привязываем товар к каноническому товару для сравнения цен между магазинами
+	if err = service.MatchProduct(&productData); err != nil {
+		HandleError(c, err)
 		return
 	}
 
@@ -203,7 +229,7 @@ func CreateProduct(c *gin.Context) {
 	}
 
 	// Сохраняем продукт и изображения
-	if err := repository.CreateProductWithImages(&productData, images); err != nil {
+	if err := repository.CreateProductWithImages(&productData, images, userID); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -217,6 +243,8 @@ func CreateProduct(c *gin.Context) {
 // UpdateProduct godoc
 // @Summary Update an existing product
 // @Description Updates the details of a product including title, description, price, and images.
+// @Description A changed amount is recorded in the stock history as a manual adjustment.
+// @Description Files of uploaded images that are removed from the product are deleted from the storage.
 // @Tags products
 // @Security ApiKeyAuth
 // @Accept  json
@@ -262,18 +290,25 @@ func UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	// Проверяем, является ли пользователь владельцем продукта
-	if userID != productData.Store.OwnerID {
-		HandleError(c, errs.ErrPermissionDenied)
+	// Проверяем, может ли пользователь редактировать товары магазина
+	if err = service.CheckStorePermission(userID, productData.Store, models.StorePermissionManageCatalog); err != nil {
+		HandleError(c, err)
 		return
 	}
 
 	// Обновляем данные продукта
 	productData.Title = updatedProductData.Title
 	productData.Description = updatedProductData.Description
-	productData.Price = updatedProductData.Price
-	productData.Amount = updatedProductData.Amount
+	productData.LowStockThreshold = updatedProductData.LowStockThreshold
 	productData.CategoryID = updatedProductData.CategoryID
+	productData.GTIN = updatedProductData.GTIN
+	productData.CanonicalProductID = updatedProductData.CanonicalProductID
+
+	// Название или штрихкод могли измениться, поэтому товар сопоставляется заново
+	if err = service.MatchProduct(&productData); err != nil {
+		HandleError(c, err)
+		return
+	}
 
 	// Обновляем Store только в случае необходимости, если это допускается
 
@@ -287,7 +322,17 @@ func UpdateProduct(c *gin.Context) {
 	}
 
 	// Сохраняем изменения в базе данных
-	if err := repository.UpdateProductWithImages(&productData, updatedImages); err != nil {
+	removedImages, err := repository.UpdateProductWithImages(&productData, updatedImages)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	// Файлы убранных изображений удаляются, если они загружены в наше хранилище и больше нигде не используются
+	service.DeleteOrphanedImages(removedImages)
+
+	// Цена и остаток хранятся в варианте товара, изменение остатка записывается в историю движений как корректировка
+	if err := service.SetProductPriceAndStock(userID, productData.ID, updatedProductData.Price, updatedProductData.Amount, "product updated"); err != nil {
 		HandleError(c, err)
 		return
 	}
@@ -340,8 +385,8 @@ func DeleteProduct(c *gin.Context) {
 	}
 
 	// Проверяем права пользователя на удаление продукта
-	if store.OwnerID != userID {
-		HandleError(c, errs.ErrPermissionDenied)
+	if err = service.CheckStorePermission(userID, store, models.StorePermissionManageCatalog); err != nil {
+		HandleError(c, err)
 		return
 	}
 
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"BizMart/internal/app/service"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// SearchCanonicalProducts godoc
+// @Summary Find a product to compare prices
+// @Description Finds products by name (typos are tolerated) or barcode that are sold by at least one store,
+// @Description with the lowest price and the number of offers. Use the ID to get every store offer.
+// @Tags comparison
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Product name or GTIN"
+// @Success 200 {array} models.CanonicalProductSummary "products"
+// @Failure 400 {object} models.ErrorResponse "Invalid search query"
+// @Router /products/compare [get]
+func SearchCanonicalProducts(c *gin.Context) {
+	products, err := service.SearchCanonicalProducts(c.Query("q"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"products": products})
+}
+
+// GetProductOffers godoc
+// @Summary Compare store offers of a product
+// @Description Returns a canonical product with the offers of all stores that sell it. Listings are matched
+// @Description by barcode (GTIN), normalized title or the canonical product declared by the seller.
+// @Description sort=price (default) puts in-stock offers first by price, sort=rating — by store rating,
+// @Description sort=availability — by amount in stock.
+// @Tags comparison
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Canonical product ID"
+// @Param sort query string false "price, rating or availability"
+// @Success 200 {object} models.CanonicalProductOffers "comparison"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID or sort"
+// @Failure 404 {object} models.ErrorResponse "Canonical product not found"
+// @Router /products/compare/{id} [get]
+func GetProductOffers(c *gin.Context) {
+	canonicalID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || canonicalID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	comparison, err := service.GetProductOffers(uint(canonicalID), c.Query("sort"))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"comparison": comparison})
+}
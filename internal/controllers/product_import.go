@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/spreadsheet"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ImportStoreProducts godoc
+// @Summary Import store products
+// @Description Uploads a CSV or XLSX file with products of the store. The first row must contain the columns
+// @Description sku, title, description, category_id, price and amount; low_stock_threshold, gtin and images (URLs separated by "|") are optional.
+// @Description Rows with a new SKU create products, rows with an existing SKU of the store update the product and its variant.
+// @Description The file is processed in the background, use the returned import ID to track progress. Only the owner, managers and catalog editors of the store can import products.
+// @Tags stores
+// @Security ApiKeyAuth
+// @Accept  multipart/form-data
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Param file formData file true "CSV or XLSX file"
+// @Success 202 {object} models.ProductImport "import"
+// @Failure 400 {object} models.ErrorResponse "Invalid file or too many rows"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store not found"
+// @Failure 413 {object} models.ErrorResponse "File is too large"
+// @Router /store/{id}/products/import [post]
+func ImportStoreProducts(c *gin.Context) {
+	storeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || storeID == 0 {
+		HandleError(c, errs.ErrInvalidStoreID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxImportFileSize+multipartOverhead)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			HandleError(c, errs.ErrImportFileTooLarge)
+			return
+		}
+		HandleError(c, errs.ErrInvalidImportFile)
+		return
+	}
+
+	if file.Size > service.MaxImportFileSize {
+		HandleError(c, errs.ErrImportFileTooLarge)
+		return
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		HandleError(c, errs.ErrInvalidImportFile)
+		return
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, service.MaxImportFileSize+1))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	if len(data) > service.MaxImportFileSize {
+		HandleError(c, errs.ErrImportFileTooLarge)
+		return
+	}
+
+	productImport, err := service.StartProductImport(userID, uint(storeID), filepath.Base(file.Filename), data)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"import": productImport})
+}
+
+// ExportStoreProducts godoc
+// @Summary Export store products
+// @Description Downloads the store catalog in the import format, one row per product variant, so the file can be edited and imported back.
+// @Description Only the owner, managers and catalog editors of the store can export products.
+// @Tags stores
+// @Security ApiKeyAuth
+// @Produce  text/csv
+// @Produce  application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param id path int true "Store ID"
+// @Param format query string false "File format: csv (default) or xlsx"
+// @Success 200 {file} file "Catalog file"
+// @Failure 400 {object} models.ErrorResponse "Invalid format"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store not found"
+// @Router /store/{id}/products/export [get]
+func ExportStoreProducts(c *gin.Context) {
+	storeID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || storeID == 0 {
+		HandleError(c, errs.ErrInvalidStoreID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", spreadsheet.FormatCSV))
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		HandleError(c, errs.ErrInvalidExportFormat)
+		return
+	}
+
+	rows, err := service.ExportStoreCatalog(userID, uint(storeID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	var buffer bytes.Buffer
+	if err = spreadsheet.Write(&buffer, format, rows); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="store-%d-products.%s"`, storeID, format))
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), buffer.Bytes())
+}
+
+// GetProductImport godoc
+// @Summary Get product import
+// @Description Returns the status and progress of a product import with the errors of rejected rows.
+// @Description Only the owner, managers and catalog editors of the store can view its imports.
+// @Tags stores
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Import ID"
+// @Success 200 {object} models.ProductImport "import"
+// @Failure 400 {object} models.ErrorResponse "Invalid import ID"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Import not found"
+// @Router /products/imports/{id} [get]
+func GetProductImport(c *gin.Context) {
+	importID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || importID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	productImport, err := service.GetProductImport(userID, uint(importID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"import": productImport})
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetProductModifierGroups godoc
+// @Summary Get product modifier groups
+// @Description Returns the modifier groups of a product (e.g. toppings, removable ingredients) with their modifiers and price deltas.
+// @Tags modifiers
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ModifierGroup "modifier_groups"
+// @Failure 400 {object} models.ErrorResponse "Invalid product ID"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /products/modifiers/{id} [get]
+func GetProductModifierGroups(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	groups, err := service.GetProductModifierGroups(uint(productID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"modifier_groups": groups})
+}
+
+// CreateModifierGroup godoc
+// @Summary Create modifier group
+// @Description Adds a modifier group to a product. A required group needs at least one selection,
+// @Description min_selections and max_selections (0 — no limit) bound the number of chosen modifiers.
+// @Tags modifiers
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Param group body models.ModifierGroupRequest true "Modifier group data"
+// @Success 201 {object} models.ModifierGroup "modifier_group"
+// @Failure 400 {object} models.ErrorResponse "Invalid modifier group"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /products/modifiers/{id} [post]
+func CreateModifierGroup(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.ModifierGroupRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	group, err := service.CreateModifierGroup(userID, uint(productID), request)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"modifier_group": group})
+}
+
+// UpdateModifierGroup godoc
+// @Summary Update modifier group
+// @Description Updates a modifier group. Modifiers with an ID are updated, modifiers without an ID are added
+// @Description and modifiers missing from the request are deleted.
+// @Tags modifiers
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Modifier group ID"
+// @Param group body models.ModifierGroupRequest true "Modifier group data"
+// @Success 200 {object} models.ModifierGroup "modifier_group"
+// @Failure 400 {object} models.ErrorResponse "Invalid modifier group"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Modifier group not found"
+// @Router /products/modifiers/{id} [put]
+func UpdateModifierGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || groupID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.ModifierGroupRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	group, err := service.UpdateModifierGroup(userID, uint(groupID), request)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"modifier_group": group})
+}
+
+// DeleteModifierGroup godoc
+// @Summary Delete modifier group
+// @Description Deletes a modifier group with its modifiers. Placed orders keep the modifiers chosen at order time.
+// @Tags modifiers
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Modifier group ID"
+// @Success 200 {object} models.DefaultResponse "Modifier group deleted"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Modifier group not found"
+// @Router /products/modifiers/{id} [delete]
+func DeleteModifierGroup(c *gin.Context) {
+	groupID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || groupID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err = service.DeleteModifierGroup(userID, uint(groupID)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "modifier group deleted successfully"})
+}
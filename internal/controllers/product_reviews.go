@@ -6,6 +6,7 @@ import (
 	"BizMart/internal/controllers/middlewares"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/pagination"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -18,9 +19,14 @@ import (
 // @Tags         product_reviews
 // @Accept       json
 // @Produce      json
-// @Param        id   path      int  true  "Product ID"
-// @Success      200  {object}  models.Review
-// @Failure      401  {object}  models.ErrorResponse
+// @Param        id      path      int     true   "Product ID"
+// @Param        limit   query     int     false  "Page size, 20 by default, at most 100"
+// @Param        cursor  query     string  false  "Opaque cursor from next_cursor of the previous page"
+// @Param        sort    query     string  false  "created_at (default) or rating"
+// @Param        order   query     string  false  "asc or desc, desc by default"
+// @Param        fields  query     string  false  "Comma-separated review fields to return"
+// @Success      200  {object}  pagination.Page[models.Review]
+// @Failure      400  {object}  models.ErrorResponse
 // @Failure      500  {object}  models.ErrorResponse
 // @Router       /products/reviews/{id} [get]
 func GetAllProductReviews(c *gin.Context) {
@@ -31,13 +37,19 @@ func GetAllProductReviews(c *gin.Context) {
 		return
 	}
 
-	reviews, err := repository.GetAllProductReviews(uint(productId))
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	reviews, err := repository.GetAllProductReviews(uint(productId), params)
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"product_reviews": reviews})
+	c.JSON(http.StatusOK, reviews)
 }
 
 // GetProductReviewByID godoc
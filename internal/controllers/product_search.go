@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Full-text search over product titles and descriptions in Russian and English with typo tolerance.
+// @Description Results are ordered by relevance and contain highlighted snippets, the response also has facet counts
+// @Description by category, store and price range. Each facet ignores its own filter.
+// @Tags products
+// @Accept  json
+// @Produce  json
+// @Param q query string true "Search query"
+// @Param category query int false "Category ID, products of its subcategories are included"
+// @Param store query int false "Store ID"
+// @Param min_price query number false "Minimum price filter"
+// @Param max_price query number false "Maximum price filter"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} models.ProductSearchResult "result"
+// @Failure 400 {object} models.ErrorResponse "Invalid search query or filters"
+// @Router /product/search [get]
+func SearchProducts(c *gin.Context) {
+	params := models.ProductSearchParams{Query: c.Query("q")}
+
+	var err error
+	if value := c.Query("category"); value != "" {
+		var categoryID uint64
+		if categoryID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			HandleError(c, errs.ErrInvalidCategory)
+			return
+		}
+		params.CategoryID = uint(categoryID)
+	}
+
+	if value := c.Query("store"); value != "" {
+		var storeID uint64
+		if storeID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			HandleError(c, errs.ErrInvalidStoreID)
+			return
+		}
+		params.StoreID = uint(storeID)
+	}
+
+	if value := c.Query("min_price"); value != "" {
+		if params.MinPrice, err = strconv.ParseFloat(value, 64); err != nil {
+			HandleError(c, errs.ErrInvalidMinPrice)
+			return
+		}
+	}
+
+	if value := c.Query("max_price"); value != "" {
+		if params.MaxPrice, err = strconv.ParseFloat(value, 64); err != nil {
+			HandleError(c, errs.ErrInvalidMaxPrice)
+			return
+		}
+	}
+
+	if params.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0")); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if params.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	result, err := service.SearchProducts(params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
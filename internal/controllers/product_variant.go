@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetProductVariants godoc
+// @Summary Get product variants
+// @Description Returns every variant of a product with its SKU, price, stock, images and options.
+// @Tags variants
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Success 200 {array} models.ProductVariant "variants"
+// @Failure 400 {object} models.ErrorResponse "Invalid product ID"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /products/variants/{id} [get]
+func GetProductVariants(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	variants, err := service.GetProductVariants(uint(productID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"variants": variants})
+}
+
+// CreateProductVariant godoc
+// @Summary Create product variant
+// @Description Adds a variant (e.g. size and color) with its own SKU, price, stock and images to a product.
+// @Description Only the owner, managers and catalog editors of the product's store can add variants, the initial stock is recorded as a receipt.
+// @Tags variants
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Product ID"
+// @Param variant body models.VariantRequest true "Variant data"
+// @Success 201 {object} models.ProductVariant "variant"
+// @Failure 400 {object} models.ErrorResponse "Invalid SKU, price or options"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Product not found"
+// @Router /products/variants/{id} [post]
+func CreateProductVariant(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || productID == 0 {
+		HandleError(c, errs.ErrInvalidProductID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.VariantRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	variant, err := service.CreateVariant(userID, uint(productID), request)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"variant": variant})
+}
+
+// UpdateProductVariant godoc
+// @Summary Update product variant
+// @Description Changes the SKU, title, price, images and options of a variant. Stock is changed through stock adjustments.
+// @Tags variants
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Variant ID"
+// @Param variant body models.VariantRequest true "Variant data"
+// @Success 200 {object} models.ProductVariant "variant"
+// @Failure 400 {object} models.ErrorResponse "Invalid SKU, price or options"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Variant not found"
+// @Router /products/variants/{id} [put]
+func UpdateProductVariant(c *gin.Context) {
+	variantID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || variantID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	var request models.VariantRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	variant, err := service.UpdateVariant(userID, uint(variantID), request)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"variant": variant})
+}
+
+// DeleteProductVariant godoc
+// @Summary Delete product variant
+// @Description Deletes a variant, its remaining stock is written off as an adjustment. The last variant of a product can't be deleted.
+// @Tags variants
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Variant ID"
+// @Success 200 {object} models.DefaultResponse "Variant deleted"
+// @Failure 400 {object} models.ErrorResponse "Cannot delete the last variant"
+// @Failure 401 {object} models.ErrorResponse "Unauthorized"
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Variant not found"
+// @Router /products/variants/{id} [delete]
+func DeleteProductVariant(c *gin.Context) {
+	variantID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || variantID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	userID := c.GetUint(middlewares.UserIDCtx)
+	if userID == 0 {
+		HandleError(c, errs.ErrUnauthorized)
+		return
+	}
+
+	if err = service.DeleteVariant(userID, uint(variantID)); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "variant deleted successfully"})
+}
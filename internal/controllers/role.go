@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/pagination"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// GetRoles godoc
+// @Summary Get roles
+// @Description Returns all roles with their permissions.
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {array} models.Role
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /admin/roles [get]
+func GetRoles(c *gin.Context) {
+	roles, err := service.GetRoles()
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// GetUserRoles godoc
+// @Summary Get roles of a user
+// @Description Returns the roles granted to a user with their permissions.
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Success 200 {array} models.UserRole
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "User not found"
+// @Router /admin/users/{id}/roles [get]
+func GetUserRoles(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || userID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	roles, err := service.GetUserRoles(uint(userID))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"roles": roles})
+}
+
+// GrantUserRole godoc
+// @Summary Grant a role
+// @Description Grants a role to a user and records the grant in the role audit trail.
+// @Description The role is added to the user's tokens when their session is refreshed.
+// @Tags roles
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param request body models.RoleGrantRequest true "Role code and reason"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "User or role not found"
+// @Router /admin/users/{id}/roles [post]
+func GrantUserRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || userID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	var request models.RoleGrantRequest
+	if err = c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err = service.GrantRole(c.GetUint(middlewares.UserIDCtx), uint(userID), request.Role, request.Reason); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role granted successfully"})
+}
+
+// RevokeUserRole godoc
+// @Summary Revoke a role
+// @Description Revokes a role from a user and records the revocation in the role audit trail.
+// @Description Access tokens issued to the user before the revocation stop working. An admin can't revoke their own platform_admin role.
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "User ID"
+// @Param role path string true "Role code"
+// @Param reason query string false "Reason of the revocation"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Role not found"
+// @Router /admin/users/{id}/roles/{role} [delete]
+func RevokeUserRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || userID == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return
+	}
+
+	if err = service.RevokeRole(c.GetUint(middlewares.UserIDCtx), uint(userID), c.Param("role"), c.Query("reason")); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role revoked successfully"})
+}
+
+// GetRoleAudit godoc
+// @Summary Get role audit trail
+// @Description Returns a page of role grants and revocations, newest first.
+// @Tags roles
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param user_id query int false "Only records of this user"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param order query string false "asc or desc, desc by default"
+// @Success 200 {object} pagination.Page[models.RoleAudit]
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Router /admin/roles/audit [get]
+func GetRoleAudit(c *gin.Context) {
+	var userID uint64
+	if value := c.Query("user_id"); value != "" {
+		var err error
+		if userID, err = strconv.ParseUint(value, 10, 64); err != nil || userID == 0 {
+			HandleError(c, errs.ErrInvalidID)
+			return
+		}
+	}
+
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	audit, err := service.GetRoleAudit(uint(userID), params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, audit)
+}
@@ -6,6 +6,7 @@ import (
 	"BizMart/internal/controllers/middlewares"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/pagination"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -14,20 +15,32 @@ import (
 
 // GetStores godoc
 // @Summary Get all stores
-// @Description Fetches all available stores.
+// @Description Fetches a page of available stores.
 // @Tags stores
 // @Accept  json
 // @Produce  json
-// @Success 200 {object} models.DefaultResponse "Returns a list of stores"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param sort query string false "id (default), name or created_at"
+// @Param order query string false "asc or desc, asc by default"
+// @Param fields query string false "Comma-separated store fields to return"
+// @Success 200 {object} pagination.Page[models.Store] "Returns a page of stores"
 // @Failure 400 {object} models.ErrorResponse
 // @Router /store [get]
 func GetStores(c *gin.Context) {
-	stores, err := repository.GetStores()
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
 	if err != nil {
 		HandleError(c, err)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"stores": stores})
+	stores, err := repository.GetStores(params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stores)
 }
 
 // GetStoreByID godoc
@@ -81,7 +94,7 @@ func GetStoreByID(c *gin.Context) {
 
 // CreateStore godoc
 // @Summary Create a new store
-// @Description Creates a new store for the current user.
+// @Description Creates a new store for the current user. The user must have a verified email.
 // @Tags stores
 // @Security ApiKeyAuth
 // @Accept  json
@@ -89,7 +102,7 @@ func GetStoreByID(c *gin.Context) {
 // @Param store body models.StoreRequest true "Store data"
 // @Success 200 {object} models.DefaultResponse "Returns success message"
 // @Failure 400 {object} models.ErrorResponse
-// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 403 {object} models.ErrorResponse "Email is not verified"
 // @Router /store [post]
 func CreateStore(c *gin.Context) {
 	var OurStore models.Store
@@ -111,6 +124,8 @@ func CreateStore(c *gin.Context) {
 	}
 
 	OurStore.OwnerID = userID
+	// Изображение магазина загружается отдельным запросом
+	OurStore.ImageURL = ""
 
 	if err := service.CreateStore(OurStore); err != nil {
 		HandleError(c, err)
@@ -157,12 +172,15 @@ func UpdateStore(c *gin.Context) {
 
 	userID := c.GetUint(middlewares.UserIDCtx)
 
-	if userID != store.OwnerID {
-		HandleError(c, errs.ErrPermissionDenied)
+	if err = service.CheckStorePermission(userID, store, models.StorePermissionManageStore); err != nil {
+		HandleError(c, err)
 		return
 	}
 
 	OurStore.ID = uint(storeID)
+	OurStore.ImageURL = ""
+	// Сотрудники не могут сменить владельца магазина
+	OurStore.OwnerID = 0
 	err = repository.UpdateStore(uint(storeID), &OurStore)
 	if err != nil {
 		HandleError(c, err)
@@ -200,8 +218,8 @@ func DeleteStore(c *gin.Context) {
 
 	userID := c.GetUint(middlewares.UserIDCtx)
 
-	if userID != store.OwnerID {
-		HandleError(c, errs.ErrPermissionDenied)
+	if err = service.CheckStorePermission(userID, store, models.StorePermissionDeleteStore); err != nil {
+		HandleError(c, err)
 		return
 	}
 
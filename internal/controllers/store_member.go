@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/app/service"
+	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/errs"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// parseIDParam читает положительный ID из параметра пути
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		HandleError(c, errs.ErrInvalidID)
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// GetStoreMembers godoc
+// @Summary Get store members
+// @Description Returns the members of a store with their roles, the owner first. Only members of the store can see the list.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Success 200 {array} models.StoreMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store not found"
+// @Router /store/{id}/members [get]
+func GetStoreMembers(c *gin.Context) {
+	storeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := service.GetStoreMembers(c.GetUint(middlewares.UserIDCtx), storeID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// UpdateStoreMemberRole godoc
+// @Summary Change the role of a store member
+// @Description The owner can assign any role except owner, a manager can only move members between catalog_editor and order_operator.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Param user_id path int true "User ID of the member"
+// @Param request body models.StoreMemberRoleRequest true "New role"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store or member not found"
+// @Router /store/{id}/members/{user_id} [put]
+func UpdateStoreMemberRole(c *gin.Context) {
+	storeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	memberID, ok := parseIDParam(c, "user_id")
+	if !ok {
+		return
+	}
+
+	var request models.StoreMemberRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	if err := service.UpdateStoreMemberRole(c.GetUint(middlewares.UserIDCtx), storeID, memberID, request.Role); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member role updated successfully"})
+}
+
+// RemoveStoreMember godoc
+// @Summary Remove a store member
+// @Description Removes a member from a store. A member can leave a store on their own, the owner can't be removed.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Param user_id path int true "User ID of the member"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store or member not found"
+// @Router /store/{id}/members/{user_id} [delete]
+func RemoveStoreMember(c *gin.Context) {
+	storeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	memberID, ok := parseIDParam(c, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := service.RemoveStoreMember(c.GetUint(middlewares.UserIDCtx), storeID, memberID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed successfully"})
+}
+
+// InviteStoreMember godoc
+// @Summary Invite a user to a store
+// @Description Invites a user found by username or email to work in the store with a role. The invitation expires in 7 days.
+// @Description The owner can invite managers, catalog editors and order operators, a manager only catalog editors and order operators.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Param request body models.StoreInvitationRequest true "Username or email and role"
+// @Success 201 {object} models.StoreInvitation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store or user not found"
+// @Router /store/{id}/invitations [post]
+func InviteStoreMember(c *gin.Context) {
+	storeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request models.StoreInvitationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		HandleError(c, errs.ErrValidationFailed)
+		return
+	}
+
+	invitation, err := service.InviteStoreMember(c.GetUint(middlewares.UserIDCtx), storeID, request)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+// GetStoreInvitations godoc
+// @Summary Get store invitations
+// @Description Returns the invitations of a store that are waiting for an answer.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Success 200 {array} models.StoreInvitation
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store not found"
+// @Router /store/{id}/invitations [get]
+func GetStoreInvitations(c *gin.Context) {
+	storeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := service.GetStoreInvitations(c.GetUint(middlewares.UserIDCtx), storeID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// CancelStoreInvitation godoc
+// @Summary Cancel a store invitation
+// @Description Cancels an invitation that hasn't been answered yet.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Store ID"
+// @Param invitation_id path int true "Invitation ID"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Permission denied"
+// @Failure 404 {object} models.ErrorResponse "Store or invitation not found"
+// @Router /store/{id}/invitations/{invitation_id} [delete]
+func CancelStoreInvitation(c *gin.Context) {
+	storeID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	invitationID, ok := parseIDParam(c, "invitation_id")
+	if !ok {
+		return
+	}
+
+	if err := service.CancelStoreInvitation(c.GetUint(middlewares.UserIDCtx), storeID, invitationID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation cancelled successfully"})
+}
+
+// GetMyStoreInvitations godoc
+// @Summary Get my store invitations
+// @Description Returns the store invitations of the current user that are waiting for an answer.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Success 200 {array} models.StoreInvitation
+// @Failure 401 {object} models.ErrorResponse
+// @Router /invitations [get]
+func GetMyStoreInvitations(c *gin.Context) {
+	invitations, err := service.GetUserStoreInvitations(c.GetUint(middlewares.UserIDCtx))
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// AcceptStoreInvitation godoc
+// @Summary Accept a store invitation
+// @Description Makes the current user a member of the store with the role of the invitation.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} models.StoreMember
+// @Failure 400 {object} models.ErrorResponse "Invitation expired or user is already a member"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Invitation not found"
+// @Router /invitations/{id}/accept [post]
+func AcceptStoreInvitation(c *gin.Context) {
+	invitationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	member, err := service.AcceptStoreInvitation(c.GetUint(middlewares.UserIDCtx), invitationID)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// DeclineStoreInvitation godoc
+// @Summary Decline a store invitation
+// @Description Declines an invitation of the current user.
+// @Tags store members
+// @Security ApiKeyAuth
+// @Produce  json
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} models.DefaultResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Invitation not found"
+// @Router /invitations/{id}/decline [post]
+func DeclineStoreInvitation(c *gin.Context) {
+	invitationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := service.DeclineStoreInvitation(c.GetUint(middlewares.UserIDCtx), invitationID); err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invitation declined successfully"})
+}
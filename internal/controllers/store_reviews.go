@@ -6,6 +6,7 @@ import (
 	"BizMart/internal/controllers/middlewares"
 	"BizMart/internal/repository"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/pagination"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
@@ -14,12 +15,17 @@ import (
 
 // GetAllStoreReviewsByStoreID godoc
 // @Summary Get all reviews for a store
-// @Description Fetches all reviews for a specific store by its ID.
+// @Description Fetches a page of reviews for a specific store by its ID.
 // @Tags store reviews
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Store ID"
-// @Success 200 {object} models.Store "Returns a list of reviews for the store"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param sort query string false "created_at (default) or rating"
+// @Param order query string false "asc or desc, desc by default"
+// @Param fields query string false "Comma-separated review fields to return"
+// @Success 200 {object} pagination.Page[models.StoreReview] "Returns a page of reviews for the store"
 // @Failure 400 {object} models.ErrorResponse
 // @Router /store/reviews/{id} [get]
 func GetAllStoreReviewsByStoreID(c *gin.Context) {
@@ -30,13 +36,19 @@ func GetAllStoreReviewsByStoreID(c *gin.Context) {
 		return
 	}
 
-	storeReviews, err := repository.GetAllStoreReviews(uint(storeID))
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
 	if err != nil {
 		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"store_reviews": storeReviews})
+	storeReviews, err := repository.GetAllStoreReviews(uint(storeID), params)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, storeReviews)
 }
 
 // GetStoreReviewByID godoc
@@ -4,24 +4,27 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/internal/app/service"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
 )
 
 func GetAllUsers(c *gin.Context) {
-	users, err := service.GetAllUsers()
+	params, err := pagination.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	users, err := service.GetAllUsers(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
 		logger.Error.Printf("[controllers.GetAllUsers] error: %v\n", err)
+		HandleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"users": users,
-	})
+	c.JSON(http.StatusOK, users)
 }
 
 func GetUserByID(c *gin.Context) {
@@ -49,8 +52,8 @@ func GetUserByID(c *gin.Context) {
 }
 
 func CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.BindJSON(&user); err != nil {
+	var request models.UserRequest
+	if err := c.BindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
@@ -58,6 +61,7 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	user := request.ToUser()
 	_, err := service.CreateUser(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -70,6 +74,6 @@ func CreateUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "user created successfully",
 	})
-	logger.Info.Printf("[controllers.CreateUser] message successfully\n data %v", user)
+	logger.Info.Printf("[controllers.CreateUser] user %s created successfully\n", user.Username)
 
 }
@@ -0,0 +1,49 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"BizMart/internal/app/service"
+)
+
+// productImportWorkers сколько импортов выполняется одновременно, чтобы они не заняли все соединения с базой
+const productImportWorkers = 2
+
+// ProcessProductImports запускает обработчики импортов товаров. Они забирают новые импорты сразу после загрузки,
+// а раз в ProductImportPollInterval — ожидающие и брошенные импорты, например прерванные перезапуском сервиса
+func ProcessProductImports() {
+	for i := 1; i < productImportWorkers; i++ {
+		go processProductImports()
+	}
+
+	processProductImports()
+}
+
+func processProductImports() {
+	process := func() {
+		for {
+			processed, err := service.ProcessNextProductImport()
+			if err != nil {
+				log.Printf("Error processing product imports: %v", err)
+				return
+			}
+
+			if !processed {
+				return
+			}
+		}
+	}
+
+	process()
+
+	ticker := time.NewTicker(service.ProductImportPollInterval())
+	for {
+		select {
+		case <-ticker.C:
+			process()
+		case <-service.ProductImportQueued():
+			process()
+		}
+	}
+}
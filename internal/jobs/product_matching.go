@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"log"
+
+	"BizMart/internal/app/service"
+)
+
+const productMatchingBatchSize = 500
+
+// MatchUnmatchedProducts один раз при старте привязывает к каноническим товарам товары,
+// созданные до появления сравнения цен; новые товары сопоставляются при создании и изменении
+func MatchUnmatchedProducts() {
+	total := 0
+	for {
+		matched, err := service.MatchUnmatchedProducts(productMatchingBatchSize)
+		total += matched
+		if err != nil {
+			log.Printf("Error matching products to canonical products: %v", err)
+			return
+		}
+
+		if matched < productMatchingBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		log.Printf("Matched %d products to canonical products", total)
+	}
+}
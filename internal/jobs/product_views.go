@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"BizMart/internal/app/service"
+)
+
+// FlushProductViews периодически записывает накопленные в Redis просмотры товаров в Postgres
+func FlushProductViews() {
+	flush := func() {
+		if _, err := service.FlushProductViews(); err != nil {
+			log.Printf("Error flushing product views: %v", err)
+		}
+	}
+
+	ticker := time.NewTicker(service.ProductViewsFlushInterval())
+	for {
+		select {
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
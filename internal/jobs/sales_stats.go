@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"BizMart/internal/repository"
+)
+
+// RebuildSalesStats пересчитывает счётчики продаж при запуске и раз в сутки,
+// чтобы заполнить их для старых заказов и исправить возможные расхождения
+func RebuildSalesStats() {
+	rebuild := func() {
+		if err := repository.RebuildSalesStats(); err != nil {
+			log.Printf("Error rebuilding sales stats: %v", err)
+		}
+	}
+
+	rebuild()
+
+	ticker := time.NewTicker(24 * time.Hour)
+	for {
+		select {
+		case <-ticker.C:
+			rebuild()
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"BizMart/internal/app/service"
+)
+
+const expiredReservationsBatchSize = 100
+
+// ReleaseExpiredReservations периодически снимает просроченные резервы неоплаченных заказов
+// и переводит такие заказы в статус expired
+func ReleaseExpiredReservations() {
+	release := func() {
+		expired, err := service.ExpireReservations(expiredReservationsBatchSize)
+		if err != nil {
+			log.Printf("Error releasing expired reservations: %v", err)
+		}
+
+		if expired > 0 {
+			log.Printf("Released stock reservations of %d expired orders", expired)
+		}
+	}
+
+	release()
+
+	ticker := time.NewTicker(service.ReservationCheckInterval())
+	for {
+		select {
+		case <-ticker.C:
+			release()
+		}
+	}
+}
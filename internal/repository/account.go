@@ -36,23 +36,6 @@ func GetAccountByNumber(accountNumber string) (models.Account, error) {
 	return account, nil
 }
 
-func FillAccountBalance(accountNumber string, amount float64) error {
-	var account models.Account
-	if err := db.GetDBConn().Where("account_number = ?", accountNumber).First(&account).Error; err != nil {
-		logger.Error.Printf("[repository.GetAccountByNumber] error getting account: %v\n", err)
-		return TranslateGormError(err)
-	}
-
-	account.Balance += amount
-
-	if err := db.GetDBConn().Save(&account).Error; err != nil {
-		logger.Error.Printf("[repository.GetAccountByNumber] error updating account: %v\n", err)
-		return TranslateGormError(err)
-	}
-
-	return nil
-}
-
 func GetAccountByUserEmail(email string) (models.Account, error) {
 	var account models.Account
 	if err := db.GetDBConn().Where("user.email = ?", email).First(&account).Error; err != nil {
@@ -72,8 +55,13 @@ func CreateAccount(account models.Account) error {
 	return nil
 }
 
+// UpdateAccount stores the editable fields of an account.
+// The balance is derived from the ledger and is never written here.
 func UpdateAccount(account models.Account) error {
-	if err := db.GetDBConn().Save(&account).Error; err != nil {
+	if err := db.GetDBConn().Model(&models.Account{}).
+		Where("id = ?", account.ID).
+		Select("account_number").
+		Updates(&account).Error; err != nil {
 		logger.Error.Printf("[repository.UpdateAccount] error updating account: %v\n", err)
 		return TranslateGormError(err)
 	}
@@ -81,11 +69,30 @@ func UpdateAccount(account models.Account) error {
 	return nil
 }
 
-func DeleteAccount(account models.Account) error {
-	if err := db.GetDBConn().Delete(&account).Error; err != nil {
-		logger.Error.Printf("[repository.DeleteAccount] error deleting account: %v\n", err)
+// DeleteAccount soft-deletes a locked account.
+func (uow *UnitOfWork) DeleteAccount(accountID uint) error {
+	if err := uow.tx.Delete(&models.Account{}, accountID).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.DeleteAccount] error deleting account: %v\n", err)
 		return TranslateGormError(err)
 	}
 
 	return nil
 }
+
+// GetPayoutAccountIDs maps each user to the account that receives payouts for their stores: the oldest live account.
+func (uow *UnitOfWork) GetPayoutAccountIDs(userIDs []uint) (map[uint]uint, error) {
+	var accounts []models.Account
+	if err := uow.tx.Where("user_id IN ?", userIDs).Order("id").Find(&accounts).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.GetPayoutAccountIDs] error getting payout accounts: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	payoutAccounts := make(map[uint]uint, len(userIDs))
+	for _, account := range accounts {
+		if _, ok := payoutAccounts[account.UserID]; !ok {
+			payoutAccounts[account.UserID] = account.ID
+		}
+	}
+
+	return payoutAccounts, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+)
+
+func GetCanonicalProductByID(canonicalID uint) (models.CanonicalProduct, error) {
+	var canonical models.CanonicalProduct
+	if err := db.GetDBConn().Where("id = ?", canonicalID).First(&canonical).Error; err != nil {
+		logger.Error.Printf("[repository.GetCanonicalProductByID] error getting canonical product: %v\n", err)
+		return models.CanonicalProduct{}, TranslateGormError(err)
+	}
+
+	return canonical, nil
+}
+
+func GetCanonicalProductByGTIN(gtin string) (models.CanonicalProduct, error) {
+	var canonical models.CanonicalProduct
+	if err := db.GetDBConn().Where("gtin = ?", gtin).Order("id").First(&canonical).Error; err != nil {
+		logger.Error.Printf("[repository.GetCanonicalProductByGTIN] error getting canonical product: %v\n", err)
+		return models.CanonicalProduct{}, TranslateGormError(err)
+	}
+
+	return canonical, nil
+}
+
+// GetCanonicalProductByTitle returns the oldest canonical product with the given normalized title.
+func GetCanonicalProductByTitle(normalizedTitle string) (models.CanonicalProduct, error) {
+	var canonical models.CanonicalProduct
+	if err := db.GetDBConn().Where("normalized_title = ?", normalizedTitle).Order("id").First(&canonical).Error; err != nil {
+		logger.Error.Printf("[repository.GetCanonicalProductByTitle] error getting canonical product: %v\n", err)
+		return models.CanonicalProduct{}, TranslateGormError(err)
+	}
+
+	return canonical, nil
+}
+
+func CreateCanonicalProduct(canonical *models.CanonicalProduct) error {
+	if err := db.GetDBConn().Create(canonical).Error; err != nil {
+		logger.Error.Printf("[repository.CreateCanonicalProduct] error creating canonical product: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// SetCanonicalProductGTIN stores the GTIN of a canonical product that was matched by title before.
+func SetCanonicalProductGTIN(canonicalID uint, gtin string) error {
+	if err := db.GetDBConn().Model(&models.CanonicalProduct{}).
+		Where("id = ? AND gtin = ''", canonicalID).
+		Update("gtin", gtin).Error; err != nil {
+		logger.Error.Printf("[repository.SetCanonicalProductGTIN] error updating canonical product: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// SetProductCanonical links a product listing to its canonical product.
+func SetProductCanonical(productID uint, canonicalID uint) error {
+	if err := db.GetDBConn().Model(&models.Product{}).
+		Where("id = ?", productID).
+		UpdateColumn("canonical_product_id", canonicalID).Error; err != nil {
+		logger.Error.Printf("[repository.SetProductCanonical] error updating product: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	invalidateProductCache(productID)
+	return nil
+}
+
+// GetUnmatchedProducts returns products that are not linked to a canonical product yet.
+func GetUnmatchedProducts(limit int) ([]models.Product, error) {
+	var products []models.Product
+	if err := db.GetDBConn().Where("canonical_product_id IS NULL").Order("id").Limit(limit).Find(&products).Error; err != nil {
+		logger.Error.Printf("[repository.GetUnmatchedProducts] error getting products: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return products, nil
+}
+
+// GetProductOffers returns the listings of a canonical product in every store with the store rating.
+func GetProductOffers(canonicalID uint) ([]models.ProductOffer, error) {
+	var offers []models.ProductOffer
+	if err := db.GetDBConn().
+		Table("productapp_product AS p").
+		Select("p.id AS product_id, p.title, p.store_id, s.name AS store_name, p.price, p.amount, p.amount > 0 AS in_stock, "+
+			"COALESCE(AVG(r.rating), 0) AS store_rating, COUNT(r.id) AS reviews_count").
+		Joins("JOIN stores AS s ON s.id = p.store_id AND s.deleted_at IS NULL").
+		Joins("LEFT JOIN store_reviews AS r ON r.store_id = s.id AND r.deleted_at IS NULL").
+		Where("p.canonical_product_id = ?", canonicalID).
+		Group("p.id, s.id").
+		Scan(&offers).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductOffers] error getting offers: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return offers, nil
+}
+
+// SearchCanonicalProducts finds canonical products by name (with typos) that are in stock in at least one store.
+func SearchCanonicalProducts(query string, limit int) ([]models.CanonicalProductSummary, error) {
+	summaries := []models.CanonicalProductSummary{}
+	if err := db.GetDBConn().
+		Table("productapp_canonicalproduct AS cp").
+		Select("cp.id, cp.title, cp.gtin, cp.category_id, MIN(p.price) AS min_price, COUNT(p.id) AS offers_count").
+		Joins("JOIN productapp_product AS p ON p.canonical_product_id = cp.id AND p.amount > 0").
+		Where("cp.deleted_at IS NULL").
+		Where("(cp.gtin = ? OR word_similarity(lower(?), lower(cp.title)) >= ?)", query, query, productSearchSimilarity).
+		Group("cp.id").
+		Order(clauseOrderBySimilarity("cp.title", query)).
+		Limit(limit).
+		Scan(&summaries).Error; err != nil {
+		logger.Error.Printf("[repository.SearchCanonicalProducts] error searching canonical products: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+)
+
+// GetCartItemsByUserID retrieves all cart items of a user with their products and variants.
+func GetCartItemsByUserID(userID uint) ([]models.CartItem, error) {
+	var items []models.CartItem
+	if err := db.GetDBConn().
+		Model(&models.CartItem{}).
+		Preload("Product").
+		Preload("Variant").
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&items).Error; err != nil {
+		logger.Error.Printf("[repository.GetCartItemsByUserID] error getting cart items: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return items, nil
+}
+
+// GetCartItem retrieves a single cart line of a user by product variant and chosen modifiers.
+func GetCartItem(userID, variantID uint, modifierKey string) (models.CartItem, error) {
+	var item models.CartItem
+	if err := db.GetDBConn().
+		Where("user_id = ? AND variant_id = ? AND modifier_key = ?", userID, variantID, modifierKey).
+		First(&item).Error; err != nil {
+		logger.Error.Printf("[repository.GetCartItem] error getting cart item: %v\n", err)
+		return models.CartItem{}, TranslateGormError(err)
+	}
+
+	return item, nil
+}
+
+// SaveCartItem creates or updates a cart line.
+func SaveCartItem(item models.CartItem) error {
+	if err := db.GetDBConn().Save(&item).Error; err != nil {
+		logger.Error.Printf("[repository.SaveCartItem] error saving cart item: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// DeleteCartItem removes a product variant with the chosen modifiers from the user's cart.
+func DeleteCartItem(userID, variantID uint, modifierKey string) error {
+	if err := db.GetDBConn().
+		Where("user_id = ? AND variant_id = ? AND modifier_key = ?", userID, variantID, modifierKey).
+		Delete(&models.CartItem{}).Error; err != nil {
+		logger.Error.Printf("[repository.DeleteCartItem] error deleting cart item: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// ClearCart removes all items from the user's cart.
+func ClearCart(userID uint) error {
+	if err := db.GetDBConn().Where("user_id = ?", userID).Delete(&models.CartItem{}).Error; err != nil {
+		logger.Error.Printf("[repository.ClearCart] error clearing cart: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// productListCacheTag помечает списки товаров без фильтра по магазину или категории
+const productListCacheTag = "products"
+
+func productCacheTag(productID uint) string {
+	return fmt.Sprintf("product:%d", productID)
+}
+
+func storeCacheTag(storeID uint) string {
+	return fmt.Sprintf("store:%d", storeID)
+}
+
+func categoryCacheTag(categoryID uint) string {
+	return fmt.Sprintf("category:%d", categoryID)
+}
+
+// ProductCacheTags returns the cache tags of a product page.
+func ProductCacheTags(productID uint) []string {
+	return []string{productCacheTag(productID)}
+}
+
+// ProductListCacheTags returns the cache tags of a product list with the given filters.
+// Lists filtered by a store or a category are invalidated only by changes of products of that store or category (or its subcategories).
+func ProductListCacheTags(filter models.ProductFilter) []string {
+	var tags []string
+	if filter.StoreID > 0 {
+		tags = append(tags, storeCacheTag(filter.StoreID))
+	}
+	if filter.CategoryID > 0 {
+		tags = append(tags, categoryCacheTag(filter.CategoryID))
+	}
+	if len(tags) == 0 {
+		tags = append(tags, productListCacheTag)
+	}
+
+	return tags
+}
+
+// productCatalogCacheTags returns the tags touched by a change of a product: its page and every list it can appear in.
+// If the product can't be read, only its page and the unfiltered lists are returned.
+func productCatalogCacheTags(conn *gorm.DB, productID uint) []string {
+	tags := []string{productCacheTag(productID), productListCacheTag}
+
+	var product models.Product
+	if err := conn.Select("id", "store_id", "category_id").Where("id = ?", productID).Take(&product).Error; err != nil {
+		logger.Warn.Printf("[repository.productCatalogCacheTags] error getting product %d: %v\n", productID, err)
+		return tags
+	}
+
+	return append(append(tags, storeCacheTag(product.StoreID)), categoryPathCacheTags(conn, product.CategoryID)...)
+}
+
+// categoryPathCacheTags returns the tags of the category and all its ancestors,
+// because lists filtered by a category include products of its subcategories.
+// If the ancestors can't be read, only the category's own tag is returned.
+func categoryPathCacheTags(conn *gorm.DB, categoryID uint) []string {
+	ancestorIDs, err := categoryAncestorIDs(conn, categoryID)
+	if err != nil {
+		logger.Warn.Printf("[repository.categoryPathCacheTags] error getting ancestors of category %d: %v\n", categoryID, err)
+		return []string{categoryCacheTag(categoryID)}
+	}
+
+	tags := make([]string, 0, len(ancestorIDs)+1)
+	tags = append(tags, categoryCacheTag(categoryID))
+	for _, ancestorID := range ancestorIDs {
+		tags = append(tags, categoryCacheTag(ancestorID))
+	}
+
+	return tags
+}
+
+// invalidateProductCache drops the cached pages and lists that contain the product.
+func invalidateProductCache(productID uint) {
+	db.InvalidateCacheTags(productCatalogCacheTags(db.GetDBConn(), productID)...)
+}
+
+// invalidateCache remembers tags to invalidate once the transaction is committed,
+// so that a concurrent read can't put the data from before the change back into the cache.
+func (uow *UnitOfWork) invalidateCache(tags ...string) {
+	uow.cacheTags = append(uow.cacheTags, tags...)
+}
@@ -5,8 +5,29 @@ import (
 	"BizMart/pkg/db"
 	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
+	"errors"
+	"gorm.io/gorm"
 )
 
+// maxCategoryDepth ограничивает обход предков, чтобы цикл, попавший в базу до проверок, не зациклил запрос
+const maxCategoryDepth = 100
+
+// categorySubtreeQuery выбирает ID категории и всех её подкатегорий.
+// UNION вместо UNION ALL отбрасывает повторы, поэтому рекурсия завершается даже на цикле
+const categorySubtreeQuery = `WITH RECURSIVE subtree AS (
+	SELECT id FROM categoryapp_category WHERE id = ? AND deleted_at IS NULL
+	UNION
+	SELECT c.id FROM categoryapp_category AS c JOIN subtree AS s ON c.parent_id = s.id WHERE c.deleted_at IS NULL
+) SELECT id FROM subtree`
+
+// categoryPathCTE выбирает категорию и её предков, depth — расстояние от категории
+const categoryPathCTE = `WITH RECURSIVE path AS (
+	SELECT id, parent_id, 0 AS depth FROM categoryapp_category WHERE id = ? AND deleted_at IS NULL
+	UNION ALL
+	SELECT c.id, c.parent_id, p.depth + 1 FROM categoryapp_category AS c JOIN path AS p ON c.id = p.parent_id
+	WHERE c.deleted_at IS NULL AND p.depth < ?
+)`
+
 func GetAllCategories() (categories []models.Category, err error) {
 	if err = db.GetDBConn().Find(&categories).Error; err != nil {
 		logger.Error.Printf("[repository.GetAllCategories] error finding all categories: %v", err)
@@ -43,32 +64,230 @@ func CreateCategory(category models.Category) (categoryID uint, err error) {
 	return category.ID, nil
 }
 
+// UpdateCategory updates the fields of a category. A non-zero ParentID also moves the category with its subtree,
+// the move and the field update are committed together under the category tree lock.
 func UpdateCategory(categID uint, category models.Category) (categoryID uint, err error) {
-	existingCategory := models.Category{}
-	if err = db.GetDBConn().First(&existingCategory, categID).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateCategory] category not found: %v\n", err)
-		return 0, errs.ErrCategoryNotFound
-	}
+	tags := []string{categoryCacheTag(categID)}
+	err = db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if category.ParentID != 0 {
+			if err := lockCategoryTree(tx); err != nil {
+				return err
+			}
+		}
+
+		existingCategory, err := getCategoryForChange(tx, categID, errs.ErrCategoryNotFound)
+		if err != nil {
+			return err
+		}
 
-	if err = db.GetDBConn().Model(&existingCategory).Updates(category).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateCategory] error updating category: %v\n", err)
-		return categID, TranslateGormError(err)
+		if category.ParentID != 0 {
+			moveTags, err := moveCategory(tx, existingCategory, category.ParentID)
+			if err != nil {
+				return err
+			}
+			tags = append(tags, moveTags...)
+		}
+
+		// Родитель меняется только через moveCategory, где проверяются циклы
+		if err = tx.Model(&existingCategory).Omit("parent_id").Updates(category).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateCategory] error updating category: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return categID, err
 	}
 
+	db.InvalidateCacheTags(tags...)
 	return categID, nil
 }
 
-func DeleteCategory(categoryID uint) (err error) {
-	var category models.Category
-	category, err = GetCategoryByID(categoryID)
-	if err != nil {
-		return errs.ErrCategoryNotFound
+// categoryAncestorIDs returns the IDs of the ancestors of a category starting from the root.
+func categoryAncestorIDs(conn *gorm.DB, categoryID uint) (ancestorIDs []uint, err error) {
+	err = conn.Raw(categoryPathCTE+` SELECT id FROM path WHERE depth > 0 ORDER BY depth DESC`, categoryID, maxCategoryDepth).
+		Scan(&ancestorIDs).Error
+	return ancestorIDs, err
+}
+
+// categorySubtreeIDs returns the IDs of a category and all its subcategories.
+func categorySubtreeIDs(conn *gorm.DB, categoryID uint) (categoryIDs []uint, err error) {
+	err = conn.Raw(categorySubtreeQuery, categoryID).Scan(&categoryIDs).Error
+	return categoryIDs, err
+}
+
+// GetCategoryPath returns the breadcrumbs of a category: its ancestors starting from the root and the category itself.
+func GetCategoryPath(categoryID uint) (path []models.Category, err error) {
+	if err = db.GetDBConn().Raw(categoryPathCTE+` SELECT c.* FROM categoryapp_category AS c JOIN path ON path.id = c.id ORDER BY path.depth DESC`,
+		categoryID, maxCategoryDepth).Scan(&path).Error; err != nil {
+		logger.Error.Printf("[repository.GetCategoryPath] error getting path of category %d: %v\n", categoryID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	if len(path) == 0 {
+		return nil, errs.ErrCategoryNotFound
+	}
+
+	return path, nil
+}
+
+// lockCategoryTree блокирует изменения категорий до конца транзакции. Перемещения и удаления выполняются по очереди,
+// иначе два встречных перемещения могли бы пройти проверку и вместе создать цикл. Чтение таблицы не блокируется
+func lockCategoryTree(tx *gorm.DB) error {
+	if err := tx.Exec("LOCK TABLE categoryapp_category IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		logger.Error.Printf("[repository.lockCategoryTree] error locking categories: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+func getCategoryForChange(tx *gorm.DB, categoryID uint, notFound error) (category models.Category, err error) {
+	if err = tx.Where("id = ?", categoryID).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return category, notFound
+		}
+
+		logger.Error.Printf("[repository.getCategoryForChange] error getting category %d: %v\n", categoryID, err)
+		return category, TranslateGormError(err)
+	}
+
+	return category, nil
+}
+
+// checkCategoryTarget проверяет, что категорию или её содержимое можно перенести в target: target существует и не лежит в поддереве
+func checkCategoryTarget(tx *gorm.DB, categoryID, targetID uint) error {
+	if _, err := getCategoryForChange(tx, targetID, errs.ErrInvalidTargetCategory); err != nil {
+		return err
 	}
 
-	if err = db.GetDBConn().Delete(&category).Error; err != nil {
-		logger.Error.Printf("[repository.DeleteCategory] error deleting category: %v\n", err)
+	subtree, err := categorySubtreeIDs(tx, categoryID)
+	if err != nil {
+		logger.Error.Printf("[repository.checkCategoryTarget] error getting subtree of category %d: %v\n", categoryID, err)
 		return TranslateGormError(err)
 	}
 
+	for _, id := range subtree {
+		if id == targetID {
+			return errs.ErrCategoryCycle
+		}
+	}
+
 	return nil
 }
+
+// MoveCategory moves a category with its whole subtree under another parent, parentID 0 makes it a root category.
+// A category can't be moved under itself or one of its descendants.
+func MoveCategory(categoryID, parentID uint) error {
+	var tags []string
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+
+		category, err := getCategoryForChange(tx, categoryID, errs.ErrCategoryNotFound)
+		if err != nil {
+			return err
+		}
+
+		tags, err = moveCategory(tx, category, parentID)
+		return err
+	})
+	if err == nil {
+		db.InvalidateCacheTags(tags...)
+	}
+
+	return err
+}
+
+// moveCategory перемещает категорию под parentID внутри транзакции с заблокированным деревом
+// и возвращает теги кэша, которые нужно сбросить после коммита
+func moveCategory(tx *gorm.DB, category models.Category, parentID uint) ([]string, error) {
+	if category.ParentID == parentID {
+		return nil, nil
+	}
+
+	if parentID != 0 {
+		if err := checkCategoryTarget(tx, category.ID, parentID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Товары поддерева пропадают из списков прежних предков и появляются в списках новых
+	tags := categoryPathCacheTags(tx, category.ID)
+
+	if err := tx.Model(&category).Update("parent_id", parentID).Error; err != nil {
+		logger.Error.Printf("[repository.moveCategory] error moving category %d: %v\n", category.ID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return append(tags, categoryPathCacheTags(tx, category.ID)...), nil
+}
+
+// DeleteCategory deletes a category. A category with products or subcategories is deleted only when reassignTo is set:
+// its products and direct subcategories are moved to that category first, so nothing is deleted along with it.
+func DeleteCategory(categoryID, reassignTo uint) error {
+	var tags []string
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+
+		category, err := getCategoryForChange(tx, categoryID, errs.ErrCategoryNotFound)
+		if err != nil {
+			return err
+		}
+
+		var productsCount, childrenCount int64
+		if err = tx.Model(&models.Product{}).Where("category_id = ?", categoryID).Count(&productsCount).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteCategory] error counting products: %v\n", err)
+			return TranslateGormError(err)
+		}
+		if err = tx.Model(&models.Category{}).Where("parent_id = ?", categoryID).Count(&childrenCount).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteCategory] error counting subcategories: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		tags = categoryPathCacheTags(tx, categoryID)
+
+		if productsCount > 0 || childrenCount > 0 {
+			if reassignTo == 0 {
+				return errs.ErrCategoryNotEmpty
+			}
+
+			if err = checkCategoryTarget(tx, categoryID, reassignTo); err != nil {
+				return err
+			}
+
+			var productIDs []uint
+			if err = tx.Raw("UPDATE productapp_product SET category_id = ? WHERE category_id = ? RETURNING id",
+				reassignTo, categoryID).Scan(&productIDs).Error; err != nil {
+				logger.Error.Printf("[repository.DeleteCategory] error reassigning products: %v\n", err)
+				return TranslateGormError(err)
+			}
+
+			if err = tx.Model(&models.Category{}).Where("parent_id = ?", categoryID).Update("parent_id", reassignTo).Error; err != nil {
+				logger.Error.Printf("[repository.DeleteCategory] error reassigning subcategories: %v\n", err)
+				return TranslateGormError(err)
+			}
+
+			tags = append(tags, categoryPathCacheTags(tx, reassignTo)...)
+			for _, productID := range productIDs {
+				tags = append(tags, productCacheTag(productID))
+			}
+		}
+
+		if err = tx.Delete(&category).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteCategory] error deleting category: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err == nil {
+		db.InvalidateCacheTags(tags...)
+	}
+
+	return err
+}
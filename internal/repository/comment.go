@@ -4,35 +4,62 @@ import (
 	"BizMart/internal/app/models"
 	db2 "BizMart/pkg/db"
 	"BizMart/pkg/errs"
+	"BizMart/pkg/pagination"
 	"errors"
 	"gorm.io/gorm"
 )
 
-// GetProductComments - получение всех комментариев для продукта
-func GetProductComments(productID uint) ([]models.Comment, map[uint][]models.Comment, error) {
-	var comments []models.Comment
+// commentPageSpec - сортировки корневых комментариев продукта
+var commentPageSpec = pagination.Spec[models.Comment]{
+	Sorts: map[string]pagination.Sort[models.Comment]{
+		"created_at": {
+			Columns: []string{"commentapp_comment.created_at", "commentapp_comment.id"},
+			Key:     func(c models.Comment) []interface{} { return []interface{}{c.CreatedAt, c.ID} },
+		},
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	CountTotal:   true,
+}
+
+// GetProductComments - получение страницы корневых комментариев продукта и всех ответов на них
+func GetProductComments(productID uint, params pagination.Params) (pagination.Page[models.Comment], map[uint][]models.Comment, error) {
 	db := db2.GetDBConn()
 
-	result := db.Where("product_id = ?", productID).Find(&comments)
-	if result.Error != nil {
-		return nil, nil, result.Error
+	// Страница строится только по корневым комментариям, ответы подгружаются целиком
+	query := db.Model(&models.Comment{}).Where("product_id = ? AND parent_id = 0", productID)
+	page, err := pagination.Paginate(query, commentPageSpec, params)
+	if err != nil {
+		return pagination.Page[models.Comment]{}, nil, err
+	}
+
+	rootIDs := make([]uint, 0, len(page.Items))
+	for _, comment := range page.Items {
+		rootIDs = append(rootIDs, comment.ID)
 	}
 
 	// Создаем словарь для комментариев
 	commentsDict := make(map[uint][]models.Comment)
-	for _, comment := range comments {
-		commentsDict[comment.ParentID] = append(commentsDict[comment.ParentID], comment)
+	if len(rootIDs) == 0 {
+		return page, commentsDict, nil
 	}
 
-	// Получаем корневые комментарии
-	var mainComments []models.Comment
-	for _, comment := range comments {
-		if comment.ParentID == 0 {
-			mainComments = append(mainComments, comment)
-		}
+	var replies []models.Comment
+	result := db.Raw(`WITH RECURSIVE thread AS (
+			SELECT * FROM commentapp_comment WHERE parent_id IN ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.* FROM commentapp_comment AS c JOIN thread AS t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+		)
+		SELECT * FROM thread ORDER BY created_at, id`, rootIDs).Scan(&replies)
+	if result.Error != nil {
+		return pagination.Page[models.Comment]{}, nil, result.Error
+	}
+
+	for _, comment := range replies {
+		commentsDict[comment.ParentID] = append(commentsDict[comment.ParentID], comment)
 	}
 
-	return mainComments, commentsDict, nil
+	return page, commentsDict, nil
 }
 
 // CreateComment - создание комментария
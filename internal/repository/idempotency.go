@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// CreateIdempotencyKey inserts the key if it doesn't exist yet and reports whether it was inserted.
+// Expired keys of the user are removed first so that they can be reused.
+func CreateIdempotencyKey(key *models.IdempotencyKey) (bool, error) {
+	if err := db.GetDBConn().
+		Where("user_id = ? AND key = ? AND expires_at < ?", key.UserID, key.Key, time.Now()).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		logger.Error.Printf("[repository.CreateIdempotencyKey] error deleting expired idempotency key: %v\n", err)
+		return false, TranslateGormError(err)
+	}
+
+	result := db.GetDBConn().Clauses(clause.OnConflict{DoNothing: true}).Create(key)
+	if result.Error != nil {
+		logger.Error.Printf("[repository.CreateIdempotencyKey] error creating idempotency key: %v\n", result.Error)
+		return false, TranslateGormError(result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func GetIdempotencyKey(userID uint, key string) (models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+	if err := db.GetDBConn().Where("user_id = ? AND key = ?", userID, key).First(&idempotencyKey).Error; err != nil {
+		logger.Error.Printf("[repository.GetIdempotencyKey] error getting idempotency key: %v\n", err)
+		return models.IdempotencyKey{}, TranslateGormError(err)
+	}
+
+	return idempotencyKey, nil
+}
+
+// SaveIdempotencyKey inserts the key or overwrites the stored response of an existing one.
+func SaveIdempotencyKey(key *models.IdempotencyKey) error {
+	if err := db.GetDBConn().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fingerprint", "status", "status_code", "content_type", "response_body", "expires_at", "updated_at"}),
+	}).Create(key).Error; err != nil {
+		logger.Error.Printf("[repository.SaveIdempotencyKey] error saving idempotency key: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// ExtendIdempotencyKey moves the expiration of a key that is still being processed.
+func ExtendIdempotencyKey(userID uint, key string, expiresAt time.Time) error {
+	if err := db.GetDBConn().Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ? AND status = ?", userID, key, models.IdempotencyStatusProcessing).
+		Update("expires_at", expiresAt).Error; err != nil {
+		logger.Error.Printf("[repository.ExtendIdempotencyKey] error extending idempotency key: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+func DeleteIdempotencyKey(userID uint, key string) error {
+	if err := db.GetDBConn().Where("user_id = ? AND key = ?", userID, key).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		logger.Error.Printf("[repository.DeleteIdempotencyKey] error deleting idempotency key: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
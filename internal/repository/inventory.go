@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+)
+
+// GetInventoryMovements returns the stock history of a product, newest first.
+func GetInventoryMovements(productID uint) ([]models.InventoryMovement, error) {
+	var movements []models.InventoryMovement
+	if err := db.GetDBConn().
+		Where("product_id = ?", productID).
+		Order("id DESC").
+		Find(&movements).Error; err != nil {
+		logger.Error.Printf("[repository.GetInventoryMovements] error getting inventory movements: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return movements, nil
+}
+
+// GetLowStockProducts returns products of a store whose stock fell to their low-stock threshold.
+// Products without a threshold are never reported.
+func GetLowStockProducts(storeID uint) ([]models.Product, error) {
+	var products []models.Product
+	if err := db.GetDBConn().
+		Where("store_id = ? AND low_stock_threshold > 0 AND amount <= low_stock_threshold", storeID).
+		Order("amount, id").
+		Find(&products).Error; err != nil {
+		logger.Error.Printf("[repository.GetLowStockProducts] error getting low stock products: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return products, nil
+}
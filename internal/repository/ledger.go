@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const ledgerBalanceExpr = "COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)"
+
+// PostJournalEntry stores a balanced journal entry and re-derives the balances of every account it touches.
+// The touched accounts are expected to be locked by the caller.
+func (uow *UnitOfWork) PostJournalEntry(entry *models.JournalEntry) error {
+	var debit, credit int64
+	for _, posting := range entry.Postings {
+		if posting.Amount <= 0 {
+			return errs.ErrInvalidAmount
+		}
+
+		switch posting.Direction {
+		case models.PostingDebit:
+			debit += posting.Amount
+		case models.PostingCredit:
+			credit += posting.Amount
+		default:
+			return errs.ErrUnbalancedJournalEntry
+		}
+	}
+
+	if len(entry.Postings) < 2 || debit != credit {
+		return errs.ErrUnbalancedJournalEntry
+	}
+
+	if err := uow.tx.Create(entry).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.PostJournalEntry] error creating journal entry: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	refreshed := make(map[uint]bool)
+	for _, posting := range entry.Postings {
+		if posting.AccountID == nil || refreshed[*posting.AccountID] {
+			continue
+		}
+
+		if err := uow.refreshAccountBalance(*posting.AccountID); err != nil {
+			return err
+		}
+		refreshed[*posting.AccountID] = true
+	}
+
+	return nil
+}
+
+func (uow *UnitOfWork) refreshAccountBalance(accountID uint) error {
+	balance, err := getLedgerBalance(uow.tx, accountID)
+	if err != nil {
+		return err
+	}
+
+	return uow.UpdateAccountBalance(accountID, utils.FromMinorUnits(balance))
+}
+
+// GetPayoutCredits maps each user to the account credited by the payout entry of a payment.
+// Used for items of orders paid before order items remembered their payout account.
+func (uow *UnitOfWork) GetPayoutCredits(paymentID uint) (map[uint]uint, error) {
+	type row struct {
+		UserID    uint
+		AccountID uint
+	}
+
+	var rows []row
+	if err := uow.tx.
+		Table("ledgerapp_posting AS p").
+		Select("a.user_id, p.account_id").
+		Joins("JOIN ledgerapp_journalentry AS e ON e.id = p.journal_entry_id").
+		Joins("JOIN accountapp_account AS a ON a.id = p.account_id").
+		Where("e.payment_id = ? AND e.kind = ? AND p.direction = ?", paymentID, models.JournalKindPayout, models.PostingCredit).
+		Order("p.id").
+		Scan(&rows).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.GetPayoutCredits] error getting payout credits: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	credits := make(map[uint]uint, len(rows))
+	for _, r := range rows {
+		if _, ok := credits[r.UserID]; !ok {
+			credits[r.UserID] = r.AccountID
+		}
+	}
+
+	return credits, nil
+}
+
+// GetLedgerBalance returns the balance of a locked account derived from its postings, in minor units.
+func (uow *UnitOfWork) GetLedgerBalance(accountID uint) (int64, error) {
+	return getLedgerBalance(uow.tx, accountID)
+}
+
+func getLedgerBalance(conn *gorm.DB, accountID uint) (int64, error) {
+	var balance int64
+	if err := conn.Model(&models.Posting{}).
+		Select(ledgerBalanceExpr).
+		Where("account_id = ?", accountID).
+		Scan(&balance).Error; err != nil {
+		logger.Error.Printf("[repository.getLedgerBalance] error calculating ledger balance: %v\n", err)
+		return 0, TranslateGormError(err)
+	}
+
+	return balance, nil
+}
+
+// GetAccountPostings returns the transaction history of an account, newest first.
+func GetAccountPostings(accountID uint) ([]models.Posting, error) {
+	var postings []models.Posting
+	if err := db.GetDBConn().
+		Preload("JournalEntry").
+		Where("account_id = ?", accountID).
+		Order("id DESC").
+		Find(&postings).Error; err != nil {
+		logger.Error.Printf("[repository.GetAccountPostings] error getting account postings: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return postings, nil
+}
+
+// GetDivergentAccounts returns every account whose stored balance differs from the sum of its postings.
+func GetDivergentAccounts() ([]models.AccountReconciliation, error) {
+	type row struct {
+		AccountID     uint
+		AccountNumber string
+		StoredBalance float64
+		LedgerBalance int64
+	}
+
+	var rows []row
+	if err := db.GetDBConn().
+		Table("accountapp_account AS a").
+		Select("a.id AS account_id, a.account_number, a.balance AS stored_balance, " +
+			"COALESCE(SUM(CASE WHEN p.direction = 'credit' THEN p.amount ELSE -p.amount END), 0) AS ledger_balance").
+		Joins("LEFT JOIN ledgerapp_posting AS p ON p.account_id = a.id").
+		Where("a.deleted_at IS NULL").
+		Group("a.id, a.account_number, a.balance").
+		Order("a.id").
+		Scan(&rows).Error; err != nil {
+		logger.Error.Printf("[repository.GetDivergentAccounts] error reconciling accounts: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	var divergent []models.AccountReconciliation
+	for _, r := range rows {
+		if utils.ToMinorUnits(r.StoredBalance) == r.LedgerBalance {
+			continue
+		}
+
+		ledgerBalance := utils.FromMinorUnits(r.LedgerBalance)
+		divergent = append(divergent, models.AccountReconciliation{
+			AccountID:     r.AccountID,
+			AccountNumber: r.AccountNumber,
+			StoredBalance: r.StoredBalance,
+			LedgerBalance: ledgerBalance,
+			Difference:    r.StoredBalance - ledgerBalance,
+		})
+	}
+
+	return divergent, nil
+}
@@ -4,6 +4,7 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/pkg/db"
 	"BizMart/pkg/logger"
+	"gorm.io/gorm/clause"
 )
 
 func GetAllOrders() ([]models.Order, error) {
@@ -11,6 +12,7 @@ func GetAllOrders() ([]models.Order, error) {
 	if err := db.GetDBConn().
 		Model(&models.Order{}).
 		Preload("OrderDetails").
+		Preload("Items.Modifiers").
 		Find(&orders).Error; err != nil {
 		logger.Error.Printf("[repository.GetAllOrderByUserID] Error getting orders by user id: %v", err)
 		return []models.Order{}, TranslateGormError(err)
@@ -25,6 +27,7 @@ func GetAllOrderByUserID(userID uint) ([]models.Order, error) {
 		Model(&models.Order{}).
 		Where("user_id = ?", userID).
 		Preload("OrderDetails").
+		Preload("Items.Modifiers").
 		Find(&orders).Error; err != nil {
 		logger.Error.Printf("[repository.GetAllOrderByUserID] Error getting orders by user id: %v", err)
 		return []models.Order{}, TranslateGormError(err)
@@ -39,6 +42,7 @@ func GetOrderByID(orderID uint) (models.Order, error) {
 		Model(&models.Order{}).
 		Where("id = ?", orderID).
 		Preload("OrderDetails").
+		Preload("Items.Modifiers").
 		First(&order).Error; err != nil {
 		logger.Error.Printf("[repository.GetAllOrderByUserID] Error getting orders by user id: %v", err)
 		return models.Order{}, TranslateGormError(err)
@@ -57,121 +61,82 @@ func GetOrderDetailsByID(orderDetailsID uint) (models.OrderDetails, error) {
 	return orderDetails, nil
 }
 
-func CreateOrder(order models.Order, orderDetails models.OrderDetails) error {
-	var product models.Product
-	var err error
-
-	if err = db.GetDBConn().Create(&orderDetails).Error; err != nil {
-		logger.Error.Printf("[repository.CreateOrder] Error creating orderDetails: %v", err)
-		return TranslateGormError(err)
-	}
-
-	order.OrderDetailsID = orderDetails.ID
-
-	if err = db.GetDBConn().Create(&order).Error; err != nil {
-		logger.Error.Printf("[repository.CreateOrder] Error creating order: %v", err)
-		return TranslateGormError(err)
-	}
-
-	if product, err = GetProductByID(orderDetails.ProductID); err != nil {
-		return TranslateGormError(err)
+func GetOrderItemsByOrderID(orderID uint) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	if err := db.GetDBConn().Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		logger.Error.Printf("[repository.GetOrderItemsByOrderID] Error getting order items: %v", err)
+		return nil, TranslateGormError(err)
 	}
 
-	product.Amount -= orderDetails.Quantity
+	return items, nil
+}
 
-	if err = db.GetDBConn().Model(&models.Product{}).Where("id = ?", product.ID).Save(&product).Error; err != nil {
-		logger.Error.Printf("[repository.CreateOrder] Error creating order: %v", err)
+// UpdateOrderItem stores the changed price and quantity of an order item, its modifiers are left as is.
+func (uow *UnitOfWork) UpdateOrderItem(item models.OrderItem) error {
+	if err := uow.tx.Omit(clause.Associations).Save(&item).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateOrderItem] error updating order item: %v\n", err)
 		return TranslateGormError(err)
 	}
 
 	return nil
 }
 
-func UpdateOrder(order models.Order, orderDetails models.OrderDetails) error {
-	if err := db.GetDBConn().Save(&order).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateOrder] Error updating order: %v", err)
-		return TranslateGormError(err)
-	}
-
-	if err := db.GetDBConn().Save(&orderDetails).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateOrder] Error updating orderDetails: %v", err)
+// UpdateOrderItemsStatus sets the fulfilment status of items of a locked order.
+func (uow *UnitOfWork) UpdateOrderItemsStatus(itemIDs []uint, statusID uint) error {
+	if err := uow.tx.Model(&models.OrderItem{}).Where("id IN ?", itemIDs).Update("status_id", statusID).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateOrderItemsStatus] error updating order items status: %v\n", err)
 		return TranslateGormError(err)
 	}
 
 	return nil
 }
 
-func DeleteOrder(orderID uint) error {
-	order, err := GetOrderByID(orderID)
-	if err != nil {
+// SetOrderItemPayoutAccount remembers the account credited for an item when its order was paid.
+func (uow *UnitOfWork) SetOrderItemPayoutAccount(orderItemID, accountID uint) error {
+	if err := uow.tx.Model(&models.OrderItem{}).Where("id = ?", orderItemID).Update("payout_account_id", accountID).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.SetOrderItemPayoutAccount] error setting order item payout account: %v\n", err)
 		return TranslateGormError(err)
 	}
 
-	if err := db.GetDBConn().Delete(&models.Order{}, order.ID).Error; err != nil {
-		logger.Error.Printf("[repository.DeleteOrder] Error deleting order: %v", err)
-		return TranslateGormError(err)
-	}
+	return nil
+}
 
-	if err := db.GetDBConn().Delete(&models.OrderDetails{}, order.OrderDetailsID).Error; err != nil {
-		logger.Error.Printf("[repository.DeleteOrder] Error deleting order: %v", err)
+// UpdateOrderDetails stores the changed details of an order.
+func (uow *UnitOfWork) UpdateOrderDetails(orderDetails models.OrderDetails) error {
+	if err := uow.tx.Omit(clause.Associations).Save(&orderDetails).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateOrderDetails] error updating orderDetails: %v\n", err)
 		return TranslateGormError(err)
 	}
 
 	return nil
 }
 
+// GetNumberOfProductOrders returns the number of orders that contain the product.
 func GetNumberOfProductOrders(productID uint) (int, error) {
-	orders, err := GetAllOrders()
+	stats, err := GetProductSalesStats(productID)
 	if err != nil {
-		return 0, TranslateGormError(err)
-	}
-
-	numOfProductOrders := 0
-
-	for _, order := range orders {
-		if order.OrderDetails.ProductID == productID {
-			numOfProductOrders++
-		}
+		return 0, err
 	}
 
-	return numOfProductOrders, nil
+	return int(stats.OrdersCount), nil
 }
 
+// GetNumberOfStoreOrders returns the number of orders that contain products of the store.
 func GetNumberOfStoreOrders(storeID uint) (int, error) {
-	products, err := GetProductByStoreIDWithoutFilters(storeID)
+	stats, err := GetStoreSalesStats(storeID)
 	if err != nil {
-		return 0, TranslateGormError(err)
+		return 0, err
 	}
 
-	numOfStoreOrders := 0
-
-	for _, product := range products {
-		if product.StoreID == storeID {
-			numOfProductOrders, err := GetNumberOfProductOrders(product.ID)
-			if err != nil {
-				return 0, TranslateGormError(err)
-			}
-
-			numOfStoreOrders += numOfProductOrders
-		}
-	}
-
-	return numOfStoreOrders, nil
+	return int(stats.OrdersCount), nil
 }
 
 func GetNumberOfStoreProducts(storeID uint) (int, error) {
-	products, err := GetProductByStoreIDWithoutFilters(storeID)
-	if err != nil {
+	var count int64
+	if err := db.GetDBConn().Model(&models.Product{}).Where("store_id = ?", storeID).Count(&count).Error; err != nil {
+		logger.Error.Printf("[repository.GetNumberOfStoreProducts] Error counting store products: %v\n", err)
 		return 0, TranslateGormError(err)
 	}
 
-	numOfProductOrders := 0
-
-	for _, product := range products {
-		if product.StoreID == storeID {
-			numOfProductOrders++
-		}
-	}
-
-	return numOfProductOrders, nil
+	return int(count), nil
 }
@@ -20,7 +20,7 @@ func GetOrderStatusByID(orderStatusID uint) (models.OrderStatus, error) {
 func GetOrderStatusByName(orderStatusName string) (models.OrderStatus, error) {
 	var orderStatus models.OrderStatus
 	if err := db.GetDBConn().Where("status_name = ?", orderStatusName).First(&orderStatus).Error; err != nil {
-		logger.Error.Printf("[repository.GetOrderStatusByID] error getting order status by ID: %s\n", err.Error())
+		logger.Error.Printf("[repository.GetOrderStatusByName] error getting order status by name: %s\n", err.Error())
 		return orderStatus, TranslateGormError(err)
 	}
 
@@ -37,35 +37,55 @@ func GetAllOrderStatuses() ([]models.OrderStatus, error) {
 	return orderStatuses, nil
 }
 
-func CreateOrderStatus(orderStatus models.OrderStatus) (uint, error) {
-	if err := db.GetDBConn().Create(&orderStatus).Error; err != nil {
-		logger.Error.Printf("[repository.CreateOrderStatus] error creating order status: %s\n", err.Error())
-		return 0, err
+func GetOrderStatusByCode(code string) (models.OrderStatus, error) {
+	var orderStatus models.OrderStatus
+	if err := db.GetDBConn().Where("code = ?", code).First(&orderStatus).Error; err != nil {
+		logger.Error.Printf("[repository.GetOrderStatusByCode] error getting order status by code: %s\n", err.Error())
+		return orderStatus, TranslateGormError(err)
 	}
 
-	return orderStatus.ID, nil
+	return orderStatus, nil
 }
 
+// UpdateOrderStatus changes the display name and description of a status, its code is never changed.
 func UpdateOrderStatus(orderStatusID uint, orderStatus models.OrderStatus) (OrderStatusID uint, err error) {
-	existingOrderStatus := models.Category{}
+	existingOrderStatus := models.OrderStatus{}
 	if err = db.GetDBConn().First(&existingOrderStatus, orderStatusID).Error; err != nil {
 		logger.Error.Printf("[repository.UpdateOrderStatus] orderStatus not found: %v\n", err)
 		return 0, errs.ErrOrderStatusNotFound
 	}
 
-	if err = db.GetDBConn().Model(&existingOrderStatus).Updates(orderStatus).Error; err != nil {
+	if err = db.GetDBConn().Model(&existingOrderStatus).
+		Select("StatusName", "Description").
+		Updates(orderStatus).Error; err != nil {
 		logger.Error.Printf("[repository.UpdateOrderStatus] error updating orderStatus: %v\n", err)
-		return orderStatusID, err
+		return orderStatusID, TranslateGormError(err)
 	}
 
 	return orderStatusID, nil
 }
 
-func DeleteOrderStatus(orderStatus models.OrderStatus) error {
-	if err := db.GetDBConn().Delete(&orderStatus).Error; err != nil {
-		logger.Error.Printf("[repository.DeleteOrderStatus] error deleting order status: %s\n", err.Error())
-		return err
+// CreateOrderStatusHistory records a status transition of an order.
+func (uow *UnitOfWork) CreateOrderStatusHistory(history *models.OrderStatusHistory) error {
+	if err := uow.tx.Create(history).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreateOrderStatusHistory] error creating order status history: %v\n", err)
+		return TranslateGormError(err)
 	}
 
 	return nil
 }
+
+func GetOrderStatusHistory(orderID uint) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	if err := db.GetDBConn().
+		Preload("FromStatus").
+		Preload("ToStatus").
+		Where("order_id = ?", orderID).
+		Order("id").
+		Find(&history).Error; err != nil {
+		logger.Error.Printf("[repository.GetOrderStatusHistory] error getting order status history: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return history, nil
+}
@@ -35,29 +35,12 @@ func CreatePayment(payment models.Payment) error {
 	return nil
 }
 
-func UpdatePayment(payment models.Payment) error {
-	if err := db.GetDBConn().Model(models.Payment{}).Where("id = ?", payment.ID).Save(&payment).Error; err != nil {
-		logger.Error.Printf("[repository.UpdatePayment] error updating payment: %s\n]", err.Error())
-		return TranslateGormError(err)
-	}
-
-	return nil
-}
-
-func DeletePayment(payment models.Payment) error {
-	if err := db.GetDBConn().Model(models.Payment{}).Delete(&payment).Error; err != nil {
-		logger.Error.Printf("[repository.DeletePayment] error deleting payment: %s\n", err.Error())
-		return TranslateGormError(err)
-	}
-
-	return nil
-}
-
 func GetPaymentByOrderID(orderID uint) (models.Payment, error) {
-	if err := db.GetDBConn().Model(models.Payment{}).Where("order_id = ?", orderID).First(&models.Payment{}).Error; err != nil {
+	var payment models.Payment
+	if err := db.GetDBConn().Model(models.Payment{}).Where("order_id = ?", orderID).First(&payment).Error; err != nil {
 		logger.Error.Printf("[repository.GetPaymentByOrderID] error getting payment by order ID: %s\n", err.Error())
 		return models.Payment{}, TranslateGormError(err)
 	}
 
-	return models.Payment{}, nil
+	return payment, nil
 }
@@ -4,27 +4,64 @@ import (
 	models2 "BizMart/internal/app/models"
 	"BizMart/pkg/db"
 	"BizMart/pkg/logger"
-	"sort"
+	"BizMart/pkg/pagination"
+	"fmt"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// GetProductByID retrieves a product by its ID
+// GetProductByID retrieves a product by its ID without touching its view counter
 func GetProductByID(productID uint) (models2.Product, error) {
 	var product models2.Product
-	if err := db.GetDBConn().Preload("Store").Preload("Category").Where("id = ?", productID).First(&product).Error; err != nil {
+	if err := db.GetDBConn().
+		Preload("Store").
+		Preload("Category").
+		Preload("Variants.Options").
+		Preload("ModifierGroups", orderModifiers).
+		Preload("ModifierGroups.Modifiers", orderModifiers).
+		Where("id = ?", productID).
+		First(&product).Error; err != nil {
 		logger.Error.Printf("[repository.GetProductByID] Error getting product: %v\n", err)
 		return product, TranslateGormError(err)
 	}
 
-	// Увеличиваем количество просмотров
-	product.Views += 1
+	return product, nil
+}
 
-	// Сохраняем изменения в базе данных
-	if err := db.GetDBConn().Save(&product).Error; err != nil {
-		logger.Error.Printf("[repository.GetProductByID] Error updating product views: %v\n", err)
-		return product, TranslateGormError(err)
+// AddProductViews adds buffered view counts to products in a single statement.
+func AddProductViews(views map[uint]int64) error {
+	if len(views) == 0 {
+		return nil
 	}
 
-	return product, nil
+	ids := make(pq.Int64Array, 0, len(views))
+	counts := make(pq.Int64Array, 0, len(views))
+	for productID, count := range views {
+		ids = append(ids, int64(productID))
+		counts = append(counts, count)
+	}
+
+	// Обновляем только счётчик, чтобы не перезаписать остаток, изменённый параллельными заказами
+	if err := db.GetDBConn().Exec(`UPDATE productapp_product AS p SET views = p.views + v.count
+		FROM (SELECT unnest(?::bigint[]) AS id, unnest(?::bigint[]) AS count) AS v
+		WHERE p.id = v.id`, ids, counts).Error; err != nil {
+		logger.Error.Printf("[repository.AddProductViews] Error updating product views: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetProductsByIDs retrieves products by their IDs without touching their view counters
+func GetProductsByIDs(productIDs []uint) ([]models2.Product, error) {
+	var products []models2.Product
+	if err := db.GetDBConn().Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductsByIDs] Error getting products: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return products, nil
 }
 
 // DeleteProductByID marks a product as deleted
@@ -36,11 +73,13 @@ func DeleteProductByID(productID uint) error {
 		return TranslateGormError(err)
 	}
 
+	tags := productCatalogCacheTags(db.GetDBConn(), productID)
 	if err := db.GetDBConn().Delete(&product).Error; err != nil {
 		logger.Error.Printf("[repository.DeleteProductByID] Error deleting product: %v\n", err)
 		return TranslateGormError(err)
 	}
 
+	db.InvalidateCacheTags(tags...)
 	return nil
 }
 
@@ -52,120 +91,255 @@ func DeleteProductImagesByProductID(productID uint) error {
 	return nil
 }
 
-// GetAllProducts retrieves all products filtered by category, price range, etc.,
-// and sorts them by the number of orders and views.
-func GetAllProducts(minPrice, maxPrice float64, categoryID uint, productName string, storeID uint) ([]models2.Product, error) {
-	var products []models2.Product
-	isQuery := false
+// productOrdersCountExpr is the number of orders of a product taken from the joined sales stats.
+const productOrdersCountExpr = "COALESCE(ps.orders_count, 0)"
+
+// productPageSpec describes the sorts and fields available when listing products.
+// Relevance sorting is only available when the list is filtered by a product name.
+func productPageSpec(productName string) pagination.Spec[models2.Product] {
+	spec := pagination.Spec[models2.Product]{
+		Sorts: map[string]pagination.Sort[models2.Product]{
+			"popularity": {
+				Columns: []string{productOrdersCountExpr, "productapp_product.views", "productapp_product.id"},
+				Key: func(p models2.Product) []interface{} {
+					return []interface{}{p.OrdersCount, p.Views, p.ID}
+				},
+			},
+			"price": {
+				Columns: []string{"productapp_product.price", "productapp_product.id"},
+				Key:     func(p models2.Product) []interface{} { return []interface{}{p.Price, p.ID} },
+			},
+			"views": {
+				Columns: []string{"productapp_product.views", "productapp_product.id"},
+				Key:     func(p models2.Product) []interface{} { return []interface{}{p.Views, p.ID} },
+			},
+			"title": {
+				Columns: []string{"productapp_product.title", "productapp_product.id"},
+				Key:     func(p models2.Product) []interface{} { return []interface{}{p.Title, p.ID} },
+			},
+			"id": {
+				Columns: []string{"productapp_product.id"},
+				Key:     func(p models2.Product) []interface{} { return []interface{}{p.ID} },
+			},
+		},
+		DefaultSort:  "popularity",
+		DefaultOrder: pagination.OrderDesc,
+		Fields: []string{"id", "store_id", "category_id", "title", "description", "price", "amount",
+			"product_image", "views", "gtin", "canonical_product_id", "orders_count"},
+	}
+
+	if productName != "" {
+		spec.Sorts["relevance"] = pagination.Sort[models2.Product]{
+			Columns: []string{productSearchRank, "productapp_product.id"},
+			Vars:    []interface{}{productName, productName, productName},
+			Key:     func(p models2.Product) []interface{} { return []interface{}{p.SearchRank, p.ID} },
+		}
+		spec.DefaultSort = "relevance"
+	}
+
+	return spec
+}
+
+// GetAllProducts retrieves a page of products filtered by category (including its subcategories), price range, variant options etc.
+// By default products are sorted by the number of orders and views, or by relevance when searching by name.
+func GetAllProducts(filter models2.ProductFilter, params pagination.Params) (pagination.Page[models2.Product], error) {
+	minPrice, maxPrice := filter.MinPrice, filter.MaxPrice
+	categoryID, storeID := filter.CategoryID, filter.StoreID
+	productName, options := filter.ProductName, filter.Options
+
 	query := db.GetDBConn().
 		Table("productapp_product").
-		Select("productapp_product.*").
+		Select("productapp_product.*, " + productOrdersCountExpr + " AS orders_count").
+		Joins("LEFT JOIN productapp_productsalesstats AS ps ON ps.product_id = productapp_product.id").
 		Where("productapp_product.amount > 0")
 
 	if minPrice > 0 {
-		isQuery = true
 		query = query.Where("productapp_product.price >= ?", minPrice)
 	}
 	if maxPrice > 0 {
-		isQuery = true
 		query = query.Where("productapp_product.price <= ?", maxPrice)
 	}
 	if categoryID > 0 {
-		isQuery = true
-		query = query.Where("productapp_product.category_id = ?", categoryID)
+		query = query.Where("productapp_product.category_id IN ("+categorySubtreeQuery+")", categoryID)
 	}
 	if storeID > 0 {
-		isQuery = true
 		query = query.Where("productapp_product.store_id = ?", storeID)
 	}
 	if productName != "" {
-		isQuery = true
-		query = query.Where(
-			db.GetDBConn().Where("productapp_product.title LIKE ?", "%"+productName+"%").
-				Or("productapp_product.description LIKE ?", "%"+productName+"%"),
-		)
+		query = query.
+			Select("productapp_product.*, "+productOrdersCountExpr+" AS orders_count, "+productSearchRank+" AS search_rank",
+				productName, productName, productName).
+			Where(productSearchCondition, productName, productName, productName, productSearchSimilarity)
 	}
 
-	if err := query.Find(&products).Error; err != nil {
+	if len(options) > 0 {
+		variantQuery := "EXISTS (SELECT 1 FROM productapp_productvariant AS v " +
+			"WHERE v.product_id = productapp_product.id AND v.deleted_at IS NULL AND v.amount > 0"
+		var args []interface{}
+		for name, value := range options {
+			variantQuery += " AND EXISTS (SELECT 1 FROM productapp_variantoption AS o " +
+				"WHERE o.variant_id = v.id AND o.name = ? AND o.value = ?)"
+			args = append(args, name, value)
+		}
+		query = query.Where(variantQuery+")", args...)
+	}
+
+	page, err := pagination.Paginate(query, productPageSpec(productName), params)
+	if err != nil {
 		logger.Error.Printf("[repository.GetAllProducts] Error retrieving products: %v\n", err)
-		return nil, TranslateGormError(err)
+		return pagination.Page[models2.Product]{}, TranslateGormError(err)
 	}
-	if !isQuery {
-		for i, product := range products {
-			numOfOrders, err := GetNumberOfProductOrders(product.ID)
-			if err != nil {
-				logger.Error.Printf("[repository.GetAllProducts] Error retrieving number of orders: %v\n", err)
-				return nil, TranslateGormError(err)
-			}
-			products[i].Amount = uint(numOfOrders)
+
+	return page, nil
+}
+
+// CreateProductWithImages creates a product with its images and a default variant and records its initial stock as a receipt.
+func CreateProductWithImages(product *models2.Product, images []models2.ProductImage, userID uint) error {
+	return CreateProductWithSKU(product, images, "", userID)
+}
+
+// CreateProductWithSKU creates a product like CreateProductWithImages, giving its default variant the seller's SKU.
+// An empty SKU is replaced with a generated one.
+func CreateProductWithSKU(product *models2.Product, images []models2.ProductImage, sku string, userID uint) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		// Создаем продукт в базе данных
+		if err := tx.Create(product).Error; err != nil {
+			logger.Error.Printf("[repository.CreateProductWithSKU] error creating product: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		// Присваиваем ID продукта для всех изображений
+		for i := range images {
+			images[i].ProductID = product.ID
 		}
 
-		sort.Slice(products, func(i, j int) bool {
-			if products[i].Amount == products[j].Amount {
-				return products[i].Views > products[j].Views
+		// Сохраняем все изображения в базе данных
+		if len(images) > 0 {
+			if err := tx.Create(&images).Error; err != nil {
+				logger.Error.Printf("[repository.CreateProductWithSKU] error creating product images: %v\n", err)
+				return TranslateGormError(err)
 			}
-			return products[i].Amount > products[j].Amount
-		})
-	}
-	return products, nil
-}
+		}
 
-func CreateProductWithImages(product *models2.Product, images []models2.ProductImage) error {
-	// Создаем продукт в базе данных
-	if err := db.GetDBConn().Create(product).Error; err != nil {
-		logger.Error.Printf("[repository.CreateProductWithImages] error creating product: %v\n", err)
-		return TranslateGormError(err)
-	}
+		if sku == "" {
+			sku = fmt.Sprintf("P%d-DEFAULT", product.ID)
+		}
 
-	// Присваиваем ID продукта для всех изображений
-	for i := range images {
-		images[i].ProductID = product.ID
-	}
+		// Товар без вариантов продаётся через единственный вариант по умолчанию
+		variant := models2.ProductVariant{
+			ProductID: product.ID,
+			StoreID:   product.StoreID,
+			SKU:       sku,
+			Title:     "default",
+			Price:     product.Price,
+			Amount:    product.Amount,
+			IsDefault: true,
+			Images:    product.ProductImageList,
+		}
+		if err := tx.Create(&variant).Error; err != nil {
+			logger.Error.Printf("[repository.CreateProductWithSKU] error creating default variant: %v\n", err)
+			return TranslateGormError(err)
+		}
+		product.Variants = []models2.ProductVariant{variant}
 
-	// Сохраняем все изображения в базе данных
-	if err := db.GetDBConn().Create(&images).Error; err != nil {
-		logger.Error.Printf("[repository.CreateProductWithImages] error creating product images: %v\n", err)
-		return TranslateGormError(err)
+		if product.Amount == 0 {
+			return nil
+		}
+
+		// Начальный остаток записывается как поступление
+		if err := tx.Create(&models2.InventoryMovement{
+			ProductID:    product.ID,
+			VariantID:    variant.ID,
+			Kind:         models2.MovementReceipt,
+			Quantity:     int(product.Amount),
+			BalanceAfter: product.Amount,
+			UserID:       &userID,
+			Reason:       "initial stock",
+		}).Error; err != nil {
+			logger.Error.Printf("[repository.CreateProductWithSKU] error creating inventory movement: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err == nil {
+		tags := append([]string{productListCacheTag, storeCacheTag(product.StoreID)}, categoryPathCacheTags(db.GetDBConn(), product.CategoryID)...)
+		db.InvalidateCacheTags(tags...)
 	}
 
-	return nil
+	return err
 }
 
-func UpdateProductWithImages(product *models2.Product, images []models2.ProductImage) error {
-	// Обновляем продукт в базе данных
-	if err := db.GetDBConn().Save(product).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateProductWithImages] error updating product: %v\n", err)
-		return TranslateGormError(err)
-	}
+// UpdateProductWithImages saves a product and replaces its images in one transaction.
+// It returns the URLs of the images the product no longer uses, so that their files can be cleaned up.
+func UpdateProductWithImages(product *models2.Product, images []models2.ProductImage) ([]string, error) {
+	// Товар мог сменить категорию, поэтому сбрасываются и прежние, и новые списки
+	tags := append(productCatalogCacheTags(db.GetDBConn(), product.ID), categoryPathCacheTags(db.GetDBConn(), product.CategoryID)...)
 
-	// Удаляем старые изображения
-	if err := db.GetDBConn().Where("product_id = ?", product.ID).Delete(&models2.ProductImage{}).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateProductWithImages] error deleting product image: %v\n", err)
-		return TranslateGormError(err)
-	}
+	var previousImages []string
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models2.ProductImage{}).
+			Where("product_id = ?", product.ID).
+			Pluck("image", &previousImages).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateProductWithImages] error getting product images: %v\n", err)
+			return TranslateGormError(err)
+		}
 
-	// Добавляем новые изображения
-	for i := range images {
-		images[i].ProductID = product.ID
-	}
+		product.ProductImageList = make(pq.StringArray, 0, len(images))
+		for _, image := range images {
+			product.ProductImageList = append(product.ProductImageList, image.Image)
+		}
 
-	// Сохраняем новые изображения
-	if err := db.GetDBConn().Create(&images).Error; err != nil {
-		return TranslateGormError(err)
+		// Обновляем продукт в базе данных, цена и остаток выводятся из вариантов и меняются вместе с ними
+		if err := tx.Omit("amount", "price", clause.Associations).Save(product).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateProductWithImages] error updating product: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		// Удаляем старые изображения
+		if err := tx.Where("product_id = ?", product.ID).Delete(&models2.ProductImage{}).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateProductWithImages] error deleting product image: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		// Сохраняем новые изображения
+		for i := range images {
+			images[i].ProductID = product.ID
+		}
+
+		if len(images) > 0 {
+			if err := tx.Create(&images).Error; err != nil {
+				logger.Error.Printf("[repository.UpdateProductWithImages] error creating product images: %v\n", err)
+				return TranslateGormError(err)
+			}
+		}
+
+		if err := syncDefaultVariantImages(tx, product.ID, product.ProductImageList); err != nil {
+			logger.Error.Printf("[repository.UpdateProductWithImages] error updating default variant: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
+	db.InvalidateCacheTags(tags...)
 
-func UpdateProduct(product *models2.Product) error {
-	if err := db.GetDBConn().Model(&models2.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
-		"amount": product.Amount,
-	}).Error; err != nil {
-		logger.Error.Printf("[repository.UpdateProduct] Error updating product: %v\n", err)
-		return TranslateGormError(err)
+	kept := make(map[string]bool, len(product.ProductImageList))
+	for _, image := range product.ProductImageList {
+		kept[image] = true
 	}
 
-	return nil
+	var removed []string
+	for _, image := range previousImages {
+		if !kept[image] {
+			removed = append(removed, image)
+		}
+	}
+
+	return removed, nil
 }
 
 func GetProductByStoreID(storeID uint) ([]models2.Product, error) {
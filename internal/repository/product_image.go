@@ -3,7 +3,11 @@ package repository
 import (
 	"BizMart/internal/app/models"
 	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetProductImageRepository(productID uint) (productImage models.ProductImage, err error) {
@@ -41,3 +45,102 @@ func DeleteProductImage(productImageID uint) (err error) {
 
 	return nil
 }
+
+// syncDefaultVariantImages keeps the images of the default variant equal to the product images,
+// a product without variants is shown and sold through its default variant.
+func syncDefaultVariantImages(tx *gorm.DB, productID uint, imageURLs pq.StringArray) error {
+	return tx.Model(&models.ProductVariant{}).
+		Where("product_id = ? AND is_default", productID).
+		UpdateColumn("images", imageURLs).Error
+}
+
+// AddProductImages appends uploaded images to a product. At most maxImages images are kept per product.
+func AddProductImages(productID uint, imageURLs []string, maxImages int) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		var product models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "product_image_list").
+			Where("id = ?", productID).
+			Take(&product).Error; err != nil {
+			logger.Error.Printf("[repository.AddProductImages] error getting product: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		if len(product.ProductImageList)+len(imageURLs) > maxImages {
+			return errs.ErrTooManyImages
+		}
+
+		images := make([]models.ProductImage, 0, len(imageURLs))
+		for _, imageURL := range imageURLs {
+			images = append(images, models.ProductImage{ProductID: productID, Image: imageURL})
+		}
+
+		if err := tx.Create(&images).Error; err != nil {
+			logger.Error.Printf("[repository.AddProductImages] error creating product images: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		imageList := append(product.ProductImageList, imageURLs...)
+		if err := tx.Model(&models.Product{}).Where("id = ?", productID).
+			UpdateColumn("product_image_list", imageList).Error; err != nil {
+			logger.Error.Printf("[repository.AddProductImages] error updating product: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		if err := syncDefaultVariantImages(tx, productID, imageList); err != nil {
+			logger.Error.Printf("[repository.AddProductImages] error updating default variant: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err == nil {
+		invalidateProductCache(productID)
+	}
+
+	return err
+}
+
+// SetStoreImage replaces the image of a store and returns the URL of the previous one.
+func SetStoreImage(storeID uint, imageURL string) (string, error) {
+	var previous string
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		var store models.Store
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "image_url").
+			Where("id = ?", storeID).
+			Take(&store).Error; err != nil {
+			return err
+		}
+		previous = store.ImageURL
+
+		return tx.Model(&models.Store{}).Where("id = ?", storeID).UpdateColumn("image_url", imageURL).Error
+	})
+	if err != nil {
+		logger.Error.Printf("[repository.SetStoreImage] error updating store image: %v\n", err)
+		return "", TranslateGormError(err)
+	}
+
+	return previous, nil
+}
+
+// GetReferencedImageURLs returns which of the URLs are still used by products, variants or stores.
+func GetReferencedImageURLs(imageURLs []string) (map[string]bool, error) {
+	var referenced []string
+	if err := db.GetDBConn().Raw(`SELECT u.url FROM unnest(?::text[]) AS u(url)
+		WHERE EXISTS (SELECT 1 FROM productapp_product AS p WHERE u.url = ANY(p.product_image_list))
+			OR EXISTS (SELECT 1 FROM productapp_productvariant AS v WHERE v.deleted_at IS NULL AND u.url = ANY(v.images))
+			OR EXISTS (SELECT 1 FROM productapp_productimage AS i WHERE i.deleted_at IS NULL AND i.image = u.url)
+			OR EXISTS (SELECT 1 FROM stores AS s WHERE s.deleted_at IS NULL AND s.image_url = u.url)`,
+		pq.StringArray(imageURLs)).Scan(&referenced).Error; err != nil {
+		logger.Error.Printf("[repository.GetReferencedImageURLs] error checking image references: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	result := make(map[string]bool, len(referenced))
+	for _, imageURL := range referenced {
+		result[imageURL] = true
+	}
+
+	return result, nil
+}
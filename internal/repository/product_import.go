@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// CreateProductImport saves a new import job.
+func CreateProductImport(productImport *models.ProductImport) error {
+	if err := db.GetDBConn().Create(productImport).Error; err != nil {
+		logger.Error.Printf("[repository.CreateProductImport] error creating product import: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// ClaimProductImport marks the oldest pending import, or a running import whose worker has not saved progress
+// since stalledBefore, as running and returns it with its file. Concurrent workers skip imports claimed by each other.
+func ClaimProductImport(stalledBefore time.Time) (models.ProductImport, error) {
+	var productImport models.ProductImport
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND updated_at < ?)", models.ImportStatusPending, models.ImportStatusRunning, stalledBefore).
+			Order("id").
+			First(&productImport).Error; err != nil {
+			return err
+		}
+
+		productImport.Status = models.ImportStatusRunning
+		productImport.Attempts++
+
+		return tx.Model(&productImport).Updates(map[string]interface{}{
+			"status":   productImport.Status,
+			"attempts": productImport.Attempts,
+		}).Error
+	})
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Error.Printf("[repository.ClaimProductImport] error claiming product import: %v\n", err)
+		}
+		return models.ProductImport{}, TranslateGormError(err)
+	}
+
+	return productImport, nil
+}
+
+// GetProductImportByID returns an import job with its row errors ordered by row, without the uploaded file.
+func GetProductImportByID(importID uint) (models.ProductImport, error) {
+	var productImport models.ProductImport
+	if err := db.GetDBConn().
+		Omit("file_data").
+		Preload("Errors", func(tx *gorm.DB) *gorm.DB { return tx.Order("row, id") }).
+		Where("id = ?", importID).
+		First(&productImport).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductImportByID] error getting product import: %v\n", err)
+		return models.ProductImport{}, TranslateGormError(err)
+	}
+
+	return productImport, nil
+}
+
+// SaveProductImportProgress stores the counters and the status of an import job together with the new row errors.
+// Every save also refreshes updated_at, which tells other workers that the import is still running.
+// The file of a finished import is dropped.
+func SaveProductImportProgress(productImport *models.ProductImport, rowErrors []models.ProductImportError) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if len(rowErrors) > 0 {
+			for i := range rowErrors {
+				rowErrors[i].ImportID = productImport.ID
+			}
+
+			if err := tx.Create(&rowErrors).Error; err != nil {
+				return err
+			}
+		}
+
+		progress := map[string]interface{}{
+			"status":         productImport.Status,
+			"processed_rows": productImport.ProcessedRows,
+			"created_count":  productImport.CreatedCount,
+			"updated_count":  productImport.UpdatedCount,
+			"failed_count":   productImport.FailedCount,
+			"error":          productImport.Error,
+			"finished_at":    productImport.FinishedAt,
+		}
+
+		// Файл нужен только для продолжения прерванного импорта
+		if productImport.FinishedAt != nil {
+			progress["file_data"] = nil
+		}
+
+		return tx.Model(&models.ProductImport{}).Where("id = ?", productImport.ID).Updates(progress).Error
+	})
+	if err != nil {
+		logger.Error.Printf("[repository.SaveProductImportProgress] error saving product import progress: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetStoreCatalog returns the products of a store with their variants for export.
+func GetStoreCatalog(storeID uint) ([]models.Product, error) {
+	var products []models.Product
+	if err := db.GetDBConn().
+		Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("is_default DESC, id") }).
+		Where("store_id = ?", storeID).
+		Order("id").
+		Find(&products).Error; err != nil {
+		logger.Error.Printf("[repository.GetStoreCatalog] error getting store catalog: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return products, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"gorm.io/gorm"
+)
+
+func orderModifiers(tx *gorm.DB) *gorm.DB {
+	return tx.Order("position, id")
+}
+
+func GetModifierGroupsByProductID(productID uint) ([]models.ModifierGroup, error) {
+	return GetModifierGroupsByProductIDs([]uint{productID})
+}
+
+// GetModifierGroupsByProductIDs returns the modifier groups of the given products with their modifiers.
+func GetModifierGroupsByProductIDs(productIDs []uint) ([]models.ModifierGroup, error) {
+	var groups []models.ModifierGroup
+	if err := db.GetDBConn().
+		Preload("Modifiers", orderModifiers).
+		Where("product_id IN ?", productIDs).
+		Order("position, id").
+		Find(&groups).Error; err != nil {
+		logger.Error.Printf("[repository.GetModifierGroupsByProductIDs] error getting modifier groups: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return groups, nil
+}
+
+func GetModifierGroupByID(groupID uint) (models.ModifierGroup, error) {
+	var group models.ModifierGroup
+	if err := db.GetDBConn().Preload("Modifiers", orderModifiers).Where("id = ?", groupID).First(&group).Error; err != nil {
+		logger.Error.Printf("[repository.GetModifierGroupByID] error getting modifier group: %v\n", err)
+		return models.ModifierGroup{}, TranslateGormError(err)
+	}
+
+	return group, nil
+}
+
+// GetModifiersByIDs returns modifiers together with the name of their group.
+func GetModifiersByIDs(modifierIDs []uint) (map[uint]models.OrderItemModifier, error) {
+	var rows []models.OrderItemModifier
+	if err := db.GetDBConn().
+		Table("productapp_modifier AS m").
+		Select("m.id AS modifier_id, g.name AS group_name, m.name, m.price_delta").
+		Joins("JOIN productapp_modifiergroup AS g ON g.id = m.group_id AND g.deleted_at IS NULL").
+		Where("m.id IN ? AND m.deleted_at IS NULL", modifierIDs).
+		Scan(&rows).Error; err != nil {
+		logger.Error.Printf("[repository.GetModifiersByIDs] error getting modifiers: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	modifiers := make(map[uint]models.OrderItemModifier, len(rows))
+	for _, row := range rows {
+		modifiers[row.ModifierID] = row
+	}
+
+	return modifiers, nil
+}
+
+func CreateModifierGroup(group *models.ModifierGroup) error {
+	if err := db.GetDBConn().Create(group).Error; err != nil {
+		logger.Error.Printf("[repository.CreateModifierGroup] error creating modifier group: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	db.InvalidateCacheTags(ProductCacheTags(group.ProductID)...)
+	return nil
+}
+
+// UpdateModifierGroup saves a group and its modifiers: modifiers with an ID are updated, new ones are created
+// and the ones missing from the group are deleted. Modifier IDs are kept so carts referencing them stay valid.
+func UpdateModifierGroup(group *models.ModifierGroup) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ModifierGroup{}).Where("id = ?", group.ID).Updates(map[string]interface{}{
+			"name":           group.Name,
+			"is_required":    group.IsRequired,
+			"min_selections": group.MinSelections,
+			"max_selections": group.MaxSelections,
+			"position":       group.Position,
+		}).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateModifierGroup] error updating modifier group: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		keptIDs := []uint{0}
+		for i := range group.Modifiers {
+			modifier := &group.Modifiers[i]
+			modifier.GroupID = group.ID
+
+			if modifier.ID == 0 {
+				if err := tx.Create(modifier).Error; err != nil {
+					logger.Error.Printf("[repository.UpdateModifierGroup] error creating modifier: %v\n", err)
+					return TranslateGormError(err)
+				}
+			} else if err := tx.Model(&models.Modifier{}).
+				Where("id = ? AND group_id = ?", modifier.ID, group.ID).
+				Updates(map[string]interface{}{
+					"name":        modifier.Name,
+					"price_delta": modifier.PriceDelta,
+					"sold_out":    modifier.SoldOut,
+					"position":    modifier.Position,
+				}).Error; err != nil {
+				logger.Error.Printf("[repository.UpdateModifierGroup] error updating modifier: %v\n", err)
+				return TranslateGormError(err)
+			}
+
+			keptIDs = append(keptIDs, modifier.ID)
+		}
+
+		if err := tx.Where("group_id = ? AND id NOT IN ?", group.ID, keptIDs).Delete(&models.Modifier{}).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateModifierGroup] error deleting modifiers: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err == nil {
+		db.InvalidateCacheTags(ProductCacheTags(group.ProductID)...)
+	}
+
+	return err
+}
+
+// DeleteModifierGroup deletes a group with its modifiers, orders keep their snapshot of the chosen modifiers.
+func DeleteModifierGroup(groupID uint) error {
+	var group models.ModifierGroup
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "product_id").Where("id = ?", groupID).Take(&group).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteModifierGroup] error getting modifier group: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		if err := tx.Where("group_id = ?", groupID).Delete(&models.Modifier{}).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteModifierGroup] error deleting modifiers: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		if err := tx.Delete(&models.ModifierGroup{}, groupID).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteModifierGroup] error deleting modifier group: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+	if err == nil {
+		db.InvalidateCacheTags(ProductCacheTags(group.ProductID)...)
+	}
+
+	return err
+}
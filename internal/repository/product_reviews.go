@@ -4,16 +4,36 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/pkg/db"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
 )
 
-func GetAllProductReviews(productID uint) ([]models.Review, error) {
-	var reviews []models.Review
-	if err := db.GetDBConn().Model(models.Review{}).Where("product_id = ?", productID).Find(&reviews).Error; err != nil {
+var productReviewPageSpec = pagination.Spec[models.Review]{
+	Sorts: map[string]pagination.Sort[models.Review]{
+		"created_at": {
+			Columns: []string{"reviewapp_review.created_at", "reviewapp_review.id"},
+			Key:     func(r models.Review) []interface{} { return []interface{}{r.CreatedAt, r.ID} },
+		},
+		"rating": {
+			Columns: []string{"reviewapp_review.rating", "reviewapp_review.id"},
+			Key:     func(r models.Review) []interface{} { return []interface{}{r.Rating, r.ID} },
+		},
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	Fields:       []string{"id", "user_id", "product_id", "title", "content", "rating", "created_at", "updated_at"},
+	CountTotal:   true,
+}
+
+func GetAllProductReviews(productID uint, params pagination.Params) (pagination.Page[models.Review], error) {
+	query := db.GetDBConn().Model(models.Review{}).Where("product_id = ?", productID)
+
+	page, err := pagination.Paginate(query, productReviewPageSpec, params)
+	if err != nil {
 		logger.Error.Printf("[repository.GetAllProductReview] Error getting product reviews: %v", err)
-		return nil, TranslateGormError(err)
+		return pagination.Page[models.Review]{}, TranslateGormError(err)
 	}
 
-	return reviews, nil
+	return page, nil
 }
 
 func GetProductReviewByID(ProductReviewID uint) (models.Review, error) {
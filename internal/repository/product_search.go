@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Поиск работает по колонке search_vector (русская и английская конфигурации, см. db.setupProductSearch),
+// опечатки в названии ловятся триграммной похожестью pg_trgm.
+const (
+	productSearchTSQuery = "(websearch_to_tsquery('russian', ?) || websearch_to_tsquery('english', ?))"
+
+	productSearchCondition = "(productapp_product.search_vector @@ " + productSearchTSQuery +
+		" OR word_similarity(lower(?), lower(productapp_product.title)) >= ?)"
+
+	productSearchRank = "(ts_rank_cd(productapp_product.search_vector, " + productSearchTSQuery + ")" +
+		" + word_similarity(lower(?), lower(productapp_product.title)))"
+
+	productSearchSnippet = "ts_headline('russian', productapp_product.title || '. ' || productapp_product.description, " +
+		productSearchTSQuery + ", 'StartSel=<b>, StopSel=</b>, MaxWords=30, MinWords=10, MaxFragments=2')"
+
+	// productSearchSimilarity минимальная похожесть слова запроса на слово в названии, при которой товар считается найденным
+	productSearchSimilarity = 0.4
+)
+
+// Фильтры, которые фасет не применяет к себе
+const (
+	facetCategory = "category"
+	facetStore    = "store"
+	facetPrice    = "price"
+)
+
+func searchProductsQuery(params models.ProductSearchParams, skipFacet string) *gorm.DB {
+	query := db.GetDBConn().
+		Table("productapp_product").
+		Where("productapp_product.amount > 0").
+		Where(productSearchCondition, params.Query, params.Query, params.Query, productSearchSimilarity)
+
+	if params.CategoryID > 0 && skipFacet != facetCategory {
+		query = query.Where("productapp_product.category_id IN ("+categorySubtreeQuery+")", params.CategoryID)
+	}
+	if params.StoreID > 0 && skipFacet != facetStore {
+		query = query.Where("productapp_product.store_id = ?", params.StoreID)
+	}
+	if params.MinPrice > 0 && skipFacet != facetPrice {
+		query = query.Where("productapp_product.price >= ?", params.MinPrice)
+	}
+	if params.MaxPrice > 0 && skipFacet != facetPrice {
+		query = query.Where("productapp_product.price <= ?", params.MaxPrice)
+	}
+
+	return query
+}
+
+// SearchProducts returns a page of products matching the query ordered by relevance and the total number of matches.
+func SearchProducts(params models.ProductSearchParams) ([]models.ProductSearchHit, int64, error) {
+	var total int64
+	if err := searchProductsQuery(params, "").Count(&total).Error; err != nil {
+		logger.Error.Printf("[repository.SearchProducts] error counting products: %v\n", err)
+		return nil, 0, TranslateGormError(err)
+	}
+
+	hits := []models.ProductSearchHit{}
+	if total == 0 {
+		return hits, 0, nil
+	}
+
+	q := params.Query
+	if err := searchProductsQuery(params, "").
+		Select("productapp_product.id, productapp_product.store_id, s.name AS store_name, "+
+			"productapp_product.category_id, c.category_name, productapp_product.title, productapp_product.price, "+
+			"productapp_product.amount, productapp_product.product_image_list, "+
+			productSearchRank+" AS rank, "+productSearchSnippet+" AS snippet",
+			q, q, q, q, q).
+		Joins("JOIN stores AS s ON s.id = productapp_product.store_id").
+		Joins("JOIN categoryapp_category AS c ON c.id = productapp_product.category_id").
+		Order("rank DESC, productapp_product.views DESC, productapp_product.id").
+		Limit(params.Limit).
+		Offset(params.Offset).
+		Scan(&hits).Error; err != nil {
+		logger.Error.Printf("[repository.SearchProducts] error searching products: %v\n", err)
+		return nil, 0, TranslateGormError(err)
+	}
+
+	return hits, total, nil
+}
+
+// GetProductSearchFacets counts the products matching the query by category, store and price bucket.
+// Bounds split prices into ranges: below bounds[0], [bounds[i-1], bounds[i]) and from the last bound up.
+func GetProductSearchFacets(params models.ProductSearchParams, bounds []float64) (models.ProductSearchFacets, error) {
+	facets := models.ProductSearchFacets{Categories: []models.FacetCount{}, Stores: []models.FacetCount{}}
+
+	if err := searchProductsQuery(params, facetCategory).
+		Select("c.id, c.category_name AS name, COUNT(*) AS count").
+		Joins("JOIN categoryapp_category AS c ON c.id = productapp_product.category_id").
+		Group("c.id, c.category_name").
+		Order("count DESC, c.id").
+		Scan(&facets.Categories).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductSearchFacets] error counting categories: %v\n", err)
+		return facets, TranslateGormError(err)
+	}
+
+	if err := searchProductsQuery(params, facetStore).
+		Select("s.id, s.name, COUNT(*) AS count").
+		Joins("JOIN stores AS s ON s.id = productapp_product.store_id").
+		Group("s.id, s.name").
+		Order("count DESC, s.id").
+		Scan(&facets.Stores).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductSearchFacets] error counting stores: %v\n", err)
+		return facets, TranslateGormError(err)
+	}
+
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+	if err := searchProductsQuery(params, facetPrice).
+		Select("width_bucket(productapp_product.price, ?::float8[]) AS bucket, COUNT(*) AS count", pq.Float64Array(bounds)).
+		Group("bucket").
+		Scan(&buckets).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductSearchFacets] error counting price buckets: %v\n", err)
+		return facets, TranslateGormError(err)
+	}
+
+	counts := make([]int64, len(bounds)+1)
+	for _, bucket := range buckets {
+		if bucket.Bucket >= 0 && bucket.Bucket < len(counts) {
+			counts[bucket.Bucket] = bucket.Count
+		}
+	}
+
+	facets.PriceBuckets = make([]models.PriceBucketCount, 0, len(counts))
+	for i, count := range counts {
+		priceBucket := models.PriceBucketCount{Count: count}
+		if i > 0 {
+			priceBucket.From = bounds[i-1]
+		}
+		if i < len(bounds) {
+			priceBucket.To = bounds[i]
+		}
+		facets.PriceBuckets = append(facets.PriceBuckets, priceBucket)
+	}
+
+	return facets, nil
+}
+
+// clauseOrderBySimilarity sorts rows by how close the column is to the query, the most similar first.
+func clauseOrderBySimilarity(column, query string) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  "word_similarity(lower(?), lower(" + column + ")) DESC",
+		Vars: []interface{}{query},
+	}}
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// refreshProductAggregates re-derives the price (the lowest variant price) and the total stock of a product from its variants.
+func refreshProductAggregates(tx *gorm.DB, productID uint) error {
+	if err := tx.Exec(`UPDATE productapp_product SET
+			amount = (SELECT COALESCE(SUM(v.amount), 0) FROM productapp_productvariant AS v WHERE v.product_id = ? AND v.deleted_at IS NULL),
+			price = COALESCE((SELECT MIN(v.price) FROM productapp_productvariant AS v WHERE v.product_id = ? AND v.deleted_at IS NULL), price)
+		WHERE id = ?`, productID, productID, productID).Error; err != nil {
+		logger.Error.Printf("[repository.refreshProductAggregates] error refreshing product %d: %v\n", productID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+func GetVariantByID(variantID uint) (models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := db.GetDBConn().Preload("Options").Where("id = ?", variantID).First(&variant).Error; err != nil {
+		logger.Error.Printf("[repository.GetVariantByID] error getting variant: %v\n", err)
+		return models.ProductVariant{}, TranslateGormError(err)
+	}
+
+	return variant, nil
+}
+
+func GetVariantsByProductID(productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := db.GetDBConn().Preload("Options").Where("product_id = ?", productID).Order("id").Find(&variants).Error; err != nil {
+		logger.Error.Printf("[repository.GetVariantsByProductID] error getting variants: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return variants, nil
+}
+
+func GetDefaultVariant(productID uint) (models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := db.GetDBConn().Where("product_id = ? AND is_default", productID).First(&variant).Error; err != nil {
+		logger.Error.Printf("[repository.GetDefaultVariant] error getting default variant: %v\n", err)
+		return models.ProductVariant{}, TranslateGormError(err)
+	}
+
+	return variant, nil
+}
+
+// CreateVariant creates a variant with its options and records its initial stock as a receipt.
+func CreateVariant(variant *models.ProductVariant, userID uint) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(variant).Error; err != nil {
+			logger.Error.Printf("[repository.CreateVariant] error creating variant: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		if variant.Amount > 0 {
+			if err := tx.Create(&models.InventoryMovement{
+				ProductID:    variant.ProductID,
+				VariantID:    variant.ID,
+				Kind:         models.MovementReceipt,
+				Quantity:     int(variant.Amount),
+				BalanceAfter: variant.Amount,
+				UserID:       &userID,
+				Reason:       "initial stock",
+			}).Error; err != nil {
+				logger.Error.Printf("[repository.CreateVariant] error creating inventory movement: %v\n", err)
+				return TranslateGormError(err)
+			}
+		}
+
+		return refreshProductAggregates(tx, variant.ProductID)
+	})
+	if err == nil {
+		invalidateProductCache(variant.ProductID)
+	}
+
+	return err
+}
+
+// UpdateVariant saves the SKU, title, price, images and options of a variant, its stock is changed only by movements.
+func UpdateVariant(variant *models.ProductVariant) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ProductVariant{}).Where("id = ?", variant.ID).Updates(map[string]interface{}{
+			"sku":    variant.SKU,
+			"title":  variant.Title,
+			"price":  variant.Price,
+			"images": variant.Images,
+		}).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateVariant] error updating variant: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		if err := tx.Where("variant_id = ?", variant.ID).Delete(&models.VariantOption{}).Error; err != nil {
+			logger.Error.Printf("[repository.UpdateVariant] error deleting variant options: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		for i := range variant.Options {
+			variant.Options[i].ID = 0
+			variant.Options[i].VariantID = variant.ID
+		}
+
+		if len(variant.Options) > 0 {
+			if err := tx.Create(&variant.Options).Error; err != nil {
+				logger.Error.Printf("[repository.UpdateVariant] error creating variant options: %v\n", err)
+				return TranslateGormError(err)
+			}
+		}
+
+		return refreshProductAggregates(tx, variant.ProductID)
+	})
+	if err == nil {
+		invalidateProductCache(variant.ProductID)
+	}
+
+	return err
+}
+
+// DeleteVariant marks a variant as deleted and excludes it from the product price and stock.
+func DeleteVariant(variant models.ProductVariant) error {
+	err := db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.ProductVariant{}, variant.ID).Error; err != nil {
+			logger.Error.Printf("[repository.DeleteVariant] error deleting variant: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return refreshProductAggregates(tx, variant.ProductID)
+	})
+	if err == nil {
+		invalidateProductCache(variant.ProductID)
+	}
+
+	return err
+}
+
+// LockProductVariants locks every variant of a product.
+func (uow *UnitOfWork) LockProductVariants(productID uint) ([]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := uow.forUpdate().Where("product_id = ?", productID).Order("id").Find(&variants).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockProductVariants] error locking variants: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return variants, nil
+}
+
+// UpdateVariantPrice changes the price of a variant and the product price derived from it.
+func (uow *UnitOfWork) UpdateVariantPrice(variant models.ProductVariant, price float64) error {
+	if err := uow.tx.Model(&models.ProductVariant{}).Where("id = ?", variant.ID).Update("price", price).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateVariantPrice] error updating variant price: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	uow.invalidateCache(productCatalogCacheTags(uow.tx, variant.ProductID)...)
+	return refreshProductAggregates(uow.tx, variant.ProductID)
+}
+
+// RefreshProductAggregates re-derives the price and the stock of a product from its variants.
+func (uow *UnitOfWork) RefreshProductAggregates(productID uint) error {
+	uow.invalidateCache(productCatalogCacheTags(uow.tx, productID)...)
+	return refreshProductAggregates(uow.tx, productID)
+}
+
+// GetVariantBySKU looks a live variant of the store up by SKU.
+func GetVariantBySKU(storeID uint, sku string) (models.ProductVariant, error) {
+	var variant models.ProductVariant
+	if err := db.GetDBConn().Where("store_id = ? AND sku = ?", storeID, sku).First(&variant).Error; err != nil {
+		logger.Error.Printf("[repository.GetVariantBySKU] error getting variant: %v\n", err)
+		return models.ProductVariant{}, TranslateGormError(err)
+	}
+
+	return variant, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// statementLog запоминает команды, которые gorm отправил в базу: BEGIN, COMMIT, ROLLBACK и первое слово запросов
+type statementLog struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (l *statementLog) record(statement string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.statements = append(l.statements, statement)
+}
+
+func (l *statementLog) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.statements...)
+}
+
+// newRecordingDB возвращает gorm поверх драйвера, который ничего не выполняет, а только записывает команды.
+// Так транзакции проверяются без PostgreSQL
+func newRecordingDB(t *testing.T) (*gorm.DB, *statementLog) {
+	t.Helper()
+
+	log := &statementLog{}
+	conn, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(recordingConnector{log: log})}), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn, log
+}
+
+type recordingConnector struct {
+	log *statementLog
+}
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) {
+	return recordingConn(c), nil
+}
+
+func (c recordingConnector) Driver() driver.Driver {
+	return recordingDriver(c)
+}
+
+type recordingDriver struct {
+	log *statementLog
+}
+
+func (d recordingDriver) Open(string) (driver.Conn, error) {
+	return recordingConn(d), nil
+}
+
+type recordingConn struct {
+	log *statementLog
+}
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{log: c.log, query: query}, nil
+}
+
+func (c recordingConn) Close() error {
+	return nil
+}
+
+func (c recordingConn) Begin() (driver.Tx, error) {
+	c.log.record("BEGIN")
+	return recordingTx(c), nil
+}
+
+type recordingTx struct {
+	log *statementLog
+}
+
+func (tx recordingTx) Commit() error {
+	tx.log.record("COMMIT")
+	return nil
+}
+
+func (tx recordingTx) Rollback() error {
+	tx.log.record("ROLLBACK")
+	return nil
+}
+
+type recordingStmt struct {
+	log   *statementLog
+	query string
+}
+
+func (s recordingStmt) Close() error {
+	return nil
+}
+
+func (s recordingStmt) NumInput() int {
+	return -1
+}
+
+func (s recordingStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.log.record(strings.Fields(s.query)[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.log.record(strings.Fields(s.query)[0])
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return nil
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next([]driver.Value) error {
+	return io.EOF
+}
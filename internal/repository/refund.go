@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"gorm.io/gorm"
+)
+
+func GetRefundsByPaymentID(paymentID uint) ([]models.Refund, error) {
+	var refunds []models.Refund
+	if err := db.GetDBConn().
+		Preload("Items").
+		Where("payment_id = ?", paymentID).
+		Order("id").
+		Find(&refunds).Error; err != nil {
+		logger.Error.Printf("[repository.GetRefundsByPaymentID] error getting refunds: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return refunds, nil
+}
+
+// LockPayment locks a payment row.
+func (uow *UnitOfWork) LockPayment(paymentID uint) (models.Payment, error) {
+	var payment models.Payment
+	if err := uow.forUpdate().Where("id = ?", paymentID).First(&payment).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockPayment] error locking payment: %v\n", err)
+		return models.Payment{}, TranslateGormError(err)
+	}
+
+	return payment, nil
+}
+
+// LockPaymentByOrderID locks the payment of an order.
+func (uow *UnitOfWork) LockPaymentByOrderID(orderID uint) (models.Payment, error) {
+	var payment models.Payment
+	if err := uow.forUpdate().Where("order_id = ?", orderID).First(&payment).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockPaymentByOrderID] error locking payment: %v\n", err)
+		return models.Payment{}, TranslateGormError(err)
+	}
+
+	return payment, nil
+}
+
+// CreateRefund inserts a refund together with its items.
+func (uow *UnitOfWork) CreateRefund(refund *models.Refund) error {
+	if err := uow.tx.Create(refund).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreateRefund] error creating refund: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// AddRefundedQuantity marks a part of an order line as refunded.
+func (uow *UnitOfWork) AddRefundedQuantity(orderItemID, quantity uint) error {
+	if err := uow.tx.Model(&models.OrderItem{}).
+		Where("id = ?", orderItemID).
+		UpdateColumn("refunded_quantity", gorm.Expr("refunded_quantity + ?", quantity)).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.AddRefundedQuantity] error updating order item: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// DeleteOrder removes an order with its details and items.
+func (uow *UnitOfWork) DeleteOrder(order models.Order) error {
+	if err := uow.tx.Where("order_id = ?", order.ID).Delete(&models.OrderItem{}).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.DeleteOrder] error deleting order items: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	if err := uow.tx.Delete(&models.Order{}, order.ID).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.DeleteOrder] error deleting order: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	if err := uow.tx.Delete(&models.OrderDetails{}, order.OrderDetailsID).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.DeleteOrder] error deleting orderDetails: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
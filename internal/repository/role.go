@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var roleAuditPageSpec = pagination.Spec[models.RoleAudit]{
+	Sorts: map[string]pagination.Sort[models.RoleAudit]{
+		"created_at": {
+			Columns: []string{"userapp_roleaudit.created_at", "userapp_roleaudit.id"},
+			Key:     func(a models.RoleAudit) []interface{} { return []interface{}{a.CreatedAt, a.ID} },
+		},
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+}
+
+// GetRoles returns all roles with their permissions.
+func GetRoles() (roles []models.Role, err error) {
+	if err = db.GetDBConn().Preload("Permissions", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("code")
+	}).Order("id").Find(&roles).Error; err != nil {
+		logger.Error.Printf("[repository.GetRoles] error getting roles: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return roles, nil
+}
+
+// GetRoleByCode returns a role by its code.
+func GetRoleByCode(code string) (role models.Role, err error) {
+	if err = db.GetDBConn().Where("code = ?", code).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return role, errs.ErrRoleNotFound
+		}
+
+		logger.Error.Printf("[repository.GetRoleByCode] error getting role %s: %v\n", code, err)
+		return role, TranslateGormError(err)
+	}
+
+	return role, nil
+}
+
+// GetUserRoles returns the roles granted to a user with their permissions.
+func GetUserRoles(userID uint) (userRoles []models.UserRole, err error) {
+	if err = db.GetDBConn().Preload("Role.Permissions").
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&userRoles).Error; err != nil {
+		logger.Error.Printf("[repository.GetUserRoles] error getting roles of user %d: %v\n", userID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return userRoles, nil
+}
+
+// GetUserRoleCodes returns the codes of the roles granted to a user.
+func GetUserRoleCodes(userID uint) (codes []string, err error) {
+	if err = db.GetDBConn().Model(&models.UserRole{}).
+		Joins("JOIN userapp_role AS r ON r.id = userapp_userrole.role_id").
+		Where("userapp_userrole.user_id = ?", userID).
+		Order("r.code").
+		Pluck("r.code", &codes).Error; err != nil {
+		logger.Error.Printf("[repository.GetUserRoleCodes] error getting roles of user %d: %v\n", userID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return codes, nil
+}
+
+// GetRolePermissions returns the permission codes of every role by role code.
+func GetRolePermissions() (map[string][]string, error) {
+	var rows []struct {
+		RoleCode       string
+		PermissionCode string
+	}
+
+	if err := db.GetDBConn().Table("userapp_rolepermission AS rp").
+		Select("r.code AS role_code, p.code AS permission_code").
+		Joins("JOIN userapp_role AS r ON r.id = rp.role_id").
+		Joins("JOIN userapp_permission AS p ON p.id = rp.permission_id").
+		Scan(&rows).Error; err != nil {
+		logger.Error.Printf("[repository.GetRolePermissions] error getting role permissions: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	permissions := make(map[string][]string)
+	for _, row := range rows {
+		permissions[row.RoleCode] = append(permissions[row.RoleCode], row.PermissionCode)
+	}
+
+	return permissions, nil
+}
+
+// GrantUserRole grants a role to a user and records the grant in the audit trail.
+// It returns false if the user already has the role, nothing is recorded then.
+func GrantUserRole(userID uint, role models.Role, actorID *uint, reason string) (granted bool, err error) {
+	err = db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).
+			Create(&models.UserRole{UserID: userID, RoleID: role.ID, GrantedByID: actorID})
+		if result.Error != nil {
+			logger.Error.Printf("[repository.GrantUserRole] error granting role %s to user %d: %v\n", role.Code, userID, result.Error)
+			return TranslateGormError(result.Error)
+		}
+
+		if granted = result.RowsAffected > 0; !granted {
+			return nil
+		}
+
+		return createRoleAudit(tx, userID, role.Code, models.RoleAuditGrant, actorID, reason)
+	})
+
+	return granted, err
+}
+
+// RevokeUserRole revokes a role from a user and records the revocation in the audit trail.
+// It returns false if the user doesn't have the role, nothing is recorded then.
+func RevokeUserRole(userID uint, role models.Role, actorID *uint, reason string) (revoked bool, err error) {
+	err = db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND role_id = ?", userID, role.ID).Delete(&models.UserRole{})
+		if result.Error != nil {
+			logger.Error.Printf("[repository.RevokeUserRole] error revoking role %s from user %d: %v\n", role.Code, userID, result.Error)
+			return TranslateGormError(result.Error)
+		}
+
+		if revoked = result.RowsAffected > 0; !revoked {
+			return nil
+		}
+
+		return createRoleAudit(tx, userID, role.Code, models.RoleAuditRevoke, actorID, reason)
+	})
+
+	return revoked, err
+}
+
+func createRoleAudit(tx *gorm.DB, userID uint, roleCode, action string, actorID *uint, reason string) error {
+	if err := tx.Create(&models.RoleAudit{
+		UserID:   userID,
+		RoleCode: roleCode,
+		Action:   action,
+		ActorID:  actorID,
+		Reason:   reason,
+	}).Error; err != nil {
+		logger.Error.Printf("[repository.createRoleAudit] error recording role %s of user %d: %v\n", action, userID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetRoleAudit returns a page of role grants and revocations, newest first. userID 0 returns records of all users.
+func GetRoleAudit(userID uint, params pagination.Params) (pagination.Page[models.RoleAudit], error) {
+	query := db.GetDBConn().Model(&models.RoleAudit{})
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	page, err := pagination.Paginate(query, roleAuditPageSpec, params)
+	if err != nil {
+		logger.Error.Printf("[repository.GetRoleAudit] error getting role audit: %v\n", err)
+		return pagination.Page[models.RoleAudit]{}, TranslateGormError(err)
+	}
+
+	return page, nil
+}
+
+// SetUserRolesChangedAt records that roles of a user were revoked, access tokens issued earlier are no longer valid.
+func SetUserRolesChangedAt(userID uint, changedAt time.Time) error {
+	if err := db.GetDBConn().Model(&models.User{}).Where("id = ?", userID).
+		Update("roles_changed_at", changedAt).Error; err != nil {
+		logger.Error.Printf("[repository.SetUserRolesChangedAt] error updating user %d: %v\n", userID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
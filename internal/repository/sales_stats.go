@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+// salesStatsUpsert adds the selected counters to the existing stats rows.
+const salesStatsUpsert = `INSERT INTO %[1]s (%[2]s, orders_count, paid_orders_count, units_sold, revenue, updated_at)
+	%[3]s
+	ON CONFLICT (%[2]s) DO UPDATE SET
+		orders_count = %[1]s.orders_count + EXCLUDED.orders_count,
+		paid_orders_count = %[1]s.paid_orders_count + EXCLUDED.paid_orders_count,
+		units_sold = %[1]s.units_sold + EXCLUDED.units_sold,
+		revenue = %[1]s.revenue + EXCLUDED.revenue,
+		updated_at = EXCLUDED.updated_at`
+
+// salesStatsTarget describes how order items are grouped into a stats table.
+type salesStatsTarget struct {
+	table   string
+	key     string
+	keyExpr string
+	join    string
+}
+
+var salesStatsTargets = []salesStatsTarget{
+	{
+		table:   models.ProductSalesStats{}.TableName(),
+		key:     "product_id",
+		keyExpr: "oi.product_id",
+	},
+	{
+		table:   models.StoreSalesStats{}.TableName(),
+		key:     "store_id",
+		keyExpr: "p.store_id",
+		join:    "JOIN productapp_product AS p ON p.id = oi.product_id",
+	},
+}
+
+// applySalesStats groups the matching order items (aliased oi) by product and by store and adds the counters
+// computed by values to the stats tables. Rows are upserted in key order so that concurrent updates can't deadlock.
+func applySalesStats(tx *gorm.DB, values, from, where string, args ...interface{}) error {
+	for _, target := range salesStatsTargets {
+		selectQuery := fmt.Sprintf("SELECT %[1]s, %[2]s, NOW() %[3]s %[4]s WHERE %[5]s GROUP BY %[1]s ORDER BY %[1]s",
+			target.keyExpr, values, from, target.join, where)
+
+		if err := tx.Exec(fmt.Sprintf(salesStatsUpsert, target.table, target.key, selectQuery), args...).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordOrderPlaced counts a new order for every product and store in it.
+func (uow *UnitOfWork) RecordOrderPlaced(orderID uint) error {
+	if err := applySalesStats(uow.tx, "CAST(1 AS bigint), 0, 0, 0", "FROM orderapp_orderitem AS oi",
+		"oi.order_id = ?", orderID); err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.RecordOrderPlaced] error updating sales stats: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// RecordOrderDeleted removes an unpaid order from the counters, it must be called before the order items are deleted.
+func (uow *UnitOfWork) RecordOrderDeleted(orderID uint) error {
+	if err := applySalesStats(uow.tx, "CAST(-1 AS bigint), 0, 0, 0", "FROM orderapp_orderitem AS oi",
+		"oi.order_id = ?", orderID); err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.RecordOrderDeleted] error updating sales stats: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// RecordOrderPaid adds the units and revenue of a paid order to the counters.
+func (uow *UnitOfWork) RecordOrderPaid(orderID uint) error {
+	if err := applySalesStats(uow.tx, "0, CAST(1 AS bigint), SUM(oi.quantity), SUM(oi.price * oi.quantity)",
+		"FROM orderapp_orderitem AS oi", "oi.order_id = ?", orderID); err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.RecordOrderPaid] error updating sales stats: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// RecordRefund subtracts the refunded units and amounts from the counters.
+func (uow *UnitOfWork) RecordRefund(refundID uint) error {
+	if err := applySalesStats(uow.tx, "0, 0, -SUM(ri.quantity), -SUM(ri.amount)",
+		"FROM refundapp_refunditem AS ri JOIN orderapp_orderitem AS oi ON oi.id = ri.order_item_id",
+		"ri.refund_id = ?", refundID); err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.RecordRefund] error updating sales stats: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// salesStatsRebuildBatch is how many keys of a stats table are reconciled in one transaction.
+const salesStatsRebuildBatch = 1000
+
+// salesStatsRebuildFrom selects the order items counted by the rebuild, aliased oi, with paid.is_paid telling
+// whether the order is paid. Refunds are already subtracted from the items.
+const salesStatsRebuildFrom = "FROM orderapp_orderitem AS oi " +
+	"JOIN orderapp_order AS o ON o.id = oi.order_id AND o.deleted_at IS NULL " +
+	"CROSS JOIN LATERAL (SELECT EXISTS (SELECT 1 FROM payapp_payment AS pay " +
+	"WHERE pay.order_id = o.id AND pay.deleted_at IS NULL) AS is_paid) AS paid "
+
+// RebuildSalesStats recomputes all sales counters from the orders, payments and refunds.
+// Tables are reconciled in batches of keys without a table lock, so readers and writers of other keys never wait.
+func RebuildSalesStats() error {
+	for _, target := range salesStatsTargets {
+		var maxKey uint
+		if err := db.GetDBConn().Raw(fmt.Sprintf("SELECT COALESCE(GREATEST((SELECT MAX(%[1]s) FROM %[2]s), (SELECT MAX(%[3]s) %[4]s %[5]s)), 0)",
+			target.key, target.table, target.keyExpr, salesStatsRebuildFrom, target.join)).Scan(&maxKey).Error; err != nil {
+			logger.Error.Printf("[repository.RebuildSalesStats] error getting stats keys: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		for from := uint(0); from <= maxKey; from += salesStatsRebuildBatch {
+			if err := rebuildSalesStatsBatch(target, from, from+salesStatsRebuildBatch); err != nil {
+				logger.Error.Printf("[repository.RebuildSalesStats] error rebuilding %s: %v\n", target.table, err)
+				return TranslateGormError(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rebuildSalesStatsBatch overwrites the counters of the keys in [from, to) with values computed from the orders.
+// Writers update counters incrementally in the same transaction as the orders, so the batch first locks its stats rows
+// in key order, like the writers do: a writer that already touched a row commits before the counters are computed,
+// a writer that comes later waits and adds its change on top of the rebuilt value.
+func rebuildSalesStatsBatch(target salesStatsTarget, from, to uint) error {
+	keyRange := fmt.Sprintf("%s >= ? AND %s < ?", target.keyExpr, target.keyExpr)
+
+	// Строки для ключей, у которых есть заказы, создаются заранее отдельной транзакцией, чтобы их тоже можно было заблокировать
+	if err := db.GetDBConn().Exec(fmt.Sprintf(`INSERT INTO %[1]s (%[2]s, updated_at)
+		SELECT DISTINCT %[3]s, NOW() %[4]s %[5]s WHERE %[6]s ORDER BY 1
+		ON CONFLICT (%[2]s) DO NOTHING`,
+		target.table, target.key, target.keyExpr, salesStatsRebuildFrom, target.join, keyRange), from, to).Error; err != nil {
+		return err
+	}
+
+	return db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SELECT %[2]s FROM %[1]s WHERE %[2]s >= ? AND %[2]s < ? ORDER BY %[2]s FOR UPDATE",
+			target.table, target.key), from, to).Error; err != nil {
+			return err
+		}
+
+		// Заказ считается оплаченным, если по нему есть платёж
+		return tx.Exec(fmt.Sprintf(`UPDATE %[1]s AS s SET
+			orders_count = COALESCE(c.orders_count, 0),
+			paid_orders_count = COALESCE(c.paid_orders_count, 0),
+			units_sold = COALESCE(c.units_sold, 0),
+			revenue = COALESCE(c.revenue, 0),
+			updated_at = NOW()
+		FROM %[1]s AS k
+		LEFT JOIN (
+			SELECT %[3]s AS stats_key,
+				COUNT(DISTINCT oi.order_id) AS orders_count,
+				COUNT(DISTINCT oi.order_id) FILTER (WHERE paid.is_paid) AS paid_orders_count,
+				COALESCE(SUM(oi.quantity - oi.refunded_quantity) FILTER (WHERE paid.is_paid), 0) AS units_sold,
+				COALESCE(SUM(oi.price * (oi.quantity - oi.refunded_quantity)) FILTER (WHERE paid.is_paid), 0) AS revenue
+			%[4]s %[5]s
+			WHERE %[6]s
+			GROUP BY %[3]s
+		) AS c ON c.stats_key = k.%[2]s
+		WHERE s.%[2]s = k.%[2]s AND k.%[2]s >= ? AND k.%[2]s < ?`,
+			target.table, target.key, target.keyExpr, salesStatsRebuildFrom, target.join, keyRange), from, to, from, to).Error
+	})
+}
+
+// GetProductSalesStats returns the sales counters of a product, a product without orders has zero counters.
+func GetProductSalesStats(productID uint) (models.ProductSalesStats, error) {
+	var stats models.ProductSalesStats
+	if err := db.GetDBConn().Where("product_id = ?", productID).Limit(1).Find(&stats).Error; err != nil {
+		logger.Error.Printf("[repository.GetProductSalesStats] error getting product sales stats: %v\n", err)
+		return models.ProductSalesStats{}, TranslateGormError(err)
+	}
+
+	stats.ProductID = productID
+	return stats, nil
+}
+
+// GetStoreSalesStats returns the sales counters of a store, a store without orders has zero counters.
+func GetStoreSalesStats(storeID uint) (models.StoreSalesStats, error) {
+	var stats models.StoreSalesStats
+	if err := db.GetDBConn().Where("store_id = ?", storeID).Limit(1).Find(&stats).Error; err != nil {
+		logger.Error.Printf("[repository.GetStoreSalesStats] error getting store sales stats: %v\n", err)
+		return models.StoreSalesStats{}, TranslateGormError(err)
+	}
+
+	stats.StoreID = storeID
+	return stats, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"time"
+)
+
+// CreateStockReservations stores reservations of a new order.
+func (uow *UnitOfWork) CreateStockReservations(reservations []models.StockReservation) error {
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	if err := uow.tx.Create(&reservations).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreateStockReservations] error creating stock reservations: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// LockActiveReservations locks the active reservations of an order.
+func (uow *UnitOfWork) LockActiveReservations(orderID uint) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	if err := uow.forUpdate().
+		Where("order_id = ? AND status = ?", orderID, models.ReservationActive).
+		Order("id").
+		Find(&reservations).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockActiveReservations] error locking stock reservations: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return reservations, nil
+}
+
+// CloseActiveReservations moves every active reservation of an order to the given status.
+func (uow *UnitOfWork) CloseActiveReservations(orderID uint, status string) error {
+	if err := uow.tx.Model(&models.StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, models.ReservationActive).
+		Update("status", status).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CloseActiveReservations] error updating stock reservations: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// UpdateReservationQuantity changes the quantity of the active reservation of an order item.
+func (uow *UnitOfWork) UpdateReservationQuantity(orderItemID, quantity uint) error {
+	if err := uow.tx.Model(&models.StockReservation{}).
+		Where("order_item_id = ? AND status = ?", orderItemID, models.ReservationActive).
+		Update("quantity", quantity).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateReservationQuantity] error updating stock reservation: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetExpiredReservationOrderIDs returns orders after afterOrderID that have active reservations
+// expired before the given moment, ordered by ID.
+func GetExpiredReservationOrderIDs(before time.Time, afterOrderID uint, limit int) ([]uint, error) {
+	var orderIDs []uint
+	if err := db.GetDBConn().Model(&models.StockReservation{}).
+		Distinct("order_id").
+		Where("status = ? AND expires_at < ? AND order_id > ?", models.ReservationActive, before, afterOrderID).
+		Order("order_id").
+		Limit(limit).
+		Pluck("order_id", &orderIDs).Error; err != nil {
+		logger.Error.Printf("[repository.GetExpiredReservationOrderIDs] error getting expired reservations: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	return orderIDs, nil
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// GetStoreMemberRole returns the role of a user in a store or an empty string if the user isn't a member.
+func GetStoreMemberRole(storeID, userID uint) (string, error) {
+	var roles []string
+	if err := db.GetDBConn().Model(&models.StoreMember{}).
+		Where("store_id = ? AND user_id = ?", storeID, userID).
+		Limit(1).
+		Pluck("role", &roles).Error; err != nil {
+		logger.Error.Printf("[repository.GetStoreMemberRole] error getting role of user %d in store %d: %v\n", userID, storeID, err)
+		return "", TranslateGormError(err)
+	}
+
+	if len(roles) == 0 {
+		return "", nil
+	}
+
+	return roles[0], nil
+}
+
+// GetUserStoreRoles returns the roles of a user by store ID, owned stores are included even without a membership.
+func GetUserStoreRoles(userID uint) (map[uint]string, error) {
+	var rows []struct {
+		StoreID uint
+		Role    string
+	}
+
+	if err := db.GetDBConn().Raw(`SELECT m.store_id, m.role FROM storeapp_storemember AS m
+		JOIN stores AS s ON s.id = m.store_id AND s.deleted_at IS NULL
+		WHERE m.user_id = ?
+		UNION SELECT id, ? FROM stores WHERE owner_id = ? AND deleted_at IS NULL`,
+		userID, models.StoreRoleOwner, userID).Scan(&rows).Error; err != nil {
+		logger.Error.Printf("[repository.GetUserStoreRoles] error getting store roles of user %d: %v\n", userID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	roles := make(map[uint]string, len(rows))
+	for _, row := range rows {
+		// Владелец магазина важнее записи в сотрудниках
+		if roles[row.StoreID] != models.StoreRoleOwner {
+			roles[row.StoreID] = row.Role
+		}
+	}
+
+	return roles, nil
+}
+
+// GetStoreMembers returns the members of a store, the owner first.
+func GetStoreMembers(storeID uint) (members []models.StoreMember, err error) {
+	if err = db.GetDBConn().
+		Select("storeapp_storemember.*, users.username").
+		Joins("JOIN users ON users.id = storeapp_storemember.user_id").
+		Where("storeapp_storemember.store_id = ?", storeID).
+		Order(clause.Expr{SQL: "storeapp_storemember.role = ? DESC, storeapp_storemember.created_at", Vars: []interface{}{models.StoreRoleOwner}}).
+		Find(&members).Error; err != nil {
+		logger.Error.Printf("[repository.GetStoreMembers] error getting members of store %d: %v\n", storeID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return members, nil
+}
+
+// GetStoreMember returns a member of a store.
+func GetStoreMember(storeID, userID uint) (member models.StoreMember, err error) {
+	if err = db.GetDBConn().Where("store_id = ? AND user_id = ?", storeID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return member, errs.ErrStoreMemberNotFound
+		}
+
+		logger.Error.Printf("[repository.GetStoreMember] error getting member %d of store %d: %v\n", userID, storeID, err)
+		return member, TranslateGormError(err)
+	}
+
+	return member, nil
+}
+
+// UpdateStoreMemberRole changes the role of a store member.
+func UpdateStoreMemberRole(storeID, userID uint, role string) error {
+	if err := db.GetDBConn().Model(&models.StoreMember{}).
+		Where("store_id = ? AND user_id = ?", storeID, userID).
+		Update("role", role).Error; err != nil {
+		logger.Error.Printf("[repository.UpdateStoreMemberRole] error updating member %d of store %d: %v\n", userID, storeID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// DeleteStoreMember removes a member from a store.
+func DeleteStoreMember(storeID, userID uint) error {
+	if err := db.GetDBConn().Where("store_id = ? AND user_id = ?", storeID, userID).
+		Delete(&models.StoreMember{}).Error; err != nil {
+		logger.Error.Printf("[repository.DeleteStoreMember] error deleting member %d of store %d: %v\n", userID, storeID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// CreateStoreInvitation saves a new invitation. It fails with ErrStoreInvitationExists
+// if the user already has a pending invitation to the store that hasn't expired.
+func CreateStoreInvitation(invitation *models.StoreInvitation) error {
+	return db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		// Приглашения одного пользователя в один магазин создаются по очереди
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('store_invitation'), ?)", invitation.StoreID).Error; err != nil {
+			logger.Error.Printf("[repository.CreateStoreInvitation] error locking invitations of store %d: %v\n", invitation.StoreID, err)
+			return TranslateGormError(err)
+		}
+
+		var pending int64
+		if err := tx.Model(&models.StoreInvitation{}).
+			Where("store_id = ? AND user_id = ? AND status = ? AND expires_at > ?",
+				invitation.StoreID, invitation.UserID, models.StoreInvitationPending, time.Now()).
+			Count(&pending).Error; err != nil {
+			logger.Error.Printf("[repository.CreateStoreInvitation] error checking invitations of store %d: %v\n", invitation.StoreID, err)
+			return TranslateGormError(err)
+		}
+
+		if pending > 0 {
+			return errs.ErrStoreInvitationExists
+		}
+
+		if err := tx.Omit(clause.Associations).Create(invitation).Error; err != nil {
+			logger.Error.Printf("[repository.CreateStoreInvitation] error creating invitation: %v\n", err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+}
+
+// GetStoreInvitationByID returns an invitation with the name of its store.
+func GetStoreInvitationByID(invitationID uint) (invitation models.StoreInvitation, err error) {
+	if err = db.GetDBConn().
+		Select("storeapp_storeinvitation.*, stores.name AS store_name").
+		Joins("JOIN stores ON stores.id = storeapp_storeinvitation.store_id").
+		Where("storeapp_storeinvitation.id = ?", invitationID).
+		First(&invitation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return invitation, errs.ErrStoreInvitationNotFound
+		}
+
+		logger.Error.Printf("[repository.GetStoreInvitationByID] error getting invitation %d: %v\n", invitationID, err)
+		return invitation, TranslateGormError(err)
+	}
+
+	return invitation, nil
+}
+
+// GetPendingStoreInvitations returns the invitations of a store that are waiting for an answer.
+func GetPendingStoreInvitations(storeID uint) (invitations []models.StoreInvitation, err error) {
+	if err = db.GetDBConn().
+		Select("storeapp_storeinvitation.*, users.username").
+		Joins("JOIN users ON users.id = storeapp_storeinvitation.user_id").
+		Where("storeapp_storeinvitation.store_id = ? AND storeapp_storeinvitation.status = ? AND storeapp_storeinvitation.expires_at > ?",
+			storeID, models.StoreInvitationPending, time.Now()).
+		Order("storeapp_storeinvitation.created_at DESC").
+		Find(&invitations).Error; err != nil {
+		logger.Error.Printf("[repository.GetPendingStoreInvitations] error getting invitations of store %d: %v\n", storeID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return invitations, nil
+}
+
+// GetUserStoreInvitations returns the invitations a user hasn't answered yet, with the names of the stores.
+func GetUserStoreInvitations(userID uint) (invitations []models.StoreInvitation, err error) {
+	if err = db.GetDBConn().
+		Select("storeapp_storeinvitation.*, stores.name AS store_name").
+		Joins("JOIN stores ON stores.id = storeapp_storeinvitation.store_id AND stores.deleted_at IS NULL").
+		Where("storeapp_storeinvitation.user_id = ? AND storeapp_storeinvitation.status = ? AND storeapp_storeinvitation.expires_at > ?",
+			userID, models.StoreInvitationPending, time.Now()).
+		Order("storeapp_storeinvitation.created_at DESC").
+		Find(&invitations).Error; err != nil {
+		logger.Error.Printf("[repository.GetUserStoreInvitations] error getting invitations of user %d: %v\n", userID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return invitations, nil
+}
+
+// AcceptStoreInvitation makes the invited user a member of the store with the role of the invitation.
+// It fails with ErrStoreInvitationNotFound if the invitation was answered or cancelled meanwhile
+// and with ErrAlreadyStoreMember if the user is already a member of the store.
+func AcceptStoreInvitation(invitation models.StoreInvitation) error {
+	return db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := respondStoreInvitation(tx, invitation.ID, models.StoreInvitationAccepted); err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).Create(&models.StoreMember{
+			StoreID:     invitation.StoreID,
+			UserID:      invitation.UserID,
+			Role:        invitation.Role,
+			InvitedByID: &invitation.InvitedByID,
+		})
+		if result.Error != nil {
+			logger.Error.Printf("[repository.AcceptStoreInvitation] error adding member %d to store %d: %v\n",
+				invitation.UserID, invitation.StoreID, result.Error)
+			return TranslateGormError(result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return errs.ErrAlreadyStoreMember
+		}
+
+		return nil
+	})
+}
+
+// UpdateStoreInvitationStatus answers or cancels a pending invitation.
+// It fails with ErrStoreInvitationNotFound if the invitation isn't pending anymore.
+func UpdateStoreInvitationStatus(invitationID uint, status string) error {
+	return respondStoreInvitation(db.GetDBConn(), invitationID, status)
+}
+
+func respondStoreInvitation(tx *gorm.DB, invitationID uint, status string) error {
+	result := tx.Model(&models.StoreInvitation{}).
+		Where("id = ? AND status = ?", invitationID, models.StoreInvitationPending).
+		Updates(map[string]interface{}{"status": status, "responded_at": time.Now()})
+	if result.Error != nil {
+		logger.Error.Printf("[repository.respondStoreInvitation] error updating invitation %d: %v\n", invitationID, result.Error)
+		return TranslateGormError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrStoreInvitationNotFound
+	}
+
+	return nil
+}
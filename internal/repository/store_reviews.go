@@ -5,19 +5,40 @@ import (
 	"BizMart/pkg/db"
 	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
 	"errors"
 	"gorm.io/gorm"
 )
 
-// GetAllStoreReviews retrieves all reviews for a given store by its ID.
-func GetAllStoreReviews(storeID uint) ([]models.StoreReview, error) {
-	var storeReviews []models.StoreReview
-	if err := db.GetDBConn().Where("store_id = ?", storeID).Find(&storeReviews).Error; err != nil {
+// storeReviewPageSpec describes the sorts and fields available when listing store reviews.
+var storeReviewPageSpec = pagination.Spec[models.StoreReview]{
+	Sorts: map[string]pagination.Sort[models.StoreReview]{
+		"created_at": {
+			Columns: []string{"store_reviews.created_at", "store_reviews.id"},
+			Key:     func(r models.StoreReview) []interface{} { return []interface{}{r.CreatedAt, r.ID} },
+		},
+		"rating": {
+			Columns: []string{"store_reviews.rating", "store_reviews.id"},
+			Key:     func(r models.StoreReview) []interface{} { return []interface{}{r.Rating, r.ID} },
+		},
+	},
+	DefaultSort:  "created_at",
+	DefaultOrder: pagination.OrderDesc,
+	Fields:       []string{"id", "store_id", "user_id", "rating", "comment", "created_at", "updated_at"},
+	CountTotal:   true,
+}
+
+// GetAllStoreReviews retrieves a page of reviews for a given store by its ID.
+func GetAllStoreReviews(storeID uint, params pagination.Params) (pagination.Page[models.StoreReview], error) {
+	query := db.GetDBConn().Model(&models.StoreReview{}).Where("store_id = ?", storeID)
+
+	page, err := pagination.Paginate(query, storeReviewPageSpec, params)
+	if err != nil {
 		logger.Error.Printf("[repository.GetAllStoreReviews] Error retrieving store reviews for store ID %d: %v", storeID, err)
-		return nil, TranslateGormError(err)
+		return pagination.Page[models.StoreReview]{}, TranslateGormError(err)
 	}
 
-	return storeReviews, nil
+	return page, nil
 }
 
 // CreateStoreReview adds a new review for a store.
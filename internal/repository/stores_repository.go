@@ -4,27 +4,64 @@ import (
 	"BizMart/internal/app/models"
 	"BizMart/pkg/db"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
 	"errors"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// GetStores retrieves all stores without their hashed passwords.
-func GetStores() ([]models.Store, error) {
-	var stores []models.Store
-	if err := db.GetDBConn().Omit("HashPassword").Find(&stores).Error; err != nil {
+// storePageSpec describes the sorts and fields available when listing stores.
+var storePageSpec = pagination.Spec[models.Store]{
+	Sorts: map[string]pagination.Sort[models.Store]{
+		"id": {
+			Columns: []string{"stores.id"},
+			Key:     func(s models.Store) []interface{} { return []interface{}{s.ID} },
+		},
+		"name": {
+			Columns: []string{"stores.name", "stores.id"},
+			Key:     func(s models.Store) []interface{} { return []interface{}{s.Name, s.ID} },
+		},
+		"created_at": {
+			Columns: []string{"stores.created_at", "stores.id"},
+			Key:     func(s models.Store) []interface{} { return []interface{}{s.CreatedAt, s.ID} },
+		},
+	},
+	DefaultSort:  "id",
+	DefaultOrder: pagination.OrderAsc,
+	Fields:       []string{"id", "name", "description", "owner_id", "created_at", "updated_at"},
+	CountTotal:   true,
+}
+
+// GetStores retrieves a page of stores.
+func GetStores(params pagination.Params) (pagination.Page[models.Store], error) {
+	page, err := pagination.Paginate(db.GetDBConn().Model(&models.Store{}), storePageSpec, params)
+	if err != nil {
 		logger.Error.Printf("[repository.GetStores] Error retrieving stores: %v", err)
-		return nil, err
+		return pagination.Page[models.Store]{}, TranslateGormError(err)
 	}
-	return stores, nil
+	return page, nil
 }
 
 // CreateStore adds a new store to the database.
+// The owner is added to the store members with the owner role.
 func CreateStore(store *models.Store) error {
-	if err := db.GetDBConn().Create(&store).Error; err != nil {
-		logger.Error.Printf("[repository.CreateStore] Error creating store: %v", err)
-		return err
-	}
-	return nil
+	return db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&store).Error; err != nil {
+			logger.Error.Printf("[repository.CreateStore] Error creating store: %v", err)
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Create(&models.StoreMember{
+			StoreID: store.ID,
+			UserID:  store.OwnerID,
+			Role:    models.StoreRoleOwner,
+		}).Error; err != nil {
+			logger.Error.Printf("[repository.CreateStore] Error adding owner of store %d: %v", store.ID, err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
 }
 
 // UpdateStore updates an existing store by ID.
@@ -45,6 +82,7 @@ func UpdateStore(storeID uint, updatedData *models.Store) error {
 	}
 
 	tx.Commit() // commit the transaction
+	db.InvalidateCacheTags(storeCacheTag(storeID))
 	return nil
 }
 
@@ -59,6 +97,8 @@ func DeleteStore(storeID uint) error {
 		logger.Error.Printf("[repository.DeleteStore] Error deleting store with ID %d: %v", storeID, err)
 		return err
 	}
+
+	db.InvalidateCacheTags(storeCacheTag(storeID))
 	return nil
 }
 
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnitOfWork groups repository operations that must be applied atomically.
+// All methods run inside a single database transaction, rows read with Lock* methods
+// stay locked (SELECT ... FOR UPDATE) until the transaction is committed or rolled back.
+type UnitOfWork struct {
+	tx        *gorm.DB
+	cacheTags []string
+}
+
+// RunInTransaction executes fn inside a transaction.
+// The transaction is committed when fn returns nil and rolled back otherwise (including panics).
+// Cached data changed by the transaction is invalidated after the commit.
+func RunInTransaction(fn func(uow *UnitOfWork) error) error {
+	return runInTransaction(db.GetDBConn(), fn)
+}
+
+func runInTransaction(conn *gorm.DB, fn func(uow *UnitOfWork) error) error {
+	uow := &UnitOfWork{}
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		uow.tx = tx
+		uow.cacheTags = nil
+		return fn(uow)
+	})
+
+	if err == nil && len(uow.cacheTags) > 0 {
+		db.InvalidateCacheTags(uow.cacheTags...)
+	}
+
+	return err
+}
+
+func (uow *UnitOfWork) forUpdate() *gorm.DB {
+	return uow.tx.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// LockProducts locks the given products ordered by ID so that concurrent checkouts can't deadlock.
+func (uow *UnitOfWork) LockProducts(productIDs []uint) (map[uint]models.Product, error) {
+	var products []models.Product
+	if err := uow.forUpdate().Where("id IN ?", productIDs).Order("id").Find(&products).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockProducts] error locking products: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	lockedProducts := make(map[uint]models.Product, len(products))
+	for _, product := range products {
+		lockedProducts[product.ID] = product
+	}
+
+	return lockedProducts, nil
+}
+
+// LockVariants locks the given product variants ordered by ID.
+// Products must be locked first (see LockProducts) so that every transaction takes locks in the same order.
+func (uow *UnitOfWork) LockVariants(variantIDs []uint) (map[uint]models.ProductVariant, error) {
+	var variants []models.ProductVariant
+	if err := uow.forUpdate().Where("id IN ?", variantIDs).Order("id").Find(&variants).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockVariants] error locking variants: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	lockedVariants := make(map[uint]models.ProductVariant, len(variants))
+	for _, variant := range variants {
+		lockedVariants[variant.ID] = variant
+	}
+
+	return lockedVariants, nil
+}
+
+// ApplyStockMovement changes the stock of a variant by movement.Quantity and records the movement.
+// Deleted variants still take returned products back, they are just not counted in the product stock.
+// Stock can't go below zero, in that case ErrNotEnoughProductInStock is returned.
+func (uow *UnitOfWork) ApplyStockMovement(movement *models.InventoryMovement) error {
+	result := uow.tx.Unscoped().Model(&models.ProductVariant{}).
+		Where("id = ? AND product_id = ? AND amount + ? >= 0", movement.VariantID, movement.ProductID, movement.Quantity).
+		UpdateColumn("amount", gorm.Expr("amount + ?", movement.Quantity))
+	if result.Error != nil {
+		logger.Error.Printf("[repository.UnitOfWork.ApplyStockMovement] error updating variant stock: %v\n", result.Error)
+		return TranslateGormError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrNotEnoughProductInStock
+	}
+
+	if err := refreshProductAggregates(uow.tx, movement.ProductID); err != nil {
+		return err
+	}
+
+	// Списки товаров показывают остаток и наличие, поэтому вместе со страницей товара сбрасываются все его списки
+	uow.invalidateCache(productCatalogCacheTags(uow.tx, movement.ProductID)...)
+
+	if err := uow.tx.Unscoped().Model(&models.ProductVariant{}).
+		Select("amount").
+		Where("id = ?", movement.VariantID).
+		Scan(&movement.BalanceAfter).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.ApplyStockMovement] error getting stock: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	if err := uow.tx.Create(movement).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.ApplyStockMovement] error creating inventory movement: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// CreateOrder creates the order with its details and line items.
+func (uow *UnitOfWork) CreateOrder(order *models.Order, orderDetails models.OrderDetails, items []models.OrderItem) error {
+	if err := uow.tx.Create(&orderDetails).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreateOrder] error creating orderDetails: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	order.OrderDetailsID = orderDetails.ID
+
+	if err := uow.tx.Omit("Items").Create(order).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreateOrder] error creating order: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	for i := range items {
+		items[i].OrderID = order.ID
+	}
+
+	if err := uow.tx.Create(&items).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreateOrder] error creating order items: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	order.OrderDetails = orderDetails
+	order.Items = items
+
+	return nil
+}
+
+// LockOrder locks an order and loads its details and items with their modifiers.
+func (uow *UnitOfWork) LockOrder(orderID uint) (models.Order, error) {
+	var order models.Order
+	if err := uow.forUpdate().Where("id = ?", orderID).First(&order).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockOrder] error locking order: %v\n", err)
+		return models.Order{}, TranslateGormError(err)
+	}
+
+	if err := uow.tx.Where("id = ?", order.OrderDetailsID).First(&order.OrderDetails).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockOrder] error getting orderDetails: %v\n", err)
+		return models.Order{}, TranslateGormError(err)
+	}
+
+	if err := uow.tx.Preload("Modifiers").Where("order_id = ?", order.ID).Find(&order.Items).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockOrder] error getting order items: %v\n", err)
+		return models.Order{}, TranslateGormError(err)
+	}
+
+	return order, nil
+}
+
+// UpdateOrderStatus changes the status of an order.
+func (uow *UnitOfWork) UpdateOrderStatus(orderID, statusID uint) error {
+	if err := uow.tx.Model(&models.Order{}).Where("id = ?", orderID).Update("status_id", statusID).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateOrderStatus] error updating order status: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// LockAccounts locks accounts ordered by ID so that concurrent payments can't deadlock.
+func (uow *UnitOfWork) LockAccounts(accountIDs []uint) (map[uint]models.Account, error) {
+	var accounts []models.Account
+	if err := uow.forUpdate().Where("id IN ?", accountIDs).Order("id").Find(&accounts).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.LockAccounts] error locking accounts: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	lockedAccounts := make(map[uint]models.Account, len(accounts))
+	for _, account := range accounts {
+		lockedAccounts[account.ID] = account
+	}
+
+	return lockedAccounts, nil
+}
+
+// UpdateAccountBalance stores the new balance of a locked account.
+func (uow *UnitOfWork) UpdateAccountBalance(accountID uint, balance float64) error {
+	if err := uow.tx.Model(&models.Account{}).Where("id = ?", accountID).Update("balance", balance).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.UpdateAccountBalance] error updating account balance: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetStoresByProductIDs maps each product to the store it belongs to.
+func (uow *UnitOfWork) GetStoresByProductIDs(productIDs []uint) (map[uint]models.Store, error) {
+	var products []models.Product
+	if err := uow.tx.Preload("Store").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.GetStoresByProductIDs] error getting product stores: %v\n", err)
+		return nil, TranslateGormError(err)
+	}
+
+	stores := make(map[uint]models.Store, len(products))
+	for _, product := range products {
+		stores[product.ID] = product.Store
+	}
+
+	return stores, nil
+}
+
+// CreatePayment inserts a payment record.
+func (uow *UnitOfWork) CreatePayment(payment *models.Payment) error {
+	if err := uow.tx.Create(payment).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.CreatePayment] error creating payment: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// ClearCart removes all cart items of a user.
+func (uow *UnitOfWork) ClearCart(userID uint) error {
+	if err := uow.tx.Where("user_id = ?", userID).Delete(&models.CartItem{}).Error; err != nil {
+		logger.Error.Printf("[repository.UnitOfWork.ClearCart] error clearing cart: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
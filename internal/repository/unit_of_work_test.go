@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/testutil"
+	"BizMart/pkg/db"
+	"errors"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	if err := testutil.Setup(); err != nil {
+		log.Fatalf("setting up test database: %v", err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestRunInTransactionRollsBackOnError(t *testing.T) {
+	testutil.RequireDB(t)
+
+	f := testutil.NewFixture(t, 10, 25, 100)
+	before := f.TakeSnapshot(t)
+
+	err := RunInTransaction(func(uow *UnitOfWork) error {
+		if err := uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: f.Product.ID,
+			VariantID: f.Variant.ID,
+			Kind:      models.MovementSale,
+			Quantity:  -3,
+		}); err != nil {
+			return err
+		}
+
+		if err := uow.UpdateAccountBalance(f.BuyerAccount.ID, 25); err != nil {
+			return err
+		}
+
+		return testutil.ErrInjected
+	})
+	if !errors.Is(err, testutil.ErrInjected) {
+		t.Fatalf("expected injected error, got %v", err)
+	}
+
+	f.RequireUnchanged(t, before)
+}
+
+func TestRunInTransactionRollsBackOnPanic(t *testing.T) {
+	testutil.RequireDB(t)
+
+	f := testutil.NewFixture(t, 10, 25, 100)
+	before := f.TakeSnapshot(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected panic to be propagated")
+			}
+		}()
+
+		_ = RunInTransaction(func(uow *UnitOfWork) error {
+			if err := uow.UpdateAccountBalance(f.BuyerAccount.ID, 25); err != nil {
+				return err
+			}
+
+			panic(testutil.ErrInjected)
+		})
+	}()
+
+	f.RequireUnchanged(t, before)
+}
+
+func TestRunInTransactionCommits(t *testing.T) {
+	testutil.RequireDB(t)
+
+	f := testutil.NewFixture(t, 10, 25, 100)
+
+	err := RunInTransaction(func(uow *UnitOfWork) error {
+		return uow.ApplyStockMovement(&models.InventoryMovement{
+			ProductID: f.Product.ID,
+			VariantID: f.Variant.ID,
+			Kind:      models.MovementSale,
+			Quantity:  -3,
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after := f.TakeSnapshot(t); after.VariantAmount != 7 || after.Movements != 1 {
+		t.Fatalf("expected stock 7 with one movement, got %+v", after)
+	}
+}
+
+func TestRunInTransactionWithoutDB(t *testing.T) {
+	tests := []struct {
+		name           string
+		fail           error
+		panics         bool
+		wantStatements []string
+		wantCacheReset bool
+	}{
+		{
+			name:           "commits when fn succeeds",
+			wantStatements: []string{"BEGIN", "UPDATE", "COMMIT"},
+			wantCacheReset: true,
+		},
+		{
+			name:           "rolls back when fn fails",
+			fail:           testutil.ErrInjected,
+			wantStatements: []string{"BEGIN", "UPDATE", "ROLLBACK"},
+		},
+		{
+			name:           "rolls back when fn panics",
+			panics:         true,
+			wantStatements: []string{"BEGIN", "UPDATE", "ROLLBACK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, statements := newRecordingDB(t)
+			tag := "test:" + t.Name()
+
+			var err error
+			func() {
+				defer func() {
+					if recovered := recover(); (recovered != nil) != tt.panics {
+						t.Fatalf("expected panic %v, recovered %v", tt.panics, recovered)
+					}
+				}()
+
+				err = runInTransaction(conn, func(uow *UnitOfWork) error {
+					uow.invalidateCache(tag)
+					if err := uow.UpdateAccountBalance(1, 25); err != nil {
+						return err
+					}
+
+					if tt.panics {
+						panic(testutil.ErrInjected)
+					}
+
+					return tt.fail
+				})
+			}()
+
+			if !tt.panics && !errors.Is(err, tt.fail) {
+				t.Fatalf("expected error %v, got %v", tt.fail, err)
+			}
+
+			if got := statements.all(); !reflect.DeepEqual(got, tt.wantStatements) {
+				t.Fatalf("expected statements %v, got %v", tt.wantStatements, got)
+			}
+
+			version, err := db.GetCache("cache:tag:" + tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if cacheReset := version != ""; cacheReset != tt.wantCacheReset {
+				t.Fatalf("expected cache invalidation %v, got version %q", tt.wantCacheReset, version)
+			}
+		})
+	}
+}
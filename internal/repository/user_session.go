@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CreateUserSession saves a new session of a user.
+func CreateUserSession(session *models.UserSession) error {
+	if err := db.GetDBConn().Create(session).Error; err != nil {
+		logger.Error.Printf("[repository.CreateUserSession] error creating session: %v\n", err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetUserSessionByID returns a session by its ID, including revoked and expired ones.
+func GetUserSessionByID(sessionID uint) (session models.UserSession, err error) {
+	if err = db.GetDBConn().Where("id = ?", sessionID).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return session, errs.ErrSessionNotFound
+		}
+
+		logger.Error.Printf("[repository.GetUserSessionByID] error getting session %d: %v\n", sessionID, err)
+		return session, TranslateGormError(err)
+	}
+
+	return session, nil
+}
+
+// GetActiveUserSessions returns the sessions of a user that are neither revoked nor expired, most recently used first.
+func GetActiveUserSessions(userID uint) (sessions []models.UserSession, err error) {
+	if err = db.GetDBConn().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		logger.Error.Printf("[repository.GetActiveUserSessions] error getting sessions of user %d: %v\n", userID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return sessions, nil
+}
+
+// RotateUserSession replaces the refresh token of an active session if currentHash is still its current token.
+// It returns false if the token was already replaced, e.g. by a concurrent refresh with the same token.
+func RotateUserSession(session models.UserSession, currentHash string) (bool, error) {
+	result := db.GetDBConn().Model(&models.UserSession{}).
+		Where("id = ? AND token_hash = ? AND revoked_at IS NULL", session.ID, currentHash).
+		Updates(map[string]interface{}{
+			"token_hash":   session.TokenHash,
+			"ip":           session.IP,
+			"user_agent":   session.UserAgent,
+			"last_used_at": session.LastUsedAt,
+			"expires_at":   session.ExpiresAt,
+		})
+	if result.Error != nil {
+		logger.Error.Printf("[repository.RotateUserSession] error rotating session %d: %v\n", session.ID, result.Error)
+		return false, TranslateGormError(result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeUserSession revokes an active session.
+func RevokeUserSession(sessionID uint, reason string) error {
+	if err := db.GetDBConn().Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoke_reason": reason}).Error; err != nil {
+		logger.Error.Printf("[repository.RevokeUserSession] error revoking session %d: %v\n", sessionID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// RevokeUserSessions revokes all active sessions of a user and returns their IDs.
+func RevokeUserSessions(userID uint, reason string) (sessionIDs []uint, err error) {
+	if err = db.GetDBConn().Raw(`UPDATE userapp_session SET revoked_at = ?, revoke_reason = ?, updated_at = ?
+		WHERE user_id = ? AND revoked_at IS NULL RETURNING id`, time.Now(), reason, time.Now(), userID).
+		Scan(&sessionIDs).Error; err != nil {
+		logger.Error.Printf("[repository.RevokeUserSessions] error revoking sessions of user %d: %v\n", userID, err)
+		return nil, TranslateGormError(err)
+	}
+
+	return sessionIDs, nil
+}
+
+// UpdateUserPassword sets a new password hash and records the time of the change.
+func UpdateUserPassword(userID uint, passwordHash string, changedAt time.Time) error {
+	if err := db.GetDBConn().Model(&models.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{"hash_password": passwordHash, "password_changed_at": changedAt}).Error; err != nil {
+		logger.Error.Printf("[repository.UpdateUserPassword] error updating password of user %d: %v\n", userID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
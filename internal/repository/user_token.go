@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/pkg/db"
+	"BizMart/pkg/errs"
+	"BizMart/pkg/logger"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// CreateUserToken saves a new token, earlier unused tokens of the user with the same purpose stop working.
+func CreateUserToken(token *models.UserToken) error {
+	return db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		if err := expireUserTokens(tx, token.UserID, token.Purpose); err != nil {
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Create(token).Error; err != nil {
+			logger.Error.Printf("[repository.CreateUserToken] error creating %s token of user %d: %v\n", token.Purpose, token.UserID, err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+}
+
+func expireUserTokens(tx *gorm.DB, userID uint, purpose string) error {
+	if err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error; err != nil {
+		logger.Error.Printf("[repository.expireUserTokens] error expiring %s tokens of user %d: %v\n", purpose, userID, err)
+		return TranslateGormError(err)
+	}
+
+	return nil
+}
+
+// GetValidUserToken returns an unused and unexpired token by its hash.
+// It fails with ErrInvalidUserToken if there is no such token or it was issued for another email of the user.
+func GetValidUserToken(tokenHash, purpose string) (models.UserToken, error) {
+	token, _, err := getValidUserToken(db.GetDBConn(), tokenHash, purpose, false)
+	return token, err
+}
+
+// getValidUserToken проверяет токен и возвращает его вместе с пользователем, lock блокирует токен до конца транзакции
+func getValidUserToken(tx *gorm.DB, tokenHash, purpose string, lock bool) (token models.UserToken, user models.User, err error) {
+	query := tx.Where("token_hash = ? AND purpose = ?", tokenHash, purpose)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	if err = query.First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return token, user, errs.ErrInvalidUserToken
+		}
+
+		logger.Error.Printf("[repository.getValidUserToken] error getting %s token: %v\n", purpose, err)
+		return token, user, TranslateGormError(err)
+	}
+
+	if token.UsedAt != nil {
+		return token, user, errs.ErrInvalidUserToken
+	}
+	if token.ExpiresAt.Before(time.Now()) {
+		return token, user, errs.ErrUserTokenExpired
+	}
+
+	if err = tx.Where("id = ?", token.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return token, user, errs.ErrInvalidUserToken
+		}
+
+		logger.Error.Printf("[repository.getValidUserToken] error getting user %d: %v\n", token.UserID, err)
+		return token, user, TranslateGormError(err)
+	}
+
+	if user.Email != token.Email {
+		return token, user, errs.ErrInvalidUserToken
+	}
+
+	return token, user, nil
+}
+
+// useUserToken помечает токен использованным и возвращает его пользователя
+func useUserToken(tx *gorm.DB, tokenHash, purpose string) (models.User, error) {
+	token, user, err := getValidUserToken(tx, tokenHash, purpose, true)
+	if err != nil {
+		return user, err
+	}
+
+	if err = tx.Model(&token).Update("used_at", time.Now()).Error; err != nil {
+		logger.Error.Printf("[repository.useUserToken] error using token %d: %v\n", token.ID, err)
+		return user, TranslateGormError(err)
+	}
+
+	return user, nil
+}
+
+// VerifyUserEmail uses an email verification token and marks the email of its user as verified.
+func VerifyUserEmail(tokenHash string) (userID uint, err error) {
+	err = db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		user, err := useUserToken(tx, tokenHash, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		userID = user.ID
+
+		if err = tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", user.ID).
+			Update("email_verified_at", time.Now()).Error; err != nil {
+			logger.Error.Printf("[repository.VerifyUserEmail] error verifying email of user %d: %v\n", user.ID, err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+
+	return userID, err
+}
+
+// ResetUserPassword uses a password reset token and sets a new password hash of its user.
+// Other reset tokens of the user stop working. The email is marked as verified, since the token was delivered to it.
+func ResetUserPassword(tokenHash, passwordHash string, changedAt time.Time) (userID uint, err error) {
+	err = db.GetDBConn().Transaction(func(tx *gorm.DB) error {
+		user, err := useUserToken(tx, tokenHash, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+		userID = user.ID
+
+		if err = expireUserTokens(tx, user.ID, models.TokenPurposePasswordReset); err != nil {
+			return err
+		}
+
+		if err = tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"hash_password":       passwordHash,
+			"password_changed_at": changedAt,
+			"email_verified_at":   gorm.Expr("COALESCE(email_verified_at, ?)", changedAt),
+		}).Error; err != nil {
+			logger.Error.Printf("[repository.ResetUserPassword] error updating password of user %d: %v\n", user.ID, err)
+			return TranslateGormError(err)
+		}
+
+		return nil
+	})
+
+	return userID, err
+}
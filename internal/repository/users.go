@@ -5,18 +5,40 @@ import (
 	"BizMart/pkg/db"
 	"BizMart/pkg/errs"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/pagination"
 	"errors"
 	"gorm.io/gorm"
 )
 
-func GetAllUsers() (users []models.User, err error) {
-	err = db.GetDBConn().Find(&users).Error
+var userPageSpec = pagination.Spec[models.User]{
+	Sorts: map[string]pagination.Sort[models.User]{
+		"id": {
+			Columns: []string{"users.id"},
+			Key:     func(u models.User) []interface{} { return []interface{}{u.ID} },
+		},
+		"username": {
+			Columns: []string{"users.username"},
+			Key:     func(u models.User) []interface{} { return []interface{}{u.Username} },
+		},
+		"created_at": {
+			Columns: []string{"users.created_at", "users.id"},
+			Key:     func(u models.User) []interface{} { return []interface{}{u.CreatedAt, u.ID} },
+		},
+	},
+	DefaultSort:  "id",
+	DefaultOrder: pagination.OrderAsc,
+	Fields:       []string{"id", "first_name", "last_name", "username", "email", "created_at", "updated_at"},
+	CountTotal:   true,
+}
+
+func GetAllUsers(params pagination.Params) (pagination.Page[models.User], error) {
+	page, err := pagination.Paginate(db.GetDBConn().Model(&models.User{}), userPageSpec, params)
 	if err != nil {
 		logger.Error.Printf("[repository.GetAllUsers] error getting all users: %s\n", err.Error())
-		return nil, TranslateGormError(err)
+		return pagination.Page[models.User]{}, TranslateGormError(err)
 	}
 
-	return users, nil
+	return page, nil
 }
 
 func GetUserByID(id uint) (user models.User, err error) {
@@ -42,22 +64,50 @@ func GetUserByUsername(username string) (*models.User, error) {
 	return &user, nil
 }
 
-func UserExists(username, email string) (bool, bool, error) {
-	users, err := GetAllUsers()
-	if err != nil {
-		return false, false, err
+// GetUserByLogin finds a user by username or email.
+func GetUserByLogin(login string) (user models.User, err error) {
+	if err = db.GetDBConn().Where("username = ? OR email = ?", login, login).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, errs.ErrUserNotFound
+		}
+
+		logger.Error.Printf("[repository.GetUserByLogin] error getting user by login: %v\n", err)
+		return user, TranslateGormError(err)
 	}
 
-	var usernameExists, emailExists bool
-	for _, user := range users {
-		if user.Username == username {
-			usernameExists = true
-		}
-		if user.Email == email {
-			emailExists = true
+	return user, nil
+}
+
+// GetUserByEmail finds a user by email.
+func GetUserByEmail(email string) (user models.User, err error) {
+	if err = db.GetDBConn().Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, errs.ErrUserNotFound
 		}
+
+		logger.Error.Printf("[repository.GetUserByEmail] error getting user by email: %v\n", err)
+		return user, TranslateGormError(err)
 	}
-	return usernameExists, emailExists, nil
+
+	return user, nil
+}
+
+func UserExists(username, email string) (bool, bool, error) {
+	var exists struct {
+		UsernameExists bool
+		EmailExists    bool
+	}
+
+	err := db.GetDBConn().Model(&models.User{}).
+		Select("COALESCE(bool_or(username = ?), false) AS username_exists, COALESCE(bool_or(email = ?), false) AS email_exists", username, email).
+		Where("username = ? OR email = ?", username, email).
+		Scan(&exists).Error
+	if err != nil {
+		logger.Error.Printf("[repository.UserExists] error checking user existence: %v\n", err)
+		return false, false, TranslateGormError(err)
+	}
+
+	return exists.UsernameExists, exists.EmailExists, nil
 }
 
 func CreateUser(user models.User) (id uint, err error) {
@@ -71,32 +121,34 @@ func CreateUser(user models.User) (id uint, err error) {
 	return user.ID, nil
 }
 
-func GetUserByUsernameAndPassword(username string, password string) (user models.User, err error) {
-	err = db.GetDBConn().Where("username = ? AND hash_password = ?", username, password).First(&user).Error
-	if err != nil {
-		logger.Error.Printf("[repository.GetUserByUsernameAndPassword] error getting user by username and password: %v\n", err)
-		return user, TranslateGormError(err)
+// GetUserForSignIn finds the user signing in by username, email or both.
+// The password is checked by the caller, so that hashes are never compared in SQL.
+func GetUserForSignIn(username, email string) (user models.User, err error) {
+	query := db.GetDBConn()
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if email != "" {
+		query = query.Where("email = ?", email)
 	}
 
-	return user, nil
-}
-
-func GetUserByEmailAndPassword(email string, password string) (user models.User, err error) {
-	err = db.GetDBConn().Where("email = ? AND hash_password = ?", email, password).First(&user).Error
-	if err != nil {
-		logger.Error.Printf("[repository.GetUserByEmailAndPassword] error getting user by email and password: %v\n", err)
+	if err = query.First(&user).Error; err != nil {
+		logger.Error.Printf("[repository.GetUserForSignIn] error getting user by username and email: %v\n", err)
 		return user, TranslateGormError(err)
 	}
 
 	return user, nil
 }
 
-func GetUserByEmailPasswordAndUsername(username, email, password string) (user models.User, err error) {
-	err = db.GetDBConn().Where("email = ? AND hash_password = ? AND username = ?", email, password, username).First(&user).Error
-	if err != nil {
-		logger.Error.Printf("[repository.GetUserByEmailPasswordAndUsername] error getting user by username, email and password: %v\n", err)
-		return user, TranslateGormError(err)
+// UpdateUserPasswordHash replaces the password hash of a user if it is still currentHash,
+// so that an upgraded hash can't overwrite a password changed in the meantime.
+func UpdateUserPasswordHash(userID uint, currentHash, newHash string) error {
+	if err := db.GetDBConn().Model(&models.User{}).
+		Where("id = ? AND hash_password = ?", userID, currentHash).
+		Update("hash_password", newHash).Error; err != nil {
+		logger.Error.Printf("[repository.UpdateUserPasswordHash] error updating password hash of user %d: %v\n", userID, err)
+		return TranslateGormError(err)
 	}
 
-	return user, nil
+	return nil
 }
@@ -2,8 +2,10 @@ package routes
 
 import (
 	_ "BizMart/docs"
+	"BizMart/internal/app/models"
 	"BizMart/internal/controllers"
 	"BizMart/internal/controllers/middlewares"
+	"BizMart/pkg/storage"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
@@ -12,6 +14,11 @@ import (
 func InitRoutes(r *gin.Engine) *gin.Engine {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Файлы локального хранилища раздаются самим приложением, файлы S3 — хранилищем
+	if directory, ok := storage.LocalDirectory(); ok {
+		r.Static(storage.LocalMediaPath, directory)
+	}
+
 	// usersRoute Маршруты для пользователей (профили)
 	usersRoute := r.Group("/users")
 	{
@@ -25,6 +32,14 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 		auth.POST("/sign-up", controllers.SignUp)
 		auth.POST("/sign-in", controllers.SignIn)
 		auth.POST("/refresh", controllers.RefreshToken)
+		auth.POST("/logout", middlewares.CheckUserAuthentication, controllers.Logout)
+		auth.GET("/sessions", middlewares.CheckUserAuthentication, controllers.GetSessions)
+		auth.DELETE("/sessions/:id", middlewares.CheckUserAuthentication, controllers.RevokeSession)
+		auth.PUT("/password", middlewares.CheckUserAuthentication, controllers.ChangePassword)
+		auth.POST("/password/forgot", controllers.ForgotPassword)
+		auth.POST("/password/reset", controllers.ResetPassword)
+		auth.POST("/email/verification", middlewares.CheckUserAuthentication, controllers.SendEmailVerification)
+		auth.POST("/email/verify", controllers.VerifyEmail)
 	}
 
 	// storeRoutes Маршруты для магазинов
@@ -35,6 +50,24 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 		storeRoutes.POST("/", middlewares.CheckUserAuthentication, controllers.CreateStore)
 		storeRoutes.PUT("/:id", middlewares.CheckUserAuthentication, controllers.UpdateStore)
 		storeRoutes.DELETE("/:id", middlewares.CheckUserAuthentication, controllers.DeleteStore)
+		storeRoutes.PUT("/:id/image", middlewares.CheckUserAuthentication, controllers.UploadStoreImage)
+		storeRoutes.GET("/:id/low-stock", middlewares.CheckUserAuthentication, controllers.GetLowStockProducts)
+		storeRoutes.POST("/:id/products/import", middlewares.CheckUserAuthentication, controllers.ImportStoreProducts)
+		storeRoutes.GET("/:id/products/export", middlewares.CheckUserAuthentication, controllers.ExportStoreProducts)
+		storeRoutes.GET("/:id/members", middlewares.CheckUserAuthentication, controllers.GetStoreMembers)
+		storeRoutes.PUT("/:id/members/:user_id", middlewares.CheckUserAuthentication, controllers.UpdateStoreMemberRole)
+		storeRoutes.DELETE("/:id/members/:user_id", middlewares.CheckUserAuthentication, controllers.RemoveStoreMember)
+		storeRoutes.GET("/:id/invitations", middlewares.CheckUserAuthentication, controllers.GetStoreInvitations)
+		storeRoutes.POST("/:id/invitations", middlewares.CheckUserAuthentication, controllers.InviteStoreMember)
+		storeRoutes.DELETE("/:id/invitations/:invitation_id", middlewares.CheckUserAuthentication, controllers.CancelStoreInvitation)
+	}
+
+	// invitationGroup Маршруты для приглашений текущего пользователя в сотрудники магазинов
+	invitationGroup := r.Group("/invitations", middlewares.CheckUserAuthentication)
+	{
+		invitationGroup.GET("/", controllers.GetMyStoreInvitations)
+		invitationGroup.POST("/:id/accept", controllers.AcceptStoreInvitation)
+		invitationGroup.POST("/:id/decline", controllers.DeclineStoreInvitation)
 	}
 
 	// storeReviewRoutes Маршруты для отзывов на магазины
@@ -53,10 +86,13 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 	categoryRoutes := r.Group("/category")
 	{
 		categoryRoutes.GET("/", controllers.GetAllCategories)
+		categoryRoutes.GET("/tree", controllers.GetCategoryTree)
 		categoryRoutes.GET("/:id", controllers.GetCategoryById)
-		categoryRoutes.POST("/", middlewares.CheckUserAuthentication, middlewares.CheckAdmin, controllers.CreateCategory)
-		categoryRoutes.PUT("/:id", middlewares.CheckUserAuthentication, middlewares.CheckAdmin, controllers.UpdateCategory)
-		categoryRoutes.DELETE("/:id", middlewares.CheckUserAuthentication, middlewares.CheckAdmin, controllers.DeleteCategory)
+		categoryRoutes.GET("/:id/path", controllers.GetCategoryPath)
+		categoryRoutes.POST("/", middlewares.CheckUserAuthentication, middlewares.RequirePermission(models.PermissionManageCategories), controllers.CreateCategory)
+		categoryRoutes.PUT("/:id", middlewares.CheckUserAuthentication, middlewares.RequirePermission(models.PermissionManageCategories), controllers.UpdateCategory)
+		categoryRoutes.PUT("/:id/move", middlewares.CheckUserAuthentication, middlewares.RequirePermission(models.PermissionManageCategories), controllers.MoveCategory)
+		categoryRoutes.DELETE("/:id", middlewares.CheckUserAuthentication, middlewares.RequirePermission(models.PermissionManageCategories), controllers.DeleteCategory)
 	}
 
 	// orderStatusGroup Маршруты для статусов заказов
@@ -64,9 +100,7 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 	{
 		orderStatusGroup.GET("/", controllers.GetAllOrderStatuses)
 		orderStatusGroup.GET("/:id", controllers.GetOrderStatusByID)
-		orderStatusGroup.POST("/", middlewares.CheckUserAuthentication, middlewares.CheckAdmin, controllers.CreateOrderStatus)
-		orderStatusGroup.PUT("/:id", middlewares.CheckUserAuthentication, middlewares.CheckAdmin, controllers.UpdateOrderStatus)
-		orderStatusGroup.DELETE("/:id", middlewares.CheckUserAuthentication, middlewares.CheckAdmin, controllers.DeleteOrderStatus)
+		orderStatusGroup.PUT("/:id", middlewares.CheckUserAuthentication, middlewares.RequirePermission(models.PermissionManageOrderStatuses), controllers.UpdateOrderStatus)
 	}
 
 	// Обработчик статусов заказов по имени
@@ -75,6 +109,7 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 	productGroup := r.Group("/product")
 	{
 		productGroup.GET("/", controllers.GetAllProducts)
+		productGroup.GET("/search", controllers.SearchProducts)
 		productGroup.GET("/:id", controllers.GetProductByID)
 		productGroup.POST("/:store_id", middlewares.CheckUserAuthentication, controllers.CreateProduct)
 		productGroup.PUT("/:id", middlewares.CheckUserAuthentication, controllers.UpdateProduct)
@@ -97,9 +132,22 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 		accountGroup.POST("/", controllers.CreateAccount)
 		accountGroup.PUT("/:id", controllers.UpdateAccount)
 		accountGroup.PUT("/fill/:id", controllers.FillAccountBalance)
+		accountGroup.GET("/:id/transactions", controllers.GetAccountTransactions)
 		accountGroup.DELETE("/:id", controllers.DeleteAccount)
 	}
 
+	r.GET("/ledger/reconciliation", middlewares.CheckUserAuthentication, middlewares.RequirePermission(models.PermissionReconcileLedger), controllers.ReconcileAccounts)
+
+	// adminGroup Маршруты управления ролями пользователей
+	adminGroup := r.Group("/admin", middlewares.CheckUserAuthentication)
+	{
+		adminGroup.GET("/roles", middlewares.RequirePermission(models.PermissionViewRoles), controllers.GetRoles)
+		adminGroup.GET("/roles/audit", middlewares.RequirePermission(models.PermissionViewRoles), controllers.GetRoleAudit)
+		adminGroup.GET("/users/:id/roles", middlewares.RequirePermission(models.PermissionViewRoles), controllers.GetUserRoles)
+		adminGroup.POST("/users/:id/roles", middlewares.RequirePermission(models.PermissionManageRoles), controllers.GrantUserRole)
+		adminGroup.DELETE("/users/:id/roles/:role", middlewares.RequirePermission(models.PermissionManageRoles), controllers.RevokeUserRole)
+	}
+
 	featuredProductGroup := r.Group("/products/featured", middlewares.CheckUserAuthentication)
 	{
 		featuredProductGroup.GET("/", controllers.GetFeaturedProducts)
@@ -108,6 +156,50 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 		featuredProductGroup.DELETE("/:id", controllers.DeleteFeaturedProduct)
 	}
 
+	// variantGroup Маршруты для вариантов товара (размер, цвет и т.д.)
+	variantGroup := r.Group("/products/variants")
+	{
+		variantGroup.GET("/:id", controllers.GetProductVariants)
+		variantGroup.POST("/:id", middlewares.CheckUserAuthentication, controllers.CreateProductVariant)
+		variantGroup.PUT("/:id", middlewares.CheckUserAuthentication, controllers.UpdateProductVariant)
+		variantGroup.DELETE("/:id", middlewares.CheckUserAuthentication, controllers.DeleteProductVariant)
+	}
+
+	// productImageGroup Маршруты для загрузки изображений товара
+	productImageGroup := r.Group("/products/images", middlewares.CheckUserAuthentication)
+	{
+		productImageGroup.POST("/:id", controllers.UploadProductImages)
+	}
+
+	// productImportGroup Маршруты для отслеживания импорта товаров из файла
+	productImportGroup := r.Group("/products/imports", middlewares.CheckUserAuthentication)
+	{
+		productImportGroup.GET("/:id", controllers.GetProductImport)
+	}
+
+	// compareGroup Маршруты для сравнения цен одного товара в разных магазинах
+	compareGroup := r.Group("/products/compare")
+	{
+		compareGroup.GET("/", controllers.SearchCanonicalProducts)
+		compareGroup.GET("/:id", controllers.GetProductOffers)
+	}
+
+	// modifierGroup Маршруты для модификаторов товара (добавки, «без лука» и т.д.)
+	modifierGroup := r.Group("/products/modifiers")
+	{
+		modifierGroup.GET("/:id", controllers.GetProductModifierGroups)
+		modifierGroup.POST("/:id", middlewares.CheckUserAuthentication, controllers.CreateModifierGroup)
+		modifierGroup.PUT("/:id", middlewares.CheckUserAuthentication, controllers.UpdateModifierGroup)
+		modifierGroup.DELETE("/:id", middlewares.CheckUserAuthentication, controllers.DeleteModifierGroup)
+	}
+
+	// inventoryGroup Маршруты для движений товара на складе
+	inventoryGroup := r.Group("/products/stock", middlewares.CheckUserAuthentication)
+	{
+		inventoryGroup.GET("/:id/history", controllers.GetProductStockHistory)
+		inventoryGroup.POST("/:id/adjustments", controllers.AdjustProductStock)
+	}
+
 	productReviewGroup := r.Group("/products/reviews")
 	{
 		productReviewGroup.GET("/:id", controllers.GetAllProductReviews)
@@ -122,18 +214,34 @@ func InitRoutes(r *gin.Engine) *gin.Engine {
 	{
 		orderGroup.GET("/", controllers.GetAllUserOrders)
 		orderGroup.GET("/:id", controllers.GetOrderByID)
-		orderGroup.POST("/", controllers.CreateOrder)
+		orderGroup.POST("/", middlewares.Idempotency, controllers.CreateOrder)
 		orderGroup.PUT("/:id", controllers.UpdateOrder)
 		orderGroup.DELETE("/:id", controllers.DeleteOrder)
+		orderGroup.POST("/:id/cancel", controllers.CancelOrder)
+		orderGroup.PUT("/:id/status", controllers.ChangeOrderStatus)
+		orderGroup.GET("/:id/history", controllers.GetOrderStatusHistory)
+	}
+
+	cartGroup := r.Group("/cart", middlewares.CheckUserAuthentication)
+	{
+		cartGroup.GET("/", controllers.GetCart)
+		cartGroup.POST("/", controllers.AddCartItem)
+		cartGroup.POST("/checkout", middlewares.Idempotency, controllers.Checkout)
+		cartGroup.PUT("/:product_id", controllers.UpdateCartItem)
+		cartGroup.DELETE("/:product_id", controllers.RemoveCartItem)
+		cartGroup.DELETE("/", controllers.ClearCart)
 	}
 
 	paymentGroup := r.Group("/payments", middlewares.CheckUserAuthentication)
 	{
 		paymentGroup.GET("/", controllers.GetUserPayments)
 		paymentGroup.GET("/:id", controllers.GetPaymentByID)
-		paymentGroup.POST("/", controllers.CreatePayment)
-		paymentGroup.PUT("/:id", controllers.UpdatePayment)
-		paymentGroup.DELETE("/:id", controllers.DeletePayment)
+		paymentGroup.POST("/", middlewares.Idempotency, controllers.CreatePayment)
+		// Изменение и удаление оплат убраны, вместо них возвраты. Старые клиенты получают 410 Gone
+		paymentGroup.PUT("/:id", controllers.PaymentChangeRemoved)
+		paymentGroup.DELETE("/:id", controllers.PaymentChangeRemoved)
+		paymentGroup.GET("/:id/refunds", controllers.GetPaymentRefunds)
+		paymentGroup.POST("/:id/refunds", controllers.RefundPayment)
 	}
 
 	commentGroup := r.Group("product/comments")
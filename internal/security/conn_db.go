@@ -12,6 +12,10 @@ var (
 	Password string
 	DBName   string
 	SSLMode  string
+
+	RedisPassword string
+	S3SecretKey   string
+	SMTPPassword  string
 )
 
 func SetConnDB(AppSettingsConfig models.Configs) {
@@ -23,4 +27,7 @@ func SetConnDB(AppSettingsConfig models.Configs) {
 	Password = os.Getenv("DB_PASSWORD")
 	DBName = postgresParams.Database
 	SSLMode = postgresParams.SSLMode
+	RedisPassword = os.Getenv("REDIS_PASSWORD")
+	S3SecretKey = os.Getenv("S3_SECRET_KEY")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
 }
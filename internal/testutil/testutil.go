@@ -0,0 +1,279 @@
+// Package testutil подключает тесты к отдельной базе PostgreSQL, создаёт в ней тестовые данные
+// и подменяет ошибкой выбранные запросы, чтобы проверять откат транзакций
+package testutil
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/security"
+	"BizMart/pkg/db"
+	"BizMart/pkg/logger"
+	"BizMart/pkg/utils"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DBNameEnv имя переменной окружения с базой для тестов. Тесты меняют данные и накатывают миграции,
+// поэтому база задаётся отдельно от рабочей, без неё тесты с базой пропускаются
+const DBNameEnv = "TEST_DB_NAME"
+
+const injectErrorCallback = "testutil:inject_error"
+
+// ErrInjected ошибка, которую возвращает запрос, подменённый через InjectError
+var ErrInjected = errors.New("ErrInjected")
+
+var (
+	connected bool
+	sequence  int64
+)
+
+// Setup подключается к тестовой базе и накатывает миграции, вызывается из TestMain.
+// Без TEST_DB_NAME ничего не делает, и тесты с базой пропускаются через RequireDB
+func Setup() error {
+	logger.Info = log.New(io.Discard, "", 0)
+	logger.Warn = log.New(io.Discard, "", 0)
+	logger.Debug = log.New(io.Discard, "", 0)
+	logger.Error = log.New(os.Stderr, "ERROR: ", log.Ltime)
+
+	security.DBName = os.Getenv(DBNameEnv)
+	if security.DBName == "" {
+		return nil
+	}
+
+	security.HostName = getEnv("TEST_DB_HOST", "localhost")
+	security.Port = getEnv("TEST_DB_PORT", "5432")
+	security.UserName = getEnv("TEST_DB_USER", "postgres")
+	security.Password = os.Getenv("TEST_DB_PASSWORD")
+	security.SSLMode = getEnv("TEST_DB_SSLMODE", "disable")
+
+	security.AppSettings.CacheParams = models.CacheParams{Backend: db.CacheBackendMemory, MaxEntries: 1000}
+	if err := db.InitializeCache(); err != nil {
+		return err
+	}
+
+	if err := db.ConnectToDB(); err != nil {
+		return err
+	}
+
+	if err := db.Migrate(); err != nil {
+		return err
+	}
+
+	connected = true
+	return nil
+}
+
+// RequireDB пропускает тест, если тестовая база не задана
+func RequireDB(t *testing.T) {
+	t.Helper()
+
+	if !connected {
+		t.Skipf("%s is not set, skipping test that needs a database", DBNameEnv)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+// InjectError заставляет первый create, update или delete, для которого match вернул true, завершиться
+// ошибкой ErrInjected. Подмена снимается по окончании теста
+func InjectError(t *testing.T, match func(tx *gorm.DB) bool) {
+	t.Helper()
+
+	var fired int32
+	inject := func(tx *gorm.DB) {
+		if match(tx) && atomic.CompareAndSwapInt32(&fired, 0, 1) {
+			_ = tx.AddError(ErrInjected)
+		}
+	}
+
+	callbacks := db.GetDBConn().Callback()
+	if err := callbacks.Create().Before("gorm:create").Register(injectErrorCallback, inject); err != nil {
+		t.Fatal(err)
+	}
+	if err := callbacks.Update().Before("gorm:update").Register(injectErrorCallback, inject); err != nil {
+		t.Fatal(err)
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register(injectErrorCallback, inject); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = callbacks.Create().Remove(injectErrorCallback)
+		_ = callbacks.Update().Remove(injectErrorCallback)
+		_ = callbacks.Delete().Remove(injectErrorCallback)
+
+		if atomic.LoadInt32(&fired) == 0 {
+			t.Error("injected error was never returned")
+		}
+	})
+}
+
+// Fixture покупатель со счётом и адресом и продавец с магазином, товаром и счётом
+type Fixture struct {
+	Buyer         models.User
+	BuyerAccount  models.Account
+	Address       models.Address
+	Seller        models.User
+	SellerAccount models.Account
+	Store         models.Store
+	Product       models.Product
+	Variant       models.ProductVariant
+}
+
+// NewFixture создаёт тестовые данные: товар с остатком stock по цене price и счёт покупателя с балансом balance.
+// Баланс проводится через журнал, как opening_balance, чтобы пересчёт по проводкам его не обнулил
+func NewFixture(t *testing.T, stock uint, price, balance float64) Fixture {
+	t.Helper()
+
+	suffix := fmt.Sprintf("%d_%d", time.Now().UnixNano(), atomic.AddInt64(&sequence, 1))
+	conn := db.GetDBConn()
+	var f Fixture
+
+	create := func(value interface{}) {
+		t.Helper()
+		if err := conn.Create(value).Error; err != nil {
+			t.Fatalf("creating fixture %T: %v", value, err)
+		}
+	}
+
+	verifiedAt := time.Now()
+	f.Buyer = models.User{
+		FirstName:       "Buyer",
+		LastName:        "Test",
+		Username:        "buyer_" + suffix,
+		Email:           "buyer_" + suffix + "@example.com",
+		HashPassword:    "-",
+		EmailVerifiedAt: &verifiedAt,
+	}
+	create(&f.Buyer)
+
+	f.Seller = models.User{
+		FirstName:       "Seller",
+		LastName:        "Test",
+		Username:        "seller_" + suffix,
+		Email:           "seller_" + suffix + "@example.com",
+		HashPassword:    "-",
+		EmailVerifiedAt: &verifiedAt,
+	}
+	create(&f.Seller)
+
+	f.BuyerAccount = models.Account{UserID: f.Buyer.ID, AccountNumber: "B" + suffix}
+	create(&f.BuyerAccount)
+
+	f.SellerAccount = models.Account{UserID: f.Seller.ID, AccountNumber: "S" + suffix}
+	create(&f.SellerAccount)
+
+	f.Address = models.Address{AddressName: "Test street " + suffix, UserID: f.Buyer.ID}
+	create(&f.Address)
+
+	f.Store = models.Store{Name: "Store " + suffix, OwnerID: f.Seller.ID}
+	create(&f.Store)
+
+	category := models.Category{CategoryName: "Category " + suffix}
+	create(&category)
+
+	f.Product = models.Product{
+		StoreID:     f.Store.ID,
+		CategoryID:  category.ID,
+		Title:       "Product " + suffix,
+		Description: "Test product",
+		Price:       price,
+		Amount:      stock,
+	}
+	create(&f.Product)
+
+	f.Variant = models.ProductVariant{
+		ProductID: f.Product.ID,
+		StoreID:   f.Store.ID,
+		SKU:       "SKU-" + suffix,
+		Price:     price,
+		Amount:    stock,
+		IsDefault: true,
+	}
+	create(&f.Variant)
+
+	if amount := utils.ToMinorUnits(balance); amount > 0 {
+		create(&models.JournalEntry{
+			Kind:        models.JournalKindOpeningBalance,
+			Description: "test opening balance",
+			Postings: []models.Posting{
+				{AccountID: &f.BuyerAccount.ID, Direction: models.PostingCredit, Amount: amount},
+				{SystemAccount: models.SystemAccountExternal, Direction: models.PostingDebit, Amount: amount},
+			},
+		})
+
+		if err := conn.Model(&f.BuyerAccount).Update("balance", balance).Error; err != nil {
+			t.Fatalf("setting fixture balance: %v", err)
+		}
+	}
+
+	return f
+}
+
+// Snapshot состояние данных фикстуры, которые меняют заказ и оплата
+type Snapshot struct {
+	VariantAmount  uint
+	ProductAmount  uint
+	BuyerBalance   float64
+	SellerBalance  float64
+	CartItems      int64
+	OrderStatusIDs []uint
+	OrderItems     int64
+	Reservations   []string
+	Movements      int64
+	Payments       int64
+	Postings       int64
+}
+
+// TakeSnapshot читает состояние данных фикстуры
+func (f Fixture) TakeSnapshot(t *testing.T) Snapshot {
+	t.Helper()
+
+	conn := db.GetDBConn()
+	var s Snapshot
+
+	queries := []*gorm.DB{
+		conn.Unscoped().Model(&models.ProductVariant{}).Select("amount").Where("id = ?", f.Variant.ID).Scan(&s.VariantAmount),
+		conn.Unscoped().Model(&models.Product{}).Select("amount").Where("id = ?", f.Product.ID).Scan(&s.ProductAmount),
+		conn.Unscoped().Model(&models.Account{}).Select("balance").Where("id = ?", f.BuyerAccount.ID).Scan(&s.BuyerBalance),
+		conn.Unscoped().Model(&models.Account{}).Select("balance").Where("id = ?", f.SellerAccount.ID).Scan(&s.SellerBalance),
+		conn.Model(&models.CartItem{}).Where("user_id = ?", f.Buyer.ID).Count(&s.CartItems),
+		conn.Unscoped().Model(&models.Order{}).Where("user_id = ?", f.Buyer.ID).Order("id").Pluck("status_id", &s.OrderStatusIDs),
+		conn.Unscoped().Model(&models.OrderItem{}).Where("product_id = ?", f.Product.ID).Count(&s.OrderItems),
+		conn.Model(&models.StockReservation{}).Where("product_id = ?", f.Product.ID).Order("id").Pluck("status", &s.Reservations),
+		conn.Model(&models.InventoryMovement{}).Where("product_id = ?", f.Product.ID).Count(&s.Movements),
+		conn.Unscoped().Model(&models.Payment{}).Where("user_id = ?", f.Buyer.ID).Count(&s.Payments),
+		conn.Model(&models.Posting{}).Where("account_id IN ?", []uint{f.BuyerAccount.ID, f.SellerAccount.ID}).Count(&s.Postings),
+	}
+
+	for _, query := range queries {
+		if query.Error != nil {
+			t.Fatalf("taking snapshot: %v", query.Error)
+		}
+	}
+
+	return s
+}
+
+// RequireUnchanged проверяет, что данные фикстуры не изменились с момента снимка before
+func (f Fixture) RequireUnchanged(t *testing.T, before Snapshot) {
+	t.Helper()
+
+	if after := f.TakeSnapshot(t); !reflect.DeepEqual(before, after) {
+		t.Fatalf("data changed after rolled back transaction:\nbefore: %+v\nafter:  %+v", before, after)
+	}
+}
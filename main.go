@@ -8,6 +8,8 @@ import (
 	"BizMart/internal/server"
 	db2 "BizMart/pkg/db"
 	"BizMart/pkg/logger"
+	"BizMart/pkg/mail"
+	"BizMart/pkg/storage"
 	"context"
 	"errors"
 	"fmt"
@@ -62,7 +64,17 @@ func main() {
 		panic(err)
 	}
 
-	err = db2.InitializeRedis()
+	err = db2.InitializeCache()
+	if err != nil {
+		panic(err)
+	}
+
+	err = storage.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	err = mail.Init()
 	if err != nil {
 		panic(err)
 	}
@@ -81,7 +93,11 @@ func main() {
 		}
 	}()
 
-	go jobs.UpdateProductCache()
+	go jobs.ReleaseExpiredReservations()
+	go jobs.MatchUnmatchedProducts()
+	go jobs.RebuildSalesStats()
+	go jobs.FlushProductViews()
+	go jobs.ProcessProductImports()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
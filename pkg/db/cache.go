@@ -0,0 +1,166 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// errCacheTagVersionLost версия тега пропала сразу после назначения, значение загружается без кэша
+var errCacheTagVersionLost = errors.New("cache tag version was evicted")
+
+// cacheLoads объединяет одновременные загрузки одного ключа, чтобы промах кэша не вызывал лавину запросов к базе
+var cacheLoads singleflight.Group
+
+// cacheEntry значение в кэше вместе с версиями тегов, действовавшими на момент загрузки
+type cacheEntry struct {
+	Versions []string        `json:"v"`
+	Data     json.RawMessage `json:"d"`
+}
+
+func cacheTagKey(tag string) string {
+	return "cache:tag:" + tag
+}
+
+// CacheKey строит ключ кэша из пространства имён и параметров запроса.
+// Параметры сортируются, поэтому один и тот же запрос с разным порядком параметров попадает в один ключ
+func CacheKey(namespace string, params url.Values) string {
+	sum := sha256.Sum256([]byte(params.Encode()))
+	return "cache:" + namespace + ":" + hex.EncodeToString(sum[:16])
+}
+
+// newCacheTagVersion возвращает начальную версию тега. Версия берётся из времени, а не с нуля,
+// поэтому тег, вытесненный из кэша или истёкший, не возвращается к версии, с которой уже сохранялись значения
+func newCacheTagVersion() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// InvalidateCacheTags делает устаревшими все значения, сохранённые с любым из тегов.
+// Версия тега увеличивается, и значения с прежней версией перестают возвращаться из кэша.
+// Отсутствующий тег получает новую версию вместо 1, которая могла использоваться до его вытеснения
+func InvalidateCacheTags(tags ...string) {
+	for _, tag := range tags {
+		key := cacheTagKey(tag)
+		seeded, err := cacheStore.SetNX(key, newCacheTagVersion(), 0)
+		if err == nil && !seeded {
+			_, err = cacheStore.Incr(key)
+		}
+		if err != nil {
+			log.Printf("Error invalidating cache tag %s: %v", tag, err)
+		}
+	}
+}
+
+// getCacheTagVersions возвращает текущие версии тегов, отсутствующим тегам назначается новая версия
+func getCacheTagVersions(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, cacheTagKey(tag))
+	}
+
+	versions, err := cacheStore.MGet(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, version := range versions {
+		if version != "" {
+			continue
+		}
+
+		version = newCacheTagVersion()
+		seeded, err := cacheStore.SetNX(keys[i], version, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		// Версию одновременно назначил другой запрос или тег успели инвалидировать
+		if !seeded {
+			if version, err = cacheStore.Get(keys[i]); err != nil {
+				return nil, err
+			}
+			if version == "" {
+				return nil, errCacheTagVersionLost
+			}
+		}
+
+		versions[i] = version
+	}
+
+	return versions, nil
+}
+
+func sameCacheVersions(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetOrLoadCache возвращает значение из кэша, если ни один из его тегов не был инвалидирован, иначе загружает его через load.
+// Версии тегов читаются до загрузки, поэтому значение, загруженное во время инвалидации, сразу считается устаревшим.
+// Одновременные промахи по одному ключу с одинаковыми версиями тегов выполняют load один раз,
+// загрузка, начатая до инвалидации, не отдаётся тем, кто пришёл после неё. Если кэш недоступен, значение загружается напрямую
+func GetOrLoadCache[T any](key string, tags []string, ttl time.Duration, load func() (T, error)) (T, error) {
+	versions, err := getCacheTagVersions(tags)
+	if err != nil {
+		log.Printf("Error getting cache tag versions: %v", err)
+		return load()
+	}
+
+	if cached, err := GetCache(key); err == nil && cached != "" {
+		var entry cacheEntry
+		if err = json.Unmarshal([]byte(cached), &entry); err == nil && sameCacheVersions(entry.Versions, versions) {
+			var value T
+			if err = json.Unmarshal(entry.Data, &value); err == nil {
+				return value, nil
+			}
+		}
+	}
+
+	data, err, _ := cacheLoads.Do(key+"|"+strings.Join(versions, ","), func() (interface{}, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := json.Marshal(cacheEntry{Versions: versions, Data: data})
+		if err == nil {
+			_ = SetCache(key, entry, ttl)
+		}
+
+		return data, nil
+	})
+
+	var value T
+	if err != nil {
+		return value, err
+	}
+
+	err = json.Unmarshal(data.([]byte), &value)
+	return value, err
+}
@@ -0,0 +1,147 @@
+package db
+
+import (
+	"BizMart/internal/security"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+const (
+	CacheBackendRedis  = "redis"
+	CacheBackendMemory = "memory"
+)
+
+// Cache хранилище кэша. Промах возвращает пустую строку без ошибки.
+// Ошибка означает, что кэш недоступен, и данные нужно брать из Postgres
+type Cache interface {
+	Ping() error
+	Get(key string) (string, error)
+	// MGet возвращает значения в порядке ключей, для отсутствующих ключей пустые строки
+	MGet(keys ...string) ([]string, error)
+	Set(key string, value interface{}, expiration time.Duration) error
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+	Delete(keys ...string) error
+	Incr(key string) (int64, error)
+	// RenameNX возвращает false, если исходного ключа нет или новый ключ уже занят
+	RenameNX(key, newKey string) (bool, error)
+	HIncrBy(key, field string, delta int64) error
+	// HGetAll для отсутствующего ключа возвращает пустой словарь
+	HGetAll(key string) (map[string]string, error)
+	HDel(key string, fields ...string) error
+}
+
+var cacheStore Cache = newMemoryCache(0)
+
+// InitializeCache создаёт кэш, выбранный в cache_params.
+// Если Redis недоступен, приложение всё равно запускается: данные берутся из Postgres, пока Redis не станет доступен
+func InitializeCache() error {
+	params := security.AppSettings.CacheParams
+
+	switch params.Backend {
+	case CacheBackendMemory:
+		cacheStore = newMemoryCache(params.MaxEntries)
+		log.Printf("Using in-memory cache with at most %d entries", cacheStore.(*memoryCache).maxEntries)
+	case CacheBackendRedis, "":
+		host, port := params.Host, params.Port
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "6379"
+		}
+
+		redisStore := newRedisCache(net.JoinHostPort(host, port), security.RedisPassword, params.DB)
+		cacheStore = redisStore
+
+		if err := redisStore.Ping(); err != nil {
+			log.Printf("Could not connect to Redis, serving data without cache until it is available: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown cache backend %q", params.Backend)
+	}
+
+	return nil
+}
+
+// SetCache записывает данные в кэш с указанным сроком жизни
+func SetCache(key string, value interface{}, expiration time.Duration) error {
+	err := cacheStore.Set(key, value, expiration)
+	if err != nil {
+		log.Printf("Error setting cache: %v", err)
+		return err
+	}
+	return nil
+}
+
+// SetCacheNX записывает данные в кэш, только если ключа ещё нет. Возвращает false, если ключ уже существует
+func SetCacheNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := cacheStore.SetNX(key, value, expiration)
+	if err != nil {
+		log.Printf("Error setting cache: %v", err)
+		return false, err
+	}
+	return ok, nil
+}
+
+// GetCache получает данные из кэша по ключу, для отсутствующего ключа возвращает пустую строку
+func GetCache(key string) (string, error) {
+	val, err := cacheStore.Get(key)
+	if err != nil {
+		log.Printf("Error getting cache: %v", err)
+		return "", err
+	}
+	return val, nil
+}
+
+// DeleteCache удаляет данные из кэша по ключу
+func DeleteCache(key string) error {
+	err := cacheStore.Delete(key)
+	if err != nil {
+		log.Printf("Error deleting cache: %v", err)
+		return err
+	}
+	return nil
+}
+
+// IncrementHashCache увеличивает поле хэша на delta
+func IncrementHashCache(key, field string, delta int64) error {
+	err := cacheStore.HIncrBy(key, field, delta)
+	if err != nil {
+		log.Printf("Error incrementing hash field in cache: %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetHashCache получает все поля хэша, для отсутствующего ключа возвращает пустой словарь
+func GetHashCache(key string) (map[string]string, error) {
+	val, err := cacheStore.HGetAll(key)
+	if err != nil {
+		log.Printf("Error getting hash from cache: %v", err)
+		return nil, err
+	}
+	return val, nil
+}
+
+// RenameCacheNX переименовывает ключ, если новый ключ ещё не существует.
+// Возвращает false, если исходного ключа нет или новый ключ уже занят
+func RenameCacheNX(key, newKey string) (bool, error) {
+	ok, err := cacheStore.RenameNX(key, newKey)
+	if err != nil {
+		log.Printf("Error renaming key in cache: %v", err)
+		return false, err
+	}
+	return ok, nil
+}
+
+// DeleteHashCacheFields удаляет поля хэша
+func DeleteHashCacheFields(key string, fields ...string) error {
+	err := cacheStore.HDel(key, fields...)
+	if err != nil {
+		log.Printf("Error deleting hash fields from cache: %v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadCacheAfterTagEviction(t *testing.T) {
+	previous := cacheStore
+	cacheStore = newMemoryCache(0)
+	t.Cleanup(func() { cacheStore = previous })
+
+	const key, tag = "cache:test:list", "test:tag"
+	loads := 0
+	load := func() (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	get := func() int {
+		t.Helper()
+
+		value, err := GetOrLoadCache(key, []string{tag}, time.Minute, load)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+
+	if got := get(); got != 1 {
+		t.Fatalf("expected the first load, got %d", got)
+	}
+	if got := get(); got != 1 {
+		t.Fatalf("expected the cached value, got %d", got)
+	}
+
+	InvalidateCacheTags(tag)
+	if got := get(); got != 2 {
+		t.Fatalf("expected a reload after invalidation, got %d", got)
+	}
+
+	// Тег вытеснен и инвалидирован заново: значение, сохранённое до вытеснения, не должно вернуться
+	if err := cacheStore.Delete(cacheTagKey(tag)); err != nil {
+		t.Fatal(err)
+	}
+	InvalidateCacheTags(tag)
+	if got := get(); got != 3 {
+		t.Fatalf("expected a reload after the tag was evicted, got %d", got)
+	}
+
+	// Тег вытеснен без инвалидации: значение сохранено со старой версией и тоже перезагружается
+	if err := cacheStore.Delete(cacheTagKey(tag)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(); got != 4 {
+		t.Fatalf("expected a reload after the tag expired, got %d", got)
+	}
+}
+
+func TestGetOrLoadCacheDoesNotShareLoadAcrossInvalidation(t *testing.T) {
+	previous := cacheStore
+	cacheStore = newMemoryCache(0)
+	t.Cleanup(func() { cacheStore = previous })
+
+	const key, tag = "cache:test:inflight", "test:inflight"
+	started, release := make(chan struct{}), make(chan struct{})
+
+	stale := make(chan int, 1)
+	go func() {
+		value, _ := GetOrLoadCache(key, []string{tag}, time.Minute, func() (int, error) {
+			close(started)
+			<-release
+			return 1, nil
+		})
+		stale <- value
+	}()
+
+	<-started
+	InvalidateCacheTags(tag)
+
+	// Загрузка со старыми версиями ещё идёт, после инвалидации значение должно загрузиться заново
+	fresh := make(chan int, 1)
+	go func() {
+		value, _ := GetOrLoadCache(key, []string{tag}, time.Minute, func() (int, error) { return 2, nil })
+		fresh <- value
+	}()
+
+	select {
+	case value := <-fresh:
+		close(release)
+		if value != 2 {
+			t.Fatalf("expected a fresh load after invalidation, got %d", value)
+		}
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("expected a fresh load after invalidation, but the call waited for the stale load")
+	}
+
+	if got := <-stale; got != 1 {
+		t.Fatalf("expected the in-flight load to finish with its own value, got %d", got)
+	}
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMemoryCacheEntries размер кэша в памяти, если max_entries не задан
+const defaultMemoryCacheEntries = 10000
+
+var errWrongCacheType = errors.New("cache key holds a value of another type")
+
+// memoryCacheItem значение ключа: строка или хэш
+type memoryCacheItem struct {
+	key       string
+	value     string
+	hash      map[string]string
+	expiresAt time.Time
+}
+
+func (item *memoryCacheItem) expired(now time.Time) bool {
+	return !item.expiresAt.IsZero() && !now.Before(item.expiresAt)
+}
+
+// memoryCache LRU-кэш в памяти процесса с теми же операциями, что и Redis.
+// Данные не разделяются между экземплярами приложения и теряются при перезапуске
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	// order ключи от недавно использованных к давно неиспользованным
+	order *list.List
+}
+
+func newMemoryCache(maxEntries int) *memoryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryCacheEntries
+	}
+
+	return &memoryCache{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// get возвращает живой элемент и отмечает его использованным, истёкший элемент удаляется
+func (m *memoryCache) get(key string) *memoryCacheItem {
+	element, ok := m.items[key]
+	if !ok {
+		return nil
+	}
+
+	item := element.Value.(*memoryCacheItem)
+	if item.expired(time.Now()) {
+		m.remove(element)
+		return nil
+	}
+
+	m.order.MoveToFront(element)
+	return item
+}
+
+// put сохраняет элемент и вытесняет давно неиспользованные ключи сверх лимита.
+// Ключи без срока жизни (версии тегов, накопленные просмотры) вытесняются последними, иначе потерялась бы инвалидация
+func (m *memoryCache) put(item *memoryCacheItem) {
+	if element, ok := m.items[item.key]; ok {
+		element.Value = item
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.items[item.key] = m.order.PushFront(item)
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.evictionCandidate())
+	}
+}
+
+func (m *memoryCache) evictionCandidate() *list.Element {
+	for element := m.order.Back(); element != nil; element = element.Prev() {
+		if !element.Value.(*memoryCacheItem).expiresAt.IsZero() {
+			return element
+		}
+	}
+	return m.order.Back()
+}
+
+func (m *memoryCache) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.items, element.Value.(*memoryCacheItem).key)
+}
+
+func expiresAt(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expiration)
+}
+
+// cacheString приводит значение к строке так же, как его записал бы Redis
+func cacheString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (m *memoryCache) Ping() error {
+	return nil
+}
+
+func (m *memoryCache) Get(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := m.get(key)
+	if item == nil {
+		return "", nil
+	}
+	if item.hash != nil {
+		return "", errWrongCacheType
+	}
+	return item.value, nil
+}
+
+func (m *memoryCache) MGet(keys ...string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := ""
+		if item := m.get(key); item != nil && item.hash == nil {
+			value = item.value
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func (m *memoryCache) Set(key string, value interface{}, expiration time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.put(&memoryCacheItem{key: key, value: cacheString(value), expiresAt: expiresAt(expiration)})
+	return nil
+}
+
+func (m *memoryCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.get(key) != nil {
+		return false, nil
+	}
+
+	m.put(&memoryCacheItem{key: key, value: cacheString(value), expiresAt: expiresAt(expiration)})
+	return true, nil
+}
+
+func (m *memoryCache) Delete(keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := m.items[key]; ok {
+			m.remove(element)
+		}
+	}
+	return nil
+}
+
+func (m *memoryCache) Incr(key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := m.get(key)
+	if item == nil {
+		m.put(&memoryCacheItem{key: key, value: "1"})
+		return 1, nil
+	}
+	if item.hash != nil {
+		return 0, errWrongCacheType
+	}
+
+	value, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, errWrongCacheType
+	}
+
+	value++
+	item.value = strconv.FormatInt(value, 10)
+	return value, nil
+}
+
+func (m *memoryCache) RenameNX(key, newKey string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := m.get(key)
+	if item == nil || m.get(newKey) != nil {
+		return false, nil
+	}
+
+	m.remove(m.items[key])
+	item.key = newKey
+	m.put(item)
+	return true, nil
+}
+
+func (m *memoryCache) HIncrBy(key, field string, delta int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := m.get(key)
+	if item == nil {
+		item = &memoryCacheItem{key: key, hash: make(map[string]string)}
+		m.put(item)
+	}
+	if item.hash == nil {
+		return errWrongCacheType
+	}
+
+	value, err := strconv.ParseInt(item.hash[field], 10, 64)
+	if err != nil && item.hash[field] != "" {
+		return errWrongCacheType
+	}
+
+	item.hash[field] = strconv.FormatInt(value+delta, 10)
+	return nil
+}
+
+func (m *memoryCache) HGetAll(key string) (map[string]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make(map[string]string)
+	item := m.get(key)
+	if item == nil {
+		return result, nil
+	}
+	if item.hash == nil {
+		return nil, errWrongCacheType
+	}
+
+	for field, value := range item.hash {
+		result[field] = value
+	}
+	return result, nil
+}
+
+func (m *memoryCache) HDel(key string, fields ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item := m.get(key)
+	if item == nil {
+		return nil
+	}
+	if item.hash == nil {
+		return errWrongCacheType
+	}
+
+	for _, field := range fields {
+		delete(item.hash, field)
+	}
+
+	// Как и в Redis, хэш без полей удаляется
+	if len(item.hash) == 0 {
+		m.remove(m.items[key])
+	}
+	return nil
+}
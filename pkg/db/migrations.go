@@ -2,7 +2,12 @@ package db
 
 import (
 	models2 "BizMart/internal/app/models"
+	"BizMart/pkg/utils"
 	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"os"
 )
 
 func Migrate() error {
@@ -10,6 +15,9 @@ func Migrate() error {
 		return errors.New("database connection is not initialized")
 	}
 
+	// Пользователи, зарегистрированные до подтверждения email, считаются подтверждёнными
+	verifyExistingEmails := !dbConn.Migrator().HasColumn(&models2.User{}, "EmailVerifiedAt")
+
 	err := dbConn.AutoMigrate(
 		&models2.User{},
 		&models2.Store{},
@@ -17,21 +25,372 @@ func Migrate() error {
 		&models2.Address{},
 		&models2.UserProfile{},
 		&models2.Account{},
+		&models2.UserSession{},
+		&models2.UserToken{},
+		&models2.Permission{},
+		&models2.Role{},
+		&models2.UserRole{},
+		&models2.RoleAudit{},
 		&models2.Category{},
 		&models2.Comment{},
+		&models2.StoreMember{},
+		&models2.StoreInvitation{},
 		&models2.FeaturedProduct{},
+		&models2.CanonicalProduct{},
 		&models2.Product{},
+		&models2.ProductVariant{},
+		&models2.VariantOption{},
+		&models2.ModifierGroup{},
+		&models2.Modifier{},
 		&models2.ProductImage{},
+		&models2.InventoryMovement{},
 		&models2.Order{},
 		&models2.OrderDetails{},
+		&models2.OrderItem{},
+		&models2.OrderItemModifier{},
+		&models2.StockReservation{},
+		&models2.CartItem{},
 		&models2.OrderStatus{},
+		&models2.OrderStatusHistory{},
 		&models2.Review{},
 		&models2.Payment{},
+		&models2.JournalEntry{},
+		&models2.Posting{},
+		&models2.Refund{},
+		&models2.RefundItem{},
+		&models2.IdempotencyKey{},
+		&models2.ProductSalesStats{},
+		&models2.StoreSalesStats{},
+		&models2.ProductImport{},
+		&models2.ProductImportError{},
 	)
 
 	if err != nil {
 		return err
 	}
 
+	if err = postOpeningBalances(); err != nil {
+		return err
+	}
+
+	if err = seedOrderStatuses(); err != nil {
+		return err
+	}
+
+	if err = seedDefaultVariants(); err != nil {
+		return err
+	}
+
+	if err = dropObsoleteIndexes(); err != nil {
+		return err
+	}
+
+	if err = setupProductSearch(); err != nil {
+		return err
+	}
+
+	if err = restrictCategoryDeletes(); err != nil {
+		return err
+	}
+
+	if err = seedRoles(); err != nil {
+		return err
+	}
+
+	if err = grantInitialRoles(); err != nil {
+		return err
+	}
+
+	if err = addStoreOwnerMembers(); err != nil {
+		return err
+	}
+
+	if verifyExistingEmails {
+		if err = dbConn.Exec("UPDATE users SET email_verified_at = NOW() WHERE email_verified_at IS NULL").Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postOpeningBalances переносит в журнал балансы счетов, созданных до его появления: иначе баланс,
+// пересчитанный по проводкам при следующем движении денег, обнулился бы. Для каждого счёта с ненулевым
+// балансом и без проводок проводится одна запись opening_balance против внешнего счёта
+func postOpeningBalances() error {
+	var accounts []models2.Account
+	if err := dbConn.Where("balance <> 0 AND NOT EXISTS (SELECT 1 FROM ledgerapp_posting AS p WHERE p.account_id = accountapp_account.id)").
+		Find(&accounts).Error; err != nil {
+		return err
+	}
+
+	for _, account := range accounts {
+		amount := utils.ToMinorUnits(account.Balance)
+		if amount == 0 {
+			continue
+		}
+
+		// Положительный баланс приходит на счёт извне, отрицательный — уходит со счёта
+		accountDirection, externalDirection := models2.PostingCredit, models2.PostingDebit
+		if amount < 0 {
+			amount = -amount
+			accountDirection, externalDirection = models2.PostingDebit, models2.PostingCredit
+		}
+
+		accountID := account.ID
+		entry := models2.JournalEntry{
+			Kind:        models2.JournalKindOpeningBalance,
+			Description: fmt.Sprintf("opening balance of account %s", account.AccountNumber),
+			Postings: []models2.Posting{
+				{AccountID: &accountID, Direction: accountDirection, Amount: amount},
+				{SystemAccount: models2.SystemAccountExternal, Direction: externalDirection, Amount: amount},
+			},
+		}
+
+		if err := dbConn.Transaction(func(tx *gorm.DB) error {
+			// Счёт блокируется, чтобы запись не провелась дважды при одновременном запуске
+			var posted int64
+			if err := tx.Exec("SELECT 1 FROM accountapp_account WHERE id = ? FOR UPDATE", accountID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models2.Posting{}).Where("account_id = ?", accountID).Count(&posted).Error; err != nil {
+				return err
+			}
+			if posted > 0 {
+				return nil
+			}
+
+			return tx.Create(&entry).Error
+		}); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+// seedOrderStatuses создаёт статусы заказов, известные машине состояний
+func seedOrderStatuses() error {
+	for _, status := range models2.DefaultOrderStatuses {
+		var existing models2.OrderStatus
+		err := dbConn.Where("code = ?", status.Code).First(&existing).Error
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Статус с таким именем мог быть создан вручную до появления кодов
+		err = dbConn.Where("status_name = ?", status.StatusName).First(&existing).Error
+		if err == nil {
+			if err = dbConn.Model(&existing).Update("code", status.Code).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err = dbConn.Create(&status).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedDefaultVariants создаёт вариант по умолчанию для товаров без вариантов
+// и проставляет его в записях, созданных до появления вариантов
+func seedDefaultVariants() error {
+	if err := dbConn.Exec(`INSERT INTO productapp_productvariant (product_id, store_id, sku, title, price, amount, is_default, images, created_at, updated_at)
+		SELECT p.id, p.store_id, 'P' || p.id || '-DEFAULT', 'default', p.price, p.amount, true, p.product_image_list, NOW(), NOW()
+		FROM productapp_product AS p
+		WHERE NOT EXISTS (SELECT 1 FROM productapp_productvariant AS v WHERE v.product_id = p.id)`).Error; err != nil {
+		return err
+	}
+
+	// Варианты, созданные до появления store_id, получают магазин своего товара
+	if err := dbConn.Exec(`UPDATE productapp_productvariant AS v SET store_id = p.store_id
+		FROM productapp_product AS p
+		WHERE v.product_id = p.id AND v.store_id = 0`).Error; err != nil {
+		return err
+	}
+
+	tables := []string{
+		models2.OrderItem{}.TableName(),
+		models2.CartItem{}.TableName(),
+		models2.StockReservation{}.TableName(),
+		models2.InventoryMovement{}.TableName(),
+	}
+	for _, table := range tables {
+		if err := dbConn.Exec(`UPDATE ` + table + ` AS t SET variant_id = v.id
+			FROM productapp_productvariant AS v
+			WHERE (t.variant_id IS NULL OR t.variant_id = 0) AND v.product_id = t.product_id AND v.is_default`).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropObsoleteIndexes удаляет индексы, которые AutoMigrate оставляет после смены уникальности.
+// Строка корзины была уникальной по товару, затем по варианту, теперь — по варианту с набором модификаторов.
+// SKU был уникален во всём маркетплейсе, теперь — среди неудалённых вариантов магазина.
+func dropObsoleteIndexes() error {
+	indexes := []struct {
+		model interface{}
+		name  string
+	}{
+		{&models2.CartItem{}, "idx_cart_user_product"},
+		{&models2.CartItem{}, "idx_cart_user_variant"},
+		{&models2.ProductVariant{}, "idx_productapp_productvariant_sku"},
+	}
+
+	for _, index := range indexes {
+		if !dbConn.Migrator().HasIndex(index.model, index.name) {
+			continue
+		}
+
+		if err := dbConn.Migrator().DropIndex(index.model, index.name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupProductSearch добавляет полнотекстовый индекс товаров (русская и английская морфология, название важнее описания)
+// и триграммный индекс названий для поиска с опечатками
+func setupProductSearch() error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`ALTER TABLE productapp_product ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+			setweight(to_tsvector('russian'::regconfig, coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('english'::regconfig, coalesce(title, '')), 'A') ||
+			setweight(to_tsvector('russian'::regconfig, coalesce(description, '')), 'B') ||
+			setweight(to_tsvector('english'::regconfig, coalesce(description, '')), 'B')
+		) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_product_search_vector ON productapp_product USING GIN (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_product_title_trgm ON productapp_product USING GIN (lower(title) gin_trgm_ops)`,
+	}
+
+	for _, statement := range statements {
+		if err := dbConn.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restrictCategoryDeletes заменяет каскадное удаление товаров вместе с категорией на запрет удаления.
+// AutoMigrate не меняет существующие внешние ключи, поэтому старый ключ с ON DELETE CASCADE пересоздаётся
+func restrictCategoryDeletes() error {
+	var constraints []string
+	if err := dbConn.Raw(`SELECT conname FROM pg_constraint
+		WHERE conrelid = 'productapp_product'::regclass AND confrelid = 'categoryapp_category'::regclass
+		AND contype = 'f' AND confdeltype = 'c'`).Scan(&constraints).Error; err != nil {
+		return err
+	}
+
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	for _, name := range constraints {
+		if err := dbConn.Exec(`ALTER TABLE productapp_product DROP CONSTRAINT "` + name + `"`).Error; err != nil {
+			return err
+		}
+	}
+
+	return dbConn.Migrator().CreateConstraint(&models2.Product{}, "Category")
+}
+
+// seedRoles создаёт права и роли из models.DefaultRoles и приводит права ролей к описанным в коде
+func seedRoles() error {
+	permissions := make(map[string]models2.Permission, len(models2.DefaultPermissions))
+	for _, permission := range models2.DefaultPermissions {
+		if err := dbConn.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description"}),
+		}).Create(&permission).Error; err != nil {
+			return err
+		}
+
+		if err := dbConn.Where("code = ?", permission.Code).First(&permission).Error; err != nil {
+			return err
+		}
+		permissions[permission.Code] = permission
+	}
+
+	for _, defaultRole := range models2.DefaultRoles {
+		role := models2.Role{Code: defaultRole.Code, Name: defaultRole.Name, Description: defaultRole.Description}
+		if err := dbConn.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name", "description"}),
+		}).Omit("Permissions").Create(&role).Error; err != nil {
+			return err
+		}
+
+		if err := dbConn.Where("code = ?", role.Code).First(&role).Error; err != nil {
+			return err
+		}
+
+		rolePermissions := make([]models2.Permission, 0, len(defaultRole.Permissions))
+		for _, permission := range defaultRole.Permissions {
+			rolePermissions = append(rolePermissions, permissions[permission.Code])
+		}
+
+		if err := dbConn.Model(&role).Association("Permissions").Replace(rolePermissions); err != nil {
+			return err
+		}
+	}
+
+	// Права ролей кэшируются проверкой доступа
+	InvalidateCacheTags("roles")
+	return nil
+}
+
+// grantInitialRoles выдаёт роли пользователям, созданным до появления ролей: администратору из переменной ADMIN
+// и пользователям с UserProfile.IsAdmin — platform_admin, владельцам магазинов — seller, остальным — buyer.
+// Роль выдаётся только тем, кому её ещё никогда не выдавали, поэтому отозванная роль не возвращается при перезапуске
+func grantInitialRoles() error {
+	adminUsers := `SELECT id FROM users WHERE username = ?
+		UNION SELECT u.id FROM users AS u JOIN userapp_userprofile AS p ON p.username = u.username
+		WHERE p.is_admin AND p.deleted_at IS NULL`
+	if err := grantRoleToUsers(models2.RolePlatformAdmin, adminUsers, os.Getenv("ADMIN")); err != nil {
+		return err
+	}
+
+	if err := grantRoleToUsers(models2.RoleSeller, `SELECT owner_id FROM stores WHERE deleted_at IS NULL`); err != nil {
+		return err
+	}
+
+	return grantRoleToUsers(models2.RoleBuyer, `SELECT id FROM users`)
+}
+
+// grantRoleToUsers выдаёт роль пользователям из usersQuery, которые её ещё не получали, и записывает выдачу в журнал
+func grantRoleToUsers(roleCode, usersQuery string, args ...interface{}) error {
+	values := append([]interface{}{roleCode}, args...)
+	values = append(values, roleCode)
+
+	return dbConn.Exec(`WITH granted AS (
+			INSERT INTO userapp_userrole (user_id, role_id, created_at)
+			SELECT u.id, r.id, NOW() FROM users AS u JOIN userapp_role AS r ON r.code = ?
+			WHERE u.deleted_at IS NULL AND u.id IN (`+usersQuery+`)
+			AND NOT EXISTS (SELECT 1 FROM userapp_roleaudit AS a WHERE a.user_id = u.id AND a.role_code = r.code)
+			ON CONFLICT (user_id, role_id) DO NOTHING
+			RETURNING user_id
+		)
+		INSERT INTO userapp_roleaudit (user_id, role_code, action, reason, created_at)
+		SELECT user_id, ?, 'grant', 'granted on migration', NOW() FROM granted`, values...).Error
+}
+
+// addStoreOwnerMembers добавляет владельцев магазинов, созданных до появления сотрудников, в сотрудники с ролью owner
+func addStoreOwnerMembers() error {
+	return dbConn.Exec(`INSERT INTO storeapp_storemember (store_id, user_id, role, created_at, updated_at)
+		SELECT id, owner_id, ?, NOW(), NOW() FROM stores WHERE deleted_at IS NULL
+		ON CONFLICT (store_id, user_id) DO UPDATE SET role = EXCLUDED.role`, models2.StoreRoleOwner).Error
+}
@@ -2,64 +2,161 @@ package db
 
 import (
 	"context"
-	"log"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	// redisTimeout короткий таймаут, чтобы недоступный Redis не задерживал запросы, которые можно обслужить из Postgres
+	redisTimeout = 500 * time.Millisecond
+	// redisRetryInterval сколько после ошибки соединения запросы не отправляются в Redis
+	redisRetryInterval = 5 * time.Second
+)
+
 var (
-	RedisClient *redis.Client
-	ctx         = context.Background()
+	ctx = context.Background()
+
+	errRedisUnavailable = errors.New("redis is unavailable")
 )
 
-// InitializeRedis инициализирует соединение с Redis
-func InitializeRedis() error {
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379", // адрес Redis-сервера
-		Password: "",               // если пароль не установлен, оставьте пустым
-		DB:       0,                // используемая база данных Redis
-	})
+// redisCache кэш в Redis, общий для всех экземпляров приложения
+type redisCache struct {
+	client *redis.Client
+	// downUntil время в наносекундах, до которого Redis считается недоступным
+	downUntil int64
+}
 
-	// Проверка соединения
-	_, err := RedisClient.Ping(ctx).Result()
-	if err != nil {
-		log.Fatalf("Could not connect to Redis: %v", err)
-		return err
+func newRedisCache(addr, password string, database int) *redisCache {
+	return &redisCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:         addr,
+			Password:     password,
+			DB:           database,
+			DialTimeout:  redisTimeout,
+			ReadTimeout:  redisTimeout,
+			WriteTimeout: redisTimeout,
+		}),
 	}
+}
 
+// available сообщает, можно ли обращаться к Redis, после ошибки соединения запросы сразу получают errRedisUnavailable
+func (r *redisCache) available() error {
+	if time.Now().UnixNano() < atomic.LoadInt64(&r.downUntil) {
+		return errRedisUnavailable
+	}
 	return nil
 }
 
-// SetCache записывает данные в кэш с указанным сроком жизни
-func SetCache(key string, value interface{}, expiration time.Duration) error {
-	err := RedisClient.Set(ctx, key, value, expiration).Err()
-	if err != nil {
-		log.Printf("Error setting cache in Redis: %v", err)
-		return err
+// check отмечает Redis недоступным при ошибке соединения, ответы сервера с ошибкой и промахи на это не влияют
+func (r *redisCache) check(err error) error {
+	var replyErr redis.Error
+	if err != nil && err != redis.Nil && !errors.As(err, &replyErr) {
+		atomic.StoreInt64(&r.downUntil, time.Now().Add(redisRetryInterval).UnixNano())
 	}
-	return nil
+	return err
 }
 
-// GetCache получает данные из кэша по ключу
-func GetCache(key string) (string, error) {
-	val, err := RedisClient.Get(ctx, key).Result()
+func (r *redisCache) Ping() error {
+	// Проверка соединения выполняется всегда, чтобы восстановление Redis было замечено сразу
+	return r.check(r.client.Ping(ctx).Err())
+}
+
+func (r *redisCache) Get(key string) (string, error) {
+	if err := r.available(); err != nil {
+		return "", err
+	}
+
+	val, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
-		log.Printf("Key does not exist in Redis: %s", key)
 		return "", nil
-	} else if err != nil {
-		log.Printf("Error getting cache from Redis: %v", err)
-		return "", err
 	}
-	return val, nil
+	return val, r.check(err)
 }
 
-// DeleteCache удаляет данные из кэша по ключу
-func DeleteCache(key string) error {
-	err := RedisClient.Del(ctx, key).Err()
+func (r *redisCache) MGet(keys ...string) ([]string, error) {
+	if err := r.available(); err != nil {
+		return nil, err
+	}
+
+	values, err := r.client.MGet(ctx, keys...).Result()
 	if err != nil {
-		log.Printf("Error deleting cache from Redis: %v", err)
+		return nil, r.check(err)
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		text, _ := value.(string)
+		result = append(result, text)
+	}
+	return result, nil
+}
+
+func (r *redisCache) Set(key string, value interface{}, expiration time.Duration) error {
+	if err := r.available(); err != nil {
 		return err
 	}
-	return nil
+	return r.check(r.client.Set(ctx, key, value, expiration).Err())
+}
+
+func (r *redisCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	if err := r.available(); err != nil {
+		return false, err
+	}
+
+	ok, err := r.client.SetNX(ctx, key, value, expiration).Result()
+	return ok, r.check(err)
+}
+
+func (r *redisCache) Delete(keys ...string) error {
+	if err := r.available(); err != nil {
+		return err
+	}
+	return r.check(r.client.Del(ctx, keys...).Err())
+}
+
+func (r *redisCache) Incr(key string) (int64, error) {
+	if err := r.available(); err != nil {
+		return 0, err
+	}
+
+	val, err := r.client.Incr(ctx, key).Result()
+	return val, r.check(err)
+}
+
+func (r *redisCache) RenameNX(key, newKey string) (bool, error) {
+	if err := r.available(); err != nil {
+		return false, err
+	}
+
+	ok, err := r.client.RenameNX(ctx, key, newKey).Result()
+	if err != nil && err.Error() == "ERR no such key" {
+		return false, nil
+	}
+	return ok, r.check(err)
+}
+
+func (r *redisCache) HIncrBy(key, field string, delta int64) error {
+	if err := r.available(); err != nil {
+		return err
+	}
+	return r.check(r.client.HIncrBy(ctx, key, field, delta).Err())
+}
+
+func (r *redisCache) HGetAll(key string) (map[string]string, error) {
+	if err := r.available(); err != nil {
+		return nil, err
+	}
+
+	val, err := r.client.HGetAll(ctx, key).Result()
+	return val, r.check(err)
+}
+
+func (r *redisCache) HDel(key string, fields ...string) error {
+	if err := r.available(); err != nil {
+		return err
+	}
+	return r.check(r.client.HDel(ctx, key, fields...).Err())
 }
@@ -14,4 +14,14 @@ var (
 	ErrEmailOrPasswordIsEmpty      = errors.New("ErrEmailOrPasswordIsEmpty")
 	ErrPermissionDenied            = errors.New("ErrPermissionDenied")
 	ErrUnauthorized                = errors.New("ErrUnauthorized")
+	ErrRefreshTokenReused          = errors.New("ErrRefreshTokenReused")
+	ErrSessionRevoked              = errors.New("ErrSessionRevoked")
+	ErrSessionNotFound             = errors.New("ErrSessionNotFound")
+	ErrRoleNotFound                = errors.New("ErrRoleNotFound")
+	ErrCannotRevokeOwnAdminRole    = errors.New("ErrCannotRevokeOwnAdminRole")
+	ErrInvalidUserToken            = errors.New("ErrInvalidUserToken")
+	ErrUserTokenExpired            = errors.New("ErrUserTokenExpired")
+	ErrEmailNotVerified            = errors.New("ErrEmailNotVerified")
+	ErrEmailAlreadyVerified        = errors.New("ErrEmailAlreadyVerified")
+	ErrMailRecentlySent            = errors.New("ErrMailRecentlySent")
 )
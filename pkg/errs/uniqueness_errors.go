@@ -12,4 +12,5 @@ var (
 	ErrCategoryNameUniquenessFailed    = errors.New("ErrCategoryNameUniquenessFailed")
 	ErrOrderStatusNameUniquenessFailed = errors.New("ErrOrderStatusNameUniquenessFailed")
 	ErrStoreNameUniquenessFailed       = errors.New("ErrStoreNameUniquenessFailed")
+	ErrSKUUniquenessFailed             = errors.New("ErrSKUUniquenessFailed")
 )
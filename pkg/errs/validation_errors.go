@@ -4,38 +4,81 @@ import "errors"
 
 // Validation Errors
 var (
-	ErrInvalidData              = errors.New("ErrInvalidData")
-	ErrValidationFailed         = errors.New("ErrValidationFailed")
-	ErrPathParametrized         = errors.New("ErrPathParametrized")
-	ErrInvalidMinPrice          = errors.New("ErrInvalidMinPrice")
-	ErrInvalidMaxPrice          = errors.New("ErrInvalidMaxPrice")
-	ErrInvalidAmount            = errors.New("ErrInvalidAmount")
-	ErrInvalidPrice             = errors.New("ErrInvalidPrice")
-	ErrInsufficientFunds        = errors.New("ErrInsufficientFunds")
-	ErrOrderAlreadyPayed        = errors.New("ErrOrderAlreadyPayed")
-	ErrInvalidCategory          = errors.New("ErrInvalidCategory")
-	ErrInvalidStore             = errors.New("ErrInvalidStore")
-	ErrOrderHasBeenPaidFor      = errors.New("ErrOrderHasBeenPaidFor")
-	ErrInvalidID                = errors.New("ErrInvalidID")
-	ErrInvalidPaymentID         = errors.New("ErrInvalidPaymentID")
-	ErrInvalidOrderID           = errors.New("ErrInvalidOrderID")
-	ErrInvalidQuantity          = errors.New("ErrInvalidQuantity")
-	ErrInvalidFeaturedProductID = errors.New("ErrInvalidFeaturedProductID")
-	ErrInvalidAccountID         = errors.New("ErrInvalidAccountID")
-	ErrInvalidAddressID         = errors.New("ErrInvalidAddressID")
-	ErrInvalidProductID         = errors.New("ErrInvalidProductID")
-	ErrInvalidProductReviewID   = errors.New("ErrInvalidProductReviewID")
-	ErrInvalidStoreID           = errors.New("ErrInvalidStoreID")
-	ErrInvalidStoreReviewID     = errors.New("ErrInvalidStoreReviewID")
-	ErrInvalidComment           = errors.New("ErrInvalidComment")
-	ErrInvalidContent           = errors.New("ErrInvalidContent")
-	ErrInvalidBalance           = errors.New("ErrInvalidBalance")
-	ErrInvalidRating            = errors.New("ErrInvalidRating")
-	ErrInvalidTitle             = errors.New("ErrInvalidTitle")
-	ErrInvalidToken             = errors.New("ErrInvalidToken")
-	ErrNotEnoughProductInStock  = errors.New("ErrNotEnoughProductInStock")
-	ErrRefreshTokenExpired      = errors.New("ErrRefreshTokenExpired")
-	ErrInvalidAddressName       = errors.New("ErrInvalidAddressName")
-	ErrInvalidAccountNumber     = errors.New("ErrInvalidAccountNumber")
-	ErrInvalidDescription       = errors.New("ErrInvalidDescription")
+	ErrInvalidData                  = errors.New("ErrInvalidData")
+	ErrValidationFailed             = errors.New("ErrValidationFailed")
+	ErrPathParametrized             = errors.New("ErrPathParametrized")
+	ErrInvalidMinPrice              = errors.New("ErrInvalidMinPrice")
+	ErrInvalidMaxPrice              = errors.New("ErrInvalidMaxPrice")
+	ErrInvalidAmount                = errors.New("ErrInvalidAmount")
+	ErrInvalidPrice                 = errors.New("ErrInvalidPrice")
+	ErrInsufficientFunds            = errors.New("ErrInsufficientFunds")
+	ErrOrderAlreadyPayed            = errors.New("ErrOrderAlreadyPayed")
+	ErrInvalidCategory              = errors.New("ErrInvalidCategory")
+	ErrInvalidStore                 = errors.New("ErrInvalidStore")
+	ErrOrderHasBeenPaidFor          = errors.New("ErrOrderHasBeenPaidFor")
+	ErrInvalidID                    = errors.New("ErrInvalidID")
+	ErrInvalidPaymentID             = errors.New("ErrInvalidPaymentID")
+	ErrInvalidOrderID               = errors.New("ErrInvalidOrderID")
+	ErrInvalidQuantity              = errors.New("ErrInvalidQuantity")
+	ErrInvalidFeaturedProductID     = errors.New("ErrInvalidFeaturedProductID")
+	ErrInvalidAccountID             = errors.New("ErrInvalidAccountID")
+	ErrInvalidAddressID             = errors.New("ErrInvalidAddressID")
+	ErrInvalidProductID             = errors.New("ErrInvalidProductID")
+	ErrInvalidProductReviewID       = errors.New("ErrInvalidProductReviewID")
+	ErrInvalidStoreID               = errors.New("ErrInvalidStoreID")
+	ErrInvalidStoreReviewID         = errors.New("ErrInvalidStoreReviewID")
+	ErrInvalidComment               = errors.New("ErrInvalidComment")
+	ErrInvalidContent               = errors.New("ErrInvalidContent")
+	ErrInvalidBalance               = errors.New("ErrInvalidBalance")
+	ErrInvalidRating                = errors.New("ErrInvalidRating")
+	ErrInvalidTitle                 = errors.New("ErrInvalidTitle")
+	ErrInvalidToken                 = errors.New("ErrInvalidToken")
+	ErrNotEnoughProductInStock      = errors.New("ErrNotEnoughProductInStock")
+	ErrRefreshTokenExpired          = errors.New("ErrRefreshTokenExpired")
+	ErrInvalidAddressName           = errors.New("ErrInvalidAddressName")
+	ErrInvalidAccountNumber         = errors.New("ErrInvalidAccountNumber")
+	ErrInvalidDescription           = errors.New("ErrInvalidDescription")
+	ErrCartIsEmpty                  = errors.New("ErrCartIsEmpty")
+	ErrOrderNotEditable             = errors.New("ErrOrderNotEditable")
+	ErrOrderCannotBeCancelled       = errors.New("ErrOrderCannotBeCancelled")
+	ErrOrderCannotBeRefunded        = errors.New("ErrOrderCannotBeRefunded")
+	ErrNothingToRefund              = errors.New("ErrNothingToRefund")
+	ErrPaymentImmutable             = errors.New("ErrPaymentImmutable")
+	ErrInvalidOrderStatusTransition = errors.New("ErrInvalidOrderStatusTransition")
+	ErrInvalidIdempotencyKey        = errors.New("ErrInvalidIdempotencyKey")
+	ErrIdempotencyKeyReused         = errors.New("ErrIdempotencyKeyReused")
+	ErrIdempotentRequestInProgress  = errors.New("ErrIdempotentRequestInProgress")
+	ErrOrderReservationExpired      = errors.New("ErrOrderReservationExpired")
+	ErrInvalidMovementKind          = errors.New("ErrInvalidMovementKind")
+	ErrVariantRequired              = errors.New("ErrVariantRequired")
+	ErrInvalidSKU                   = errors.New("ErrInvalidSKU")
+	ErrCannotDeleteLastVariant      = errors.New("ErrCannotDeleteLastVariant")
+	ErrInvalidModifier              = errors.New("ErrInvalidModifier")
+	ErrInvalidModifierSelection     = errors.New("ErrInvalidModifierSelection")
+	ErrInvalidModifierGroup         = errors.New("ErrInvalidModifierGroup")
+	ErrInvalidSearchQuery           = errors.New("ErrInvalidSearchQuery")
+	ErrInvalidGTIN                  = errors.New("ErrInvalidGTIN")
+	ErrInvalidSort                  = errors.New("ErrInvalidSort")
+	ErrInvalidCursor                = errors.New("ErrInvalidCursor")
+	ErrInvalidLimit                 = errors.New("ErrInvalidLimit")
+	ErrInvalidFields                = errors.New("ErrInvalidFields")
+	ErrNoImagesUploaded             = errors.New("ErrNoImagesUploaded")
+	ErrTooManyImages                = errors.New("ErrTooManyImages")
+	ErrImageTooLarge                = errors.New("ErrImageTooLarge")
+	ErrUnsupportedImageType         = errors.New("ErrUnsupportedImageType")
+	ErrInvalidImportFile            = errors.New("ErrInvalidImportFile")
+	ErrTooManyImportRows            = errors.New("ErrTooManyImportRows")
+	ErrImportFileTooLarge           = errors.New("ErrImportFileTooLarge")
+	ErrInvalidExportFormat          = errors.New("ErrInvalidExportFormat")
+	ErrDuplicateSKU                 = errors.New("ErrDuplicateSKU")
+	ErrInvalidTargetCategory        = errors.New("ErrInvalidTargetCategory")
+	ErrCategoryCycle                = errors.New("ErrCategoryCycle")
+	ErrCategoryNotEmpty             = errors.New("ErrCategoryNotEmpty")
+	ErrInvalidStoreRole             = errors.New("ErrInvalidStoreRole")
+	ErrInvalidEmail                 = errors.New("ErrInvalidEmail")
+	ErrAlreadyStoreMember           = errors.New("ErrAlreadyStoreMember")
+	ErrStoreInvitationExists        = errors.New("ErrStoreInvitationExists")
+	ErrStoreInvitationExpired       = errors.New("ErrStoreInvitationExpired")
+	ErrCannotChangeStoreOwner       = errors.New("ErrCannotChangeStoreOwner")
+	ErrAccountHasBalance            = errors.New("ErrAccountHasBalance")
 )
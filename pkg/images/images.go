@@ -0,0 +1,191 @@
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxPixels ограничивает размер изображения после распаковки, чтобы маленький файл не занял гигабайты памяти
+	MaxPixels = 40_000_000
+
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+// formats поддерживаемые типы изображений и расширения их файлов
+var formats = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ThumbnailSize размер миниатюры по большей стороне
+type ThumbnailSize struct {
+	Name    string
+	MaxSide int
+}
+
+// ThumbnailSizes миниатюры, создаваемые для каждого загруженного изображения
+var ThumbnailSizes = []ThumbnailSize{
+	{Name: "small", MaxSide: 160},
+	{Name: "medium", MaxSide: 480},
+	{Name: "large", MaxSide: 1200},
+}
+
+// Image проверенное загруженное изображение
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	decoded     image.Image
+}
+
+// Thumbnail закодированная миниатюра
+type Thumbnail struct {
+	Size        ThumbnailSize
+	ContentType string
+	Extension   string
+	Data        []byte
+}
+
+// Decode определяет тип файла по содержимому, а не по имени или заголовкам клиента, и декодирует изображение
+func Decode(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := formats[contentType]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	// Размеры проверяются до декодирования, которое выделяет память под все пиксели
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedType
+	}
+
+	if config.Width <= 0 || config.Height <= 0 {
+		return Image{}, ErrUnsupportedType
+	}
+	if config.Width*config.Height > MaxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedType
+	}
+
+	return Image{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       config.Width,
+		Height:      config.Height,
+		decoded:     decoded,
+	}, nil
+}
+
+// Thumbnails создаёт миниатюры всех размеров. Изображение не увеличивается, поэтому маленькие миниатюры могут совпадать по размеру.
+// JPEG остаётся JPEG, а PNG и GIF кодируются в PNG, чтобы сохранить прозрачность (анимация GIF не сохраняется)
+func (img Image) Thumbnails() ([]Thumbnail, error) {
+	contentType, extension := "image/png", ".png"
+	if img.ContentType == "image/jpeg" {
+		contentType, extension = "image/jpeg", ".jpg"
+	}
+
+	thumbnails := make([]Thumbnail, 0, len(ThumbnailSizes))
+	for _, size := range ThumbnailSizes {
+		resized := Resize(img.decoded, size.MaxSide)
+
+		var buffer bytes.Buffer
+		var err error
+		if contentType == "image/jpeg" {
+			err = jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			err = png.Encode(&buffer, resized)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		thumbnails = append(thumbnails, Thumbnail{
+			Size:        size,
+			ContentType: contentType,
+			Extension:   extension,
+			Data:        buffer.Bytes(),
+		})
+	}
+
+	return thumbnails, nil
+}
+
+// Resize уменьшает изображение так, чтобы большая сторона не превышала maxSide, сохраняя пропорции.
+// Каждый пиксель результата — среднее покрываемых им пикселей исходника, что даёт чистое уменьшение без муара
+func Resize(src image.Image, maxSide int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return src
+	}
+
+	dstWidth, dstHeight := maxSide, height*maxSide/width
+	if height > width {
+		dstWidth, dstHeight = width*maxSide/height, maxSide
+	}
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := bounds.Min.Y + (y+1)*height/dstHeight
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := bounds.Min.X + (x+1)*width/dstWidth
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+
+			// Цвета усредняются с учётом прозрачности, затем переводятся в непредумноженные
+			pixel := color.NRGBA{}
+			if a > 0 {
+				pixel = color.NRGBA{
+					R: uint8(r * 0xff / a),
+					G: uint8(g * 0xff / a),
+					B: uint8(b * 0xff / a),
+					A: uint8((a / count) >> 8),
+				}
+			}
+			dst.SetNRGBA(x, y, pixel)
+		}
+	}
+
+	return dst
+}
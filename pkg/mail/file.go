@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// FileSender записывает письма в файлы .eml вместо отправки, для локального запуска и тестов
+type FileSender struct {
+	directory string
+	counter   atomic.Uint64
+}
+
+// NewFileSender создаёт отправителя, который пишет письма в directory
+func NewFileSender(directory string) (*FileSender, error) {
+	if err := os.MkdirAll(directory, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &FileSender{directory: directory}, nil
+}
+
+func (s *FileSender) Send(message Message) error {
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102T150405.000000000"), s.counter.Add(1))
+	path := filepath.Join(s.directory, name)
+
+	if err := os.WriteFile(path, buildMessage("", message), 0o600); err != nil {
+		return err
+	}
+
+	log.Printf("[mail.FileSender] mail %q to %s written to %s", message.Subject, message.To, path)
+	return nil
+}
@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"BizMart/internal/security"
+	"fmt"
+	"log"
+)
+
+const (
+	BackendFile = "file"
+	BackendSMTP = "smtp"
+)
+
+// Message письмо в виде простого текста
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письма пользователям
+type Sender interface {
+	Send(message Message) error
+}
+
+var sender Sender
+
+// Init создаёт отправителя писем, выбранного в mail_params
+func Init() error {
+	params := security.AppSettings.MailParams
+
+	switch params.Backend {
+	case BackendFile, "":
+		directory := params.Directory
+		if directory == "" {
+			directory = "mail"
+		}
+
+		fileSender, err := NewFileSender(directory)
+		if err != nil {
+			return err
+		}
+
+		sender = fileSender
+		log.Printf("Writing outgoing mail to %s", directory)
+	case BackendSMTP:
+		sender = NewSMTPSender(SMTPConfig{
+			Host:     params.Host,
+			Port:     params.Port,
+			Username: params.Username,
+			Password: security.SMTPPassword,
+			From:     params.From,
+		})
+		log.Printf("Sending mail through %s:%s", params.Host, params.Port)
+	default:
+		return fmt.Errorf("unknown mail backend %q", params.Backend)
+	}
+
+	return nil
+}
+
+// Get возвращает отправителя писем приложения
+func Get() Sender {
+	return sender
+}
@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPConfig параметры подключения к SMTP-серверу
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPSender отправляет письма через SMTP-сервер. STARTTLS используется, если сервер его поддерживает
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender создаёт отправителя писем через SMTP
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+func (s *SMTPSender) Send(message Message) error {
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	address := net.JoinHostPort(s.config.Host, s.config.Port)
+	return smtp.SendMail(address, auth, s.config.From, []string{message.To}, buildMessage(s.config.From, message))
+}
+
+// buildMessage собирает письмо в формате RFC 5322, тема кодируется для кириллицы
+func buildMessage(from string, message Message) []byte {
+	var buffer bytes.Buffer
+
+	if from != "" {
+		fmt.Fprintf(&buffer, "From: %s\r\n", from)
+	}
+	fmt.Fprintf(&buffer, "To: %s\r\n", message.To)
+	fmt.Fprintf(&buffer, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buffer, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buffer.WriteString("MIME-Version: 1.0\r\n")
+	buffer.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buffer.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	buffer.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+
+	return buffer.Bytes()
+}
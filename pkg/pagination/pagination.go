@@ -0,0 +1,324 @@
+package pagination
+
+import (
+	"BizMart/pkg/errs"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// Params параметры страницы из запроса: размер, курсор, сортировка и выбранные поля
+type Params struct {
+	Limit  int
+	Cursor string
+	Sort   string
+	Order  string
+	Fields []string
+}
+
+// IsDefault сообщает, что клиент запросил первую страницу без дополнительных параметров
+func (p Params) IsDefault() bool {
+	return p.Limit == 0 && p.Cursor == "" && p.Sort == "" && p.Order == "" && len(p.Fields) == 0
+}
+
+// ParseQuery читает параметры limit, cursor, sort, order и fields из строки запроса
+func ParseQuery(query url.Values) (Params, error) {
+	params := Params{
+		Cursor: query.Get("cursor"),
+		Sort:   query.Get("sort"),
+		Order:  strings.ToLower(query.Get("order")),
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return Params{}, errs.ErrInvalidLimit
+		}
+		params.Limit = limit
+	}
+
+	if value := query.Get("fields"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				params.Fields = append(params.Fields, field)
+			}
+		}
+	}
+
+	return params, nil
+}
+
+// Sort допустимая сортировка ресурса.
+// Columns — выражения SQL, последнее из которых уникально (обычно id), чтобы порядок был однозначным.
+// Vars — аргументы плейсхолдеров в Columns, Key — значения этих выражений для строки.
+type Sort[T any] struct {
+	Columns []string
+	Vars    []interface{}
+	Key     func(item T) []interface{}
+}
+
+// Spec описывает, как листать ресурс: доступные сортировки, поля и нужно ли считать общее количество
+type Spec[T any] struct {
+	Sorts        map[string]Sort[T]
+	DefaultSort  string
+	DefaultOrder string
+	// Fields поля ответа, которые можно запросить через fields, пустой список запрещает выбор полей
+	Fields []string
+	// CountTotal включает подсчёт total на первой странице, только для дешёвых запросов
+	CountTotal bool
+}
+
+// Page конверт ответа со страницей элементов
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+
+	fields []string
+}
+
+// MarshalJSON оставляет в элементах только выбранные поля, если они были запрошены
+func (p Page[T]) MarshalJSON() ([]byte, error) {
+	var items interface{} = p.Items
+	if p.Items == nil {
+		items = []T{}
+	}
+
+	if len(p.fields) > 0 {
+		selected, err := selectFields(p.Items, p.fields)
+		if err != nil {
+			return nil, err
+		}
+		items = selected
+	}
+
+	return json.Marshal(struct {
+		Items      interface{} `json:"items"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+		Total      *int64      `json:"total,omitempty"`
+	}{items, p.NextCursor, p.Total})
+}
+
+// WithFields возвращает копию страницы, в которой при сериализации останутся только fields, nil оставляет все поля
+func (p Page[T]) WithFields(fields []string) Page[T] {
+	p.fields = fields
+	return p
+}
+
+type cursor struct {
+	Sort   string            `json:"s"`
+	Order  string            `json:"o"`
+	Values []json.RawMessage `json:"v"`
+}
+
+func encodeCursor(sort, order string, values []interface{}) (string, error) {
+	c := cursor{Sort: sort, Order: order}
+	for _, value := range values {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		c.Values = append(c.Values, raw)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor проверяет, что курсор выдан для той же сортировки, и возвращает значения ключа
+func decodeCursor(token, sort, order string, size int) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	if c.Sort != sort || c.Order != order || len(c.Values) != size {
+		return nil, errs.ErrInvalidCursor
+	}
+
+	values := make([]interface{}, 0, size)
+	for _, raw := range c.Values {
+		if len(raw) > 0 && raw[0] == '"' {
+			var text string
+			if err = json.Unmarshal(raw, &text); err != nil {
+				return nil, errs.ErrInvalidCursor
+			}
+			values = append(values, text)
+			continue
+		}
+
+		var number json.Number
+		if err = json.Unmarshal(raw, &number); err != nil {
+			return nil, errs.ErrInvalidCursor
+		}
+
+		if integer, err := number.Int64(); err == nil {
+			values = append(values, integer)
+		} else if float, err := number.Float64(); err == nil {
+			values = append(values, float)
+		} else {
+			return nil, errs.ErrInvalidCursor
+		}
+	}
+
+	return values, nil
+}
+
+// Paginate возвращает страницу запроса: фильтр по курсору, сортировка и LIMIT выполняются в SQL.
+// Запрос должен содержать только фильтры, сортировку задаёт spec.
+func Paginate[T any](query *gorm.DB, spec Spec[T], params Params) (Page[T], error) {
+	sortName := params.Sort
+	if sortName == "" {
+		sortName = spec.DefaultSort
+	}
+
+	sort, ok := spec.Sorts[sortName]
+	if !ok {
+		return Page[T]{}, errs.ErrInvalidSort
+	}
+
+	order := params.Order
+	if order == "" {
+		order = spec.DefaultOrder
+	}
+	if order != OrderAsc && order != OrderDesc {
+		return Page[T]{}, errs.ErrInvalidSort
+	}
+
+	if err := validateFields(spec.Fields, params.Fields); err != nil {
+		return Page[T]{}, err
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	query = query.Session(&gorm.Session{})
+	page := Page[T]{fields: params.Fields}
+
+	if spec.CountTotal && params.Cursor == "" {
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return Page[T]{}, err
+		}
+		page.Total = &total
+	}
+
+	direction, comparison := "ASC", ">"
+	if order == OrderDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	columns := strings.Join(sort.Columns, ", ")
+	orderBy := strings.Join(sort.Columns, " "+direction+", ") + " " + direction
+	paged := query.Order(clause.OrderBy{Expression: clause.Expr{SQL: orderBy, Vars: sort.Vars}})
+
+	if params.Cursor != "" {
+		values, err := decodeCursor(params.Cursor, sortName, order, len(sort.Columns))
+		if err != nil {
+			return Page[T]{}, err
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		vars := append(append([]interface{}{}, sort.Vars...), values...)
+		paged = paged.Where("("+columns+") "+comparison+" ("+placeholders+")", vars...)
+	}
+
+	// Берём на одну строку больше, чтобы узнать, есть ли следующая страница
+	var items []T
+	if err := paged.Limit(limit + 1).Find(&items).Error; err != nil {
+		return Page[T]{}, err
+	}
+
+	if len(items) > limit {
+		items = items[:limit]
+
+		next, err := encodeCursor(sortName, order, sort.Key(items[limit-1]))
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.NextCursor = next
+	}
+
+	page.Items = items
+	return page, nil
+}
+
+// Map переносит курсор и total страницы на страницу с преобразованными элементами
+func Map[T, R any](page Page[T], convert func(items []T) []R) Page[R] {
+	return Page[R]{
+		Items:      convert(page.Items),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+		fields:     page.fields,
+	}
+}
+
+func validateFields(allowed, requested []string) error {
+	for _, field := range requested {
+		found := false
+		for _, name := range allowed {
+			if name == field {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return errs.ErrInvalidFields
+		}
+	}
+
+	return nil
+}
+
+func selectFields[T any](items []T, fields []string) ([]map[string]json.RawMessage, error) {
+	selected := make([]map[string]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		data, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]json.RawMessage
+		if err = json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			if value, ok := all[field]; ok {
+				row[field] = value
+			}
+		}
+		selected = append(selected, row)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,325 @@
+package pagination
+
+import (
+	"BizMart/pkg/errs"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type testItem struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+var testSpec = Spec[testItem]{
+	Sorts: map[string]Sort[testItem]{
+		"id": {Columns: []string{"id"}, Key: func(item testItem) []interface{} { return []interface{}{item.ID} }},
+		"name": {
+			Columns: []string{"name", "id"},
+			Key:     func(item testItem) []interface{} { return []interface{}{item.Name, item.ID} },
+		},
+	},
+	DefaultSort:  "id",
+	DefaultOrder: OrderAsc,
+	Fields:       []string{"id", "name"},
+	CountTotal:   true,
+}
+
+// fakeQuery запрос, который gorm отправил в базу, вместе с аргументами
+type fakeQuery struct {
+	sql  string
+	args []driver.Value
+}
+
+// fakeDB отвечает на SELECT заранее заданными строками, а на count(*) — заданным числом, и запоминает запросы.
+// Фильтрацию по курсору выполняет PostgreSQL, здесь проверяется только сгенерированный SQL
+type fakeDB struct {
+	mu      sync.Mutex
+	rows    []testItem
+	total   int64
+	queries []fakeQuery
+}
+
+func newFakeDB(t *testing.T, rows []testItem, total int64) (*gorm.DB, *fakeDB) {
+	t.Helper()
+
+	fake := &fakeDB{rows: rows, total: total}
+	conn, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(fakeConnector{fake})}), &gorm.Config{
+		Logger: gormlogger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return conn.Table("items"), fake
+}
+
+func (f *fakeDB) recorded() []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]fakeQuery(nil), f.queries...)
+}
+
+type fakeConnector struct{ db *fakeDB }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver(c) }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn(d), nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{db: c.db, query: query}, nil
+}
+func (c fakeConn) Close() error { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.queries = append(s.db.queries, fakeQuery{sql: s.query, args: args})
+	if strings.Contains(s.query, "count(*)") {
+		return &fakeRows{columns: []string{"count"}, values: [][]driver.Value{{s.db.total}}}, nil
+	}
+
+	rows := &fakeRows{columns: []string{"id", "name"}}
+	for _, item := range s.db.rows {
+		rows.values = append(rows.values, []driver.Value{item.ID, item.Name})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestPaginateFirstAndNextPage(t *testing.T) {
+	query, fake := newFakeDB(t, []testItem{{1, "a"}, {2, "b"}, {3, "c"}}, 5)
+
+	page, err := Paginate(query, testSpec, Params{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(page.Items, []testItem{{1, "a"}, {2, "b"}}) {
+		t.Fatalf("expected the first two rows, got %+v", page.Items)
+	}
+	if page.Total == nil || *page.Total != 5 {
+		t.Fatalf("expected total 5, got %v", page.Total)
+	}
+	if page.NextCursor == "" {
+		t.Fatal("expected a next cursor when there are more rows")
+	}
+
+	queries := fake.recorded()
+	if len(queries) != 2 || !strings.Contains(queries[0].sql, "count(*)") {
+		t.Fatalf("expected a count and a select, got %+v", queries)
+	}
+	if !strings.Contains(queries[1].sql, "ORDER BY id ASC") || !reflect.DeepEqual(queries[1].args, []driver.Value{int64(3)}) {
+		t.Fatalf("expected ordering by id and one extra row, got %s with %v", queries[1].sql, queries[1].args)
+	}
+
+	next, fake := newFakeDB(t, []testItem{{3, "c"}}, 5)
+	page, err = Paginate(next, testSpec, Params{Limit: 2, Cursor: page.NextCursor})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if page.NextCursor != "" || page.Total != nil {
+		t.Fatalf("expected the last page without a cursor and total, got %+v", page)
+	}
+
+	queries = fake.recorded()
+	if len(queries) != 1 {
+		t.Fatalf("expected only a select on later pages, got %+v", queries)
+	}
+	if !strings.Contains(queries[0].sql, "(id) > ($1)") || !reflect.DeepEqual(queries[0].args, []driver.Value{int64(2), int64(3)}) {
+		t.Fatalf("expected rows after id 2, got %s with %v", queries[0].sql, queries[0].args)
+	}
+}
+
+func TestPaginateDescendingRowComparison(t *testing.T) {
+	cursor, err := encodeCursor("name", OrderDesc, []interface{}{"b", int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query, fake := newFakeDB(t, nil, 0)
+	if _, err = Paginate(query, testSpec, Params{Sort: "name", Order: OrderDesc, Cursor: cursor}); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := fake.recorded()
+	if len(queries) != 1 {
+		t.Fatalf("expected one select, got %+v", queries)
+	}
+	if !strings.Contains(queries[0].sql, "(name, id) < ($1, $2)") || !strings.Contains(queries[0].sql, "ORDER BY name DESC, id DESC") {
+		t.Fatalf("expected a descending row comparison, got %s", queries[0].sql)
+	}
+	if !reflect.DeepEqual(queries[0].args, []driver.Value{"b", int64(2), int64(21)}) {
+		t.Fatalf("expected the cursor values and the default limit as arguments, got %v", queries[0].args)
+	}
+}
+
+func TestPaginateRejectsBadParams(t *testing.T) {
+	valid, err := encodeCursor("id", OrderAsc, []interface{}{int64(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encode := func(value interface{}) string {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	tests := []struct {
+		name    string
+		params  Params
+		wantErr error
+	}{
+		{name: "unknown sort", params: Params{Sort: "price"}, wantErr: errs.ErrInvalidSort},
+		{name: "unknown order", params: Params{Order: "up"}, wantErr: errs.ErrInvalidSort},
+		{name: "unknown field", params: Params{Fields: []string{"id", "password"}}, wantErr: errs.ErrInvalidFields},
+		{name: "cursor is not base64", params: Params{Cursor: "%%%"}, wantErr: errs.ErrInvalidCursor},
+		{name: "cursor is not json", params: Params{Cursor: base64.RawURLEncoding.EncodeToString([]byte("{"))}, wantErr: errs.ErrInvalidCursor},
+		{name: "cursor of another sort", params: Params{Sort: "name", Cursor: valid}, wantErr: errs.ErrInvalidCursor},
+		{name: "cursor of another order", params: Params{Order: OrderDesc, Cursor: valid}, wantErr: errs.ErrInvalidCursor},
+		{
+			name:    "cursor with too many values",
+			params:  Params{Cursor: encode(map[string]interface{}{"s": "id", "o": OrderAsc, "v": []interface{}{1, 2}})},
+			wantErr: errs.ErrInvalidCursor,
+		},
+		{
+			name:    "cursor with an object value",
+			params:  Params{Cursor: encode(map[string]interface{}{"s": "id", "o": OrderAsc, "v": []interface{}{map[string]int{"id": 1}}})},
+			wantErr: errs.ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, fake := newFakeDB(t, nil, 0)
+			if _, err := Paginate(query, testSpec, tt.params); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+
+			for _, q := range fake.recorded() {
+				if !strings.Contains(q.sql, "count(*)") {
+					t.Fatalf("expected no select for invalid params, got %s", q.sql)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeCursorKeepsValueTypes(t *testing.T) {
+	token, err := encodeCursor("name", OrderAsc, []interface{}{"b", int64(2), 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := decodeCursor(token, "name", OrderAsc, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(values, []interface{}{"b", int64(2), 1.5}) {
+		t.Fatalf("expected string, integer and float values, got %#v", values)
+	}
+}
+
+func TestPageMarshalSelectedFields(t *testing.T) {
+	page := Page[testItem]{Items: []testItem{{1, "a"}}}.WithFields([]string{"name"})
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"items":[{"name":"a"}]}` {
+		t.Fatalf("expected only the name field, got %s", data)
+	}
+
+	data, err = json.Marshal(Page[testItem]{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != `{"items":[]}` {
+		t.Fatalf("expected an empty item list, got %s", data)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	params, err := ParseQuery(url.Values{
+		"limit":  {"10"},
+		"cursor": {"abc"},
+		"sort":   {"name"},
+		"order":  {"DESC"},
+		"fields": {"id, name,,"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Params{Limit: 10, Cursor: "abc", Sort: "name", Order: OrderDesc, Fields: []string{"id", "name"}}
+	if !reflect.DeepEqual(params, want) {
+		t.Fatalf("expected %+v, got %+v", want, params)
+	}
+
+	for _, limit := range []string{"0", "-1", "ten"} {
+		if _, err = ParseQuery(url.Values{"limit": {limit}}); !errors.Is(err, errs.ErrInvalidLimit) {
+			t.Fatalf("expected %v for limit %q, got %v", errs.ErrInvalidLimit, limit, err)
+		}
+	}
+}
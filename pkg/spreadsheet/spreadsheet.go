@@ -0,0 +1,98 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported spreadsheet format")
+
+// DetectFormat определяет формат по содержимому: XLSX — это ZIP-архив, остальное читается как CSV
+func DetectFormat(data []byte) string {
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return FormatXLSX
+	}
+	return FormatCSV
+}
+
+// ContentType возвращает MIME-тип формата
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return ContentTypeXLSX
+	}
+	return ContentTypeCSV
+}
+
+// Read читает строки первого листа XLSX или CSV-файла. Пустые строки пропускаются, пробелы по краям ячеек обрезаются
+func Read(data []byte) ([][]string, error) {
+	var rows [][]string
+	var err error
+
+	if DetectFormat(data) == FormatXLSX {
+		rows, err = readXLSX(data)
+	} else {
+		rows, err = readCSV(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		empty := true
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+			if row[i] != "" {
+				empty = false
+			}
+		}
+
+		if !empty {
+			result = append(result, row)
+		}
+	}
+
+	return result, nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// Excel сохраняет CSV в UTF-8 с BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	// Разделитель «;» используется Excel в локалях с десятичной запятой
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	return reader.ReadAll()
+}
+
+// Write записывает строки в выбранном формате
+func Write(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	case FormatXLSX:
+		return writeXLSX(w, rows)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
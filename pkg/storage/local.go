@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalMediaPath маршрут, по которому раздаются файлы локального хранилища
+const LocalMediaPath = "/media"
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// LocalStorage хранит файлы в каталоге на диске сервера
+type LocalStorage struct {
+	directory string
+	baseURL   string
+}
+
+// NewLocalStorage создаёт хранилище в directory, файлы доступны по адресу publicURL/media/<ключ>
+func NewLocalStorage(directory, publicURL string) *LocalStorage {
+	return &LocalStorage{
+		directory: directory,
+		baseURL:   strings.TrimSuffix(publicURL, "/") + LocalMediaPath,
+	}
+}
+
+// path возвращает путь файла на диске, не позволяя ключу выйти за пределы каталога
+func (s *LocalStorage) path(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.directory, filepath.FromSlash(cleaned)), nil
+}
+
+func (s *LocalStorage) Put(key string, body io.Reader, _ int64, _ string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return err
+	}
+
+	// Файл пишется во временный и переименовывается, чтобы не отдавать клиентам недописанный файл
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".upload-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filePath)
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	filePath, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *LocalStorage) Key(url string) (string, bool) {
+	return keyFromURL(s.baseURL, url)
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const s3Timeout = 30 * time.Second
+
+// S3Config параметры S3-совместимого хранилища (AWS S3, MinIO и т.п.)
+type S3Config struct {
+	// Endpoint адрес API, например https://s3.eu-central-1.amazonaws.com или http://localhost:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PublicURL адрес, по которому файлы доступны клиентам, по умолчанию <Endpoint>/<Bucket>
+	PublicURL string
+}
+
+// S3Storage хранит файлы в бакете S3-совместимого хранилища.
+// Запросы подписываются AWS Signature V4, бакет адресуется в пути, как того ожидают MinIO и другие совместимые хранилища
+type S3Storage struct {
+	config  S3Config
+	baseURL string
+	client  *http.Client
+}
+
+func NewS3Storage(config S3Config) *S3Storage {
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	baseURL := strings.TrimSuffix(config.PublicURL, "/")
+	if baseURL == "" {
+		baseURL = config.Endpoint + "/" + config.Bucket
+	}
+
+	return &S3Storage{
+		config:  config,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: s3Timeout},
+	}
+}
+
+func (s *S3Storage) Put(key string, body io.Reader, size int64, contentType string) error {
+	request, err := http.NewRequest(http.MethodPut, s.objectURL(key), body)
+	if err != nil {
+		return err
+	}
+
+	request.ContentLength = size
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	return s.do(request, http.StatusOK)
+}
+
+func (s *S3Storage) Delete(key string) error {
+	request, err := http.NewRequest(http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+
+	// Удаление отсутствующего объекта S3 тоже считает успешным
+	return s.do(request, http.StatusNoContent)
+}
+
+func (s *S3Storage) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+func (s *S3Storage) Key(url string) (string, bool) {
+	return keyFromURL(s.baseURL, url)
+}
+
+func (s *S3Storage) objectURL(key string) string {
+	return s.config.Endpoint + "/" + s.config.Bucket + "/" + encodeS3Path(key)
+}
+
+func (s *S3Storage) do(request *http.Request, expectedStatus int) error {
+	s.sign(request, time.Now().UTC())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != expectedStatus && response.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", request.Method, request.URL.Path, response.Status, message)
+	}
+
+	return nil
+}
+
+// sign добавляет к запросу подпись AWS Signature V4. Тело не хэшируется, чтобы не читать файл дважды
+func (s *S3Storage) sign(request *http.Request, now time.Time) {
+	const payloadHash = "UNSIGNED-PAYLOAD"
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + request.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, s.config.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// encodeS3Path кодирует сегменты ключа, сохраняя разделители каталогов
+func encodeS3Path(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(url.PathEscape(segment), "+", "%2B")
+	}
+	return strings.Join(segments, "/")
+}
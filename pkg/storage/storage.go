@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"BizMart/internal/security"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Storage хранилище загруженных файлов. Ключ — относительный путь файла, например products/12/ab34.jpg
+type Storage interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Delete(key string) error
+	// URL возвращает публичный адрес файла
+	URL(key string) string
+	// Key возвращает ключ файла по его публичному адресу, false — если файл лежит не в этом хранилище
+	Key(url string) (string, bool)
+}
+
+var fileStorage Storage
+
+// Init создаёт хранилище, выбранное в storage_params
+func Init() error {
+	params := security.AppSettings.StorageParams
+
+	switch params.Backend {
+	case BackendLocal, "":
+		directory := params.LocalDirectory
+		if directory == "" {
+			directory = "uploads"
+		}
+
+		if err := os.MkdirAll(directory, 0o755); err != nil {
+			return err
+		}
+
+		fileStorage = NewLocalStorage(directory, params.PublicURL)
+		log.Printf("Storing uploaded files in %s", directory)
+	case BackendS3:
+		fileStorage = NewS3Storage(S3Config{
+			Endpoint:  params.S3Endpoint,
+			Region:    params.S3Region,
+			Bucket:    params.S3Bucket,
+			AccessKey: params.S3AccessKey,
+			SecretKey: security.S3SecretKey,
+			PublicURL: params.PublicURL,
+		})
+		log.Printf("Storing uploaded files in bucket %s", params.S3Bucket)
+	default:
+		return fmt.Errorf("unknown storage backend %q", params.Backend)
+	}
+
+	return nil
+}
+
+// Get возвращает хранилище файлов приложения
+func Get() Storage {
+	return fileStorage
+}
+
+// LocalDirectory возвращает каталог локального хранилища, который нужно раздавать как статику, и false для других хранилищ
+func LocalDirectory() (string, bool) {
+	local, ok := fileStorage.(*LocalStorage)
+	if !ok {
+		return "", false
+	}
+	return local.directory, true
+}
+
+// keyFromURL отрезает базовый адрес хранилища от адреса файла
+func keyFromURL(baseURL, url string) (string, bool) {
+	prefix := strings.TrimSuffix(baseURL, "/") + "/"
+	if !strings.HasPrefix(url, prefix) {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(url, prefix)
+	return key, key != ""
+}
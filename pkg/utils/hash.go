@@ -1,13 +1,147 @@
 package utils
 
 import (
+	"BizMart/internal/security"
+	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Параметры argon2id по умолчанию, их можно изменить в password_hash_params
+const (
+	defaultArgonMemoryKiB   = 64 * 1024
+	defaultArgonIterations  = 3
+	defaultArgonParallelism = 2
+
+	argonSaltLength = 16
+	argonKeyLength  = 32
+)
+
+type argonParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// hashSlots ограничивает количество одновременных вычислений хеша: каждое занимает memory KiB памяти
+var hashSlots = make(chan struct{}, runtime.NumCPU())
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
 )
 
-func GenerateHash(input string) string {
-	hash := sha256.New()                   // Создаем новый SHA-256 хеш
-	hash.Write([]byte(input))              // Добавляем строку в хеш
-	hashedBytes := hash.Sum(nil)           // Получаем итоговый хеш в виде байтового среза
-	return hex.EncodeToString(hashedBytes) // Конвертируем байты в строку в формате hex
+func currentArgonParams() argonParams {
+	settings := security.AppSettings.PasswordHashParams
+	params := argonParams{
+		memory:      defaultArgonMemoryKiB,
+		iterations:  defaultArgonIterations,
+		parallelism: defaultArgonParallelism,
+	}
+
+	if settings.MemoryKiB > 0 {
+		params.memory = settings.MemoryKiB
+	}
+	if settings.Iterations > 0 {
+		params.iterations = settings.Iterations
+	}
+	if settings.Parallelism > 0 {
+		params.parallelism = settings.Parallelism
+	}
+
+	return params
+}
+
+func argonKey(password string, salt []byte, params argonParams, keyLength uint32) []byte {
+	hashSlots <- struct{}{}
+	defer func() { <-hashSlots }()
+
+	return argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, keyLength)
+}
+
+// HashPassword хеширует пароль argon2id со случайной солью.
+// Результат хранится в формате $argon2id$v=19$m=65536,t=3,p=2$<соль>$<хеш>, поэтому параметры можно менять без миграции
+func HashPassword(password string) (string, error) {
+	params := currentArgonParams()
+
+	salt := make([]byte, argonSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argonKey(password, salt, params, argonKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, params.memory, params.iterations, params.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// VerifyPassword сравнивает пароль с сохранённым хешем argon2id или старым несолёным SHA-256.
+// needsRehash сообщает, что хеш устарел (SHA-256 или другие параметры) и его нужно пересчитать, пока пароль известен.
+// Пустой хеш сравнивается с фиктивным, чтобы время ответа не выдавало, существует ли пользователь
+func VerifyPassword(password, encoded string) (match bool, needsRehash bool) {
+	if encoded == "" {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = HashPassword("dummy password")
+		})
+		verifyArgonPassword(password, dummyHash)
+		return false, false
+	}
+
+	if isLegacyPasswordHash(encoded) {
+		legacy := sha256.Sum256([]byte(password))
+		match = subtle.ConstantTimeCompare([]byte(hex.EncodeToString(legacy[:])), []byte(strings.ToLower(encoded))) == 1
+		return match, match
+	}
+
+	params, match := verifyArgonPassword(password, encoded)
+	return match, match && params != currentArgonParams()
+}
+
+func verifyArgonPassword(password, encoded string) (argonParams, bool) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return argonParams{}, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argonParams{}, false
+	}
+
+	var params argonParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil ||
+		params.memory == 0 || params.iterations == 0 || params.parallelism == 0 {
+		return argonParams{}, false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argonParams{}, false
+	}
+
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return argonParams{}, false
+	}
+
+	key := argonKey(password, salt, params, uint32(len(expected)))
+	return params, subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+// isLegacyPasswordHash определяет хеш SHA-256 в hex, которым пароли хранились раньше
+func isLegacyPasswordHash(encoded string) bool {
+	if len(encoded) != sha256.Size*2 {
+		return false
+	}
+
+	_, err := hex.DecodeString(encoded)
+	return err == nil
 }
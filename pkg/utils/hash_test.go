@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"BizMart/internal/app/models"
+	"BizMart/internal/security"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// useArgonParams задаёт параметры хеширования на время теста, маленькая память ускоряет тесты
+func useArgonParams(t *testing.T, params models.PasswordHashParams) {
+	t.Helper()
+
+	previous := security.AppSettings.PasswordHashParams
+	security.AppSettings.PasswordHashParams = params
+	t.Cleanup(func() { security.AppSettings.PasswordHashParams = previous })
+}
+
+func legacyHash(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerifyPassword(t *testing.T) {
+	useArgonParams(t, models.PasswordHashParams{MemoryKiB: 1024, Iterations: 1, Parallelism: 1})
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash format %q", hash)
+	}
+
+	parts := strings.Split(hash, "$")
+
+	tests := []struct {
+		name            string
+		password        string
+		encoded         string
+		wantMatch       bool
+		wantNeedsRehash bool
+	}{
+		{name: "argon2id round trip", password: "correct horse", encoded: hash, wantMatch: true},
+		{name: "argon2id wrong password", password: "wrong horse", encoded: hash},
+		{name: "legacy hash matches and is upgraded", password: "correct horse", encoded: legacyHash("correct horse"), wantMatch: true, wantNeedsRehash: true},
+		{name: "legacy upper-case hash matches", password: "correct horse", encoded: strings.ToUpper(legacyHash("correct horse")), wantMatch: true, wantNeedsRehash: true},
+		{name: "legacy wrong password", password: "wrong horse", encoded: legacyHash("correct horse")},
+		{name: "unknown user", password: "correct horse", encoded: ""},
+		{name: "too few parts", password: "correct horse", encoded: strings.Join(parts[:5], "$")},
+		{name: "other algorithm", password: "correct horse", encoded: strings.Replace(hash, "argon2id", "argon2i", 1)},
+		{name: "other version", password: "correct horse", encoded: strings.Replace(hash, "v=19", "v=16", 1)},
+		{name: "zero memory", password: "correct horse", encoded: strings.Replace(hash, "m=1024", "m=0", 1)},
+		{name: "broken parameters", password: "correct horse", encoded: strings.Replace(hash, "m=1024,t=1,p=1", "m=x", 1)},
+		{name: "broken salt", password: "correct horse", encoded: strings.Join([]string{"", parts[1], parts[2], parts[3], "!!", parts[5]}, "$")},
+		{name: "empty key", password: "correct horse", encoded: strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], ""}, "$")},
+		{name: "not hex of sha-256 length", password: "correct horse", encoded: strings.Repeat("z", 64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash := VerifyPassword(tt.password, tt.encoded)
+			if match != tt.wantMatch || needsRehash != tt.wantNeedsRehash {
+				t.Fatalf("expected match %v and rehash %v, got %v and %v", tt.wantMatch, tt.wantNeedsRehash, match, needsRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyPasswordRehashesOnParamsChange(t *testing.T) {
+	useArgonParams(t, models.PasswordHashParams{MemoryKiB: 1024, Iterations: 1, Parallelism: 1})
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useArgonParams(t, models.PasswordHashParams{MemoryKiB: 2048, Iterations: 1, Parallelism: 1})
+
+	if match, needsRehash := VerifyPassword("correct horse", hash); !match || !needsRehash {
+		t.Fatalf("expected a match that needs a rehash, got %v and %v", match, needsRehash)
+	}
+
+	if match, needsRehash := VerifyPassword("wrong horse", hash); match || needsRehash {
+		t.Fatalf("expected a wrong password not to be rehashed, got %v and %v", match, needsRehash)
+	}
+}
+
+func TestHashPasswordUsesRandomSalt(t *testing.T) {
+	useArgonParams(t, models.PasswordHashParams{MemoryKiB: 1024, Iterations: 1, Parallelism: 1})
+
+	first, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Fatal("expected different hashes for the same password")
+	}
+}
@@ -9,42 +9,79 @@ import (
 	"time"
 )
 
-// CustomClaims определяет кастомные поля токена
+const (
+	AccessTokenTTL  = time.Hour
+	RefreshTokenTTL = 72 * time.Hour
+
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// CustomClaims определяет кастомные поля токена.
+// SessionID — сессия, которой выдан токен, у refresh-токена Id — номер токена в сессии.
+// Roles — коды ролей пользователя на момент выдачи токена.
+// IssuedAtMicro — время выдачи в микросекундах: iat хранит целые секунды, и токен, выданный в ту же секунду,
+// что и смена пароля, нельзя было бы отличить от выданного после неё
 type CustomClaims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID        uint     `json:"user_id"`
+	Username      string   `json:"username"`
+	Roles         []string `json:"roles,omitempty"`
+	SessionID     uint     `json:"sid,omitempty"`
+	TokenType     string   `json:"token_type,omitempty"`
+	IssuedAtMicro int64    `json:"iat_us,omitempty"`
 	jwt.StandardClaims
 }
 
-// GenerateToken генерирует JWT токен с кастомными полями
-func GenerateToken(userID uint, username string) (string, string, error) {
+// IssuedAtMicros возвращает время выдачи токена в микросекундах.
+// У токенов без iat_us берётся начало секунды iat, поэтому при сравнении они считаются выданными как можно раньше
+func (c *CustomClaims) IssuedAtMicros() int64 {
+	if c.IssuedAtMicro != 0 {
+		return c.IssuedAtMicro
+	}
+	return c.IssuedAt * int64(time.Second/time.Microsecond)
+}
+
+func signToken(claims *CustomClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+}
+
+// GenerateToken генерирует access- и refresh-токены сессии. refreshID записывается в refresh-токен,
+// по нему сессия отличает действующий refresh-токен от уже использованного
+func GenerateToken(userID uint, username string, roles []string, sessionID uint, refreshID string) (string, string, error) {
+	now := time.Now()
+
 	// Access token
-	claims := &CustomClaims{
-		UserID:   userID,
-		Username: username,
+	accessTokenString, err := signToken(&CustomClaims{
+		UserID:        userID,
+		Username:      username,
+		Roles:         roles,
+		SessionID:     sessionID,
+		TokenType:     TokenTypeAccess,
+		IssuedAtMicro: now.UnixMicro(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 60).Unix(), // токен истекает через 1 час
+			ExpiresAt: now.Add(AccessTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
 			Issuer:    security.AppSettings.AppParams.ServerName,
 		},
-	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	accessTokenString, err := accessToken.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+	})
 	if err != nil {
 		return "", "", err
 	}
 
 	// Refresh token
-	refreshClaims := &CustomClaims{
-		UserID:   userID,
-		Username: username,
+	refreshTokenString, err := signToken(&CustomClaims{
+		UserID:        userID,
+		Username:      username,
+		SessionID:     sessionID,
+		TokenType:     TokenTypeRefresh,
+		IssuedAtMicro: now.UnixMicro(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 72).Unix(), // токен истекает через 72 часа
+			Id:        refreshID,
+			ExpiresAt: now.Add(RefreshTokenTTL).Unix(),
+			IssuedAt:  now.Unix(),
 			Issuer:    security.AppSettings.AppParams.ServerName,
 		},
-	}
-
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(os.Getenv("JWT_SECRET_KEY")))
+	})
 	if err != nil {
 		return "", "", err
 	}
@@ -72,3 +109,23 @@ func ParseToken(tokenString string) (*CustomClaims, error) {
 
 	return nil, errs.ErrInvalidToken
 }
+
+// ParseAccessToken парсит access-токен. Refresh-токен не принимается: раньше у токенов не было типа,
+// поэтому токен без типа, живущий дольше access-токена, считается старым refresh-токеном
+func ParseAccessToken(tokenString string) (*CustomClaims, error) {
+	claims, err := ParseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	switch claims.TokenType {
+	case TokenTypeAccess:
+		return claims, nil
+	case "":
+		if time.Until(time.Unix(claims.ExpiresAt, 0)) <= AccessTokenTTL {
+			return claims, nil
+		}
+	}
+
+	return nil, errs.ErrInvalidToken
+}
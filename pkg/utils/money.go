@@ -0,0 +1,13 @@
+package utils
+
+import "math"
+
+// ToMinorUnits переводит сумму в минимальные единицы валюты (копейки, центы)
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromMinorUnits переводит сумму из минимальных единиц обратно в основные
+func FromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}